@@ -0,0 +1,13 @@
+package scheduler
+
+type BackoffStrategy string
+
+const (
+	FIXED       = BackoffStrategy("FIXED")
+	EXPONENTIAL = BackoffStrategy("EXPONENTIAL")
+)
+
+// String returns the backoff strategy in string notation
+func (b BackoffStrategy) String() string {
+	return string(b)
+}
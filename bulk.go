@@ -0,0 +1,48 @@
+package scheduler
+
+import "time"
+
+// CancelMany cancels every job found given the finder, setting them as CANCELED.
+//
+// If the library was configured as DeleteOnCancel, the jobs are deleted instead.
+//
+// RUNNING jobs are skipped, and the finder sorting and pagination values are ignored.
+//
+// Returns how many jobs were canceled.
+func (s *Scheduler) CancelMany(f Finder) (int, error) {
+	if s.deleteOnCancel {
+		return s.DeleteMany(f)
+	}
+
+	ctx, cancel := s.dbContext()
+	defer cancel()
+
+	return s.db.CancelMany(ctx, f)
+}
+
+// DeleteMany deletes every job found given the finder from the database.
+//
+// RUNNING jobs are skipped, and the finder sorting and pagination values are ignored.
+//
+// Returns how many jobs were deleted.
+func (s *Scheduler) DeleteMany(f Finder) (int, error) {
+	ctx, cancel := s.dbContext()
+	defer cancel()
+
+	return s.db.DeleteMany(ctx, f)
+}
+
+// RescheduleMany moves the next run date of every job found given the finder by the delta duration
+// (Ex.: an hour later with time.Hour, or an hour earlier with -time.Hour).
+//
+// Unlike Reschedule, the jobs status and attempts are kept.
+//
+// RUNNING jobs are skipped, and the finder sorting and pagination values are ignored.
+//
+// Returns how many jobs were re-scheduled.
+func (s *Scheduler) RescheduleMany(f Finder, delta time.Duration) (int, error) {
+	ctx, cancel := s.dbContext()
+	defer cancel()
+
+	return s.db.RescheduleMany(ctx, f, delta)
+}
@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancelMany(t *testing.T) {
+	t.Run("Should cancel the jobs found given the finder", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		dbMock.SetMethodResponse("CancelMany", 3, nil)
+
+		f := Finder{Data: map[string]any{"tenant": "MYMOCKTENANT"}}
+		canceled, err := s.CancelMany(f)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, canceled)
+		assert.True(t, dbMock.Method("CancelMany").CalledWith(f))
+		assert.False(t, dbMock.Method("DeleteMany").Called())
+	})
+	t.Run("Should delete the jobs instead if the library is configured to delete canceled jobs", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+		s.deleteOnCancel = true
+
+		dbMock.SetMethodResponse("DeleteMany", 3, nil)
+
+		f := Finder{Name: "MYMOCKJOB!"}
+		canceled, err := s.CancelMany(f)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, canceled)
+		assert.True(t, dbMock.Method("DeleteMany").CalledWith(f))
+		assert.False(t, dbMock.Method("CancelMany").Called())
+	})
+	t.Run("Should return the database error", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		dbMock.SetMethodResponse("CancelMany", 0, errors.New("MOCK ERROR!"))
+
+		_, err := s.CancelMany(Finder{})
+		assert.EqualError(t, err, "MOCK ERROR!")
+	})
+}
+
+func TestDeleteMany(t *testing.T) {
+	t.Run("Should delete the jobs found given the finder", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		dbMock.SetMethodResponse("DeleteMany", 2, nil)
+
+		f := Finder{Statuses: []ScheduleStatus{DONE, CANCELED}}
+		deleted, err := s.DeleteMany(f)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, deleted)
+		assert.True(t, dbMock.Method("DeleteMany").CalledWith(f))
+	})
+}
+
+func TestRescheduleMany(t *testing.T) {
+	t.Run("Should move the jobs found given the finder by the delta", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		dbMock.SetMethodResponse("RescheduleMany", 5, nil)
+
+		f := Finder{Name: "MYMOCKJOB!", Status: "PENDING"}
+		rescheduled, err := s.RescheduleMany(f, -time.Hour)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 5, rescheduled)
+		assert.True(t, dbMock.Method("RescheduleMany").CalledWith(f, -time.Hour))
+	})
+}
@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// Clock represents the source of the current time and of the tickers used by the library.
+//
+// The library uses the system clock by default,
+// a fake clock can be provided to test schedules deterministically (See schedulertest.FakeClock).
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+
+	// NewTicker returns a ticker that ticks every d duration, as time.NewTicker does
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker represents a ticker created by a Clock
+type Ticker interface {
+	// C returns the channel on which the ticks are delivered
+	C() <-chan time.Time
+
+	// Stop turns off the ticker, no more ticks are delivered after it
+	Stop()
+}
+
+// systemClock its the clock that uses the system time
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTicker struct {
+	*time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// nowKey its the context key of the current time of the scheduler that made the database call
+type nowKey struct{}
+
+// NowFromContext returns the current time of the scheduler clock that made the database call,
+// or the system time if the context did not come from a scheduler.
+//
+// Job databases should use it instead of time.Now when looking up the expired schedules and leases,
+// so that they follow the clock configured on the library (Ex.: a fake clock on the tests).
+func NowFromContext(ctx context.Context) time.Time {
+	if now, ok := ctx.Value(nowKey{}).(time.Time); ok {
+		return now
+	}
+
+	return time.Now()
+}
+
+// withNow returns a copy of the context that carries the current time
+func withNow(ctx context.Context, now time.Time) context.Context {
+	return context.WithValue(ctx, nowKey{}, now)
+}
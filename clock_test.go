@@ -0,0 +1,214 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/delivery-much/go-scheduler"
+	"github.com/delivery-much/go-scheduler/schedulertest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeClockScheduling(t *testing.T) {
+	ctx := context.Background()
+	// its a monday
+	start := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("Should run the recurrent job only when the clock reaches its schedule", func(t *testing.T) {
+		clock := schedulertest.NewFakeClock(start)
+		s, err := scheduler.New(scheduler.Config{DB: scheduler.NewMemoryJobDB(), Clock: clock})
+		assert.NoError(t, err)
+		defer s.Stop()
+
+		var runs int32
+		s.Define("MYMOCKJOB!", func(ctx context.Context, j *scheduler.Job) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		})
+		j, err := s.Every("monday at 12:00").Do("MYMOCKJOB!")
+		assert.NoError(t, err)
+		assert.Equal(t, start.Add(3*time.Hour), j.NextRunAt)
+
+		clock.Advance(2 * time.Hour)
+		assert.NoError(t, s.ProcessCycle(ctx))
+		assert.Equal(t, int32(0), atomic.LoadInt32(&runs))
+
+		clock.Advance(time.Hour)
+		assert.NoError(t, s.ProcessCycle(ctx))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+
+		j, _ = s.Get(j.ID)
+		assert.Equal(t, scheduler.PENDING, j.Status)
+		assert.Equal(t, start.Add(3*time.Hour).AddDate(0, 0, 7), j.NextRunAt)
+		assert.Equal(t, start.Add(3*time.Hour), *j.LastRunAt)
+
+		clock.Advance(7 * 24 * time.Hour)
+		assert.NoError(t, s.ProcessCycle(ctx))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&runs))
+	})
+	t.Run("Should keep a month interval anchored on the day it was scheduled, counting from the previous run", func(t *testing.T) {
+		jan31 := time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC)
+		clock := schedulertest.NewFakeClock(jan31)
+		s, err := scheduler.New(scheduler.Config{DB: scheduler.NewMemoryJobDB(), Clock: clock})
+		assert.NoError(t, err)
+		defer s.Stop()
+
+		s.Define("MYMOCKJOB!", func(ctx context.Context, j *scheduler.Job) error { return nil })
+		j, err := s.Every("1 month").Do("MYMOCKJOB!")
+		assert.NoError(t, err)
+		assert.Equal(t, jan31, *j.ScheduleAnchor)
+		assert.Equal(t, time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC), j.NextRunAt)
+
+		clock.Set(j.NextRunAt)
+		assert.NoError(t, s.ProcessCycle(ctx))
+		j, _ = s.Get(j.ID)
+		assert.Equal(t, time.Date(2024, time.March, 31, 9, 0, 0, 0, time.UTC), j.NextRunAt)
+
+		// a late run does not move the next ones
+		clock.Set(j.NextRunAt.Add(30 * time.Minute))
+		assert.NoError(t, s.ProcessCycle(ctx))
+		j, _ = s.Get(j.ID)
+		assert.Equal(t, time.Date(2024, time.April, 30, 9, 0, 0, 0, time.UTC), j.NextRunAt)
+	})
+	t.Run("Should anchor a job saved without a schedule anchor on its scheduled run, even if the run is retried", func(t *testing.T) {
+		jan31 := time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC)
+		clock := schedulertest.NewFakeClock(jan31)
+		db := scheduler.NewMemoryJobDB()
+		s, err := scheduler.New(scheduler.Config{DB: db, Clock: clock})
+		assert.NoError(t, err)
+		defer s.Stop()
+
+		var runs int32
+		s.Define("MYMOCKJOB!", func(ctx context.Context, j *scheduler.Job) error {
+			if atomic.AddInt32(&runs, 1) == 1 {
+				return errors.New("MOCK ERROR")
+			}
+			return nil
+		})
+
+		// a job saved before the schedule anchor existed
+		id, err := db.SaveJob(ctx, scheduler.Job{
+			Name:           "MYMOCKJOB!",
+			ScheduleType:   scheduler.RECURRENT,
+			Status:         scheduler.PENDING,
+			ScheduleString: "1 month",
+			NextRunAt:      jan31,
+			RetryPolicy:    &scheduler.RetryPolicy{MaxAttempts: 3, Delay: time.Hour},
+		})
+		assert.NoError(t, err)
+
+		assert.NoError(t, s.ProcessCycle(ctx))
+		j, _ := s.Get(id)
+		assert.Equal(t, jan31, *j.ScheduleAnchor)
+		assert.Equal(t, jan31.Add(time.Hour), j.NextRunAt)
+
+		// the retry delay does not move the next runs
+		clock.Set(j.NextRunAt)
+		assert.NoError(t, s.ProcessCycle(ctx))
+		j, _ = s.Get(id)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&runs))
+		assert.Equal(t, jan31, *j.ScheduleAnchor)
+		assert.Equal(t, time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC), j.NextRunAt)
+	})
+	t.Run("Should run every due job, even if there are more due jobs than workers", func(t *testing.T) {
+		clock := schedulertest.NewFakeClock(start)
+		s, err := scheduler.New(scheduler.Config{DB: scheduler.NewMemoryJobDB(), Clock: clock})
+		assert.NoError(t, err)
+		defer s.Stop()
+
+		var runs int32
+		s.Define("MYMOCKJOB!", func(ctx context.Context, j *scheduler.Job) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		})
+		ids := []string{}
+		for i := 0; i < 3; i++ {
+			j, err := s.In(time.Duration(i+1) * time.Minute).Do("MYMOCKJOB!")
+			assert.NoError(t, err)
+			ids = append(ids, j.ID)
+		}
+
+		clock.Advance(time.Hour)
+		assert.NoError(t, s.ProcessCycle(ctx))
+		assert.Equal(t, int32(3), atomic.LoadInt32(&runs))
+		for _, id := range ids {
+			j, _ := s.Get(id)
+			assert.Equal(t, scheduler.DONE, j.Status)
+		}
+	})
+	t.Run("Should run every due job, even if there are more due jobs than the job definition concurrency", func(t *testing.T) {
+		clock := schedulertest.NewFakeClock(start)
+		s, err := scheduler.New(scheduler.Config{DB: scheduler.NewMemoryJobDB(), Clock: clock, Concurrency: 5})
+		assert.NoError(t, err)
+		defer s.Stop()
+
+		var runs, running, maxRunning int32
+		s.Define("MYMOCKJOB!", func(ctx context.Context, j *scheduler.Job) error {
+			if n := atomic.AddInt32(&running, 1); n > atomic.LoadInt32(&maxRunning) {
+				atomic.StoreInt32(&maxRunning, n)
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			atomic.AddInt32(&runs, 1)
+			return nil
+		}, scheduler.DefinitionOptions{Concurrency: 1})
+		for i := 0; i < 3; i++ {
+			_, err := s.In(time.Minute).Do("MYMOCKJOB!")
+			assert.NoError(t, err)
+		}
+
+		clock.Advance(time.Minute)
+		assert.NoError(t, s.ProcessCycle(ctx))
+		assert.Equal(t, int32(3), atomic.LoadInt32(&runs))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&maxRunning))
+	})
+	t.Run("Should fail if the running jobs do not finish before the context is done", func(t *testing.T) {
+		clock := schedulertest.NewFakeClock(start)
+		s, err := scheduler.New(scheduler.Config{DB: scheduler.NewMemoryJobDB(), Clock: clock})
+		assert.NoError(t, err)
+		defer s.Stop()
+
+		release := make(chan struct{})
+		s.Define("MYMOCKJOB!", func(ctx context.Context, j *scheduler.Job) error {
+			<-release
+			return nil
+		})
+		s.In(time.Minute).Do("MYMOCKJOB!")
+		clock.Advance(time.Minute)
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		err = s.ProcessCycle(timeoutCtx)
+		assert.ErrorContains(t, err, "Failed to wait for the running jobs")
+
+		close(release)
+	})
+	t.Run("Should not run any job once the scheduler is stopped", func(t *testing.T) {
+		clock := schedulertest.NewFakeClock(start)
+		s, err := scheduler.New(scheduler.Config{DB: scheduler.NewMemoryJobDB(), Clock: clock, Concurrency: 20})
+		assert.NoError(t, err)
+
+		var runs int32
+		s.Define("MYMOCKJOB!", func(ctx context.Context, j *scheduler.Job) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		})
+		for i := 0; i < 20; i++ {
+			s.In(time.Minute).Do("MYMOCKJOB!")
+		}
+		clock.Advance(time.Minute)
+
+		s.Stop()
+		assert.Error(t, s.ProcessCycle(ctx))
+		assert.Equal(t, int32(0), atomic.LoadInt32(&runs))
+	})
+}
+
+func TestNowFromContext(t *testing.T) {
+	t.Run("Should return the system time if the context did not come from a scheduler", func(t *testing.T) {
+		assert.WithinDuration(t, time.Now(), scheduler.NowFromContext(context.Background()), time.Second)
+	})
+}
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"database/sql"
 	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
@@ -16,6 +17,13 @@ type Config struct {
 	// If no user created DB is specified, the MongoDB value should be provided
 	DB JobDatabase
 
+	// DBTimeout represents the maximum duration of each call to the job database.
+	//
+	// When the duration is exceeded, the context provided to the JobDatabase method is cancelled.
+	//
+	// Default: 30 seconds
+	DBTimeout time.Duration
+
 	// Logger represents a user created struct that implements the Logger interface.
 	//
 	// This logger will be used to log information when managing jobs.
@@ -31,16 +39,109 @@ type Config struct {
 	// If this value is not specified, the DB value should be provided.
 	MongoDB *MongoJobDBConfig
 
+	// Postgres represents the configuration values that the library need to start a job DB on a PostgreSQL connection.
+	// This configuration uses the standard database/sql package, with any PostgreSQL driver (Ex.: pgx or lib/pq).
+	//
+	// When providing this configuration, the library will have access to the user's PostgreSQL connection,
+	// since it will be responsible to create its tables and manage jobs.
+	//
+	// If this value is not specified, the DB or MongoDB value should be provided.
+	Postgres *PostgresJobDBConfig
+
+	// Redis represents the configuration values that the library need to start a job DB on a Redis connection.
+	// This configuration uses any Redis client, through a RedisClient adapter (Ex.: over go-redis).
+	//
+	// When providing this configuration, the library will have access to the user's Redis connection,
+	// since it will be responsible to manage jobs.
+	Redis *RedisJobDBConfig
+
+	// File represents the configuration values that the library need to start a job DB persisted on a local file,
+	// for applications that need persistence without running a database server (Ex.: CLI tools).
+	//
+	// The file should not be shared between multiple processes (See FileJobDB).
+	File *FileJobDBConfig
+
+	// Clock represents the source of the current time and of the tickers used by the library.
+	//
+	// Users can provide a fake clock (See schedulertest.FakeClock) to test their schedules deterministically,
+	// along with Scheduler.ProcessCycle to process the due jobs synchronously.
+	//
+	// Default: the system clock
+	Clock Clock
+
 	// ProcessingRate represents the rate that the library will process jobs.
 	//
 	// Defaut: 1 minute
 	ProcessingRate time.Duration
 
+	// Concurrency represents how many jobs this scheduler instance can run at the same time.
+	//
+	// Jobs run in a pool of workers, so that a slow job does not delay the other due jobs.
+	// When every worker is busy, the processing cycle waits for a free worker to run the other due jobs.
+	//
+	// Default: 1
+	Concurrency int
+
 	// Location represents the location that the library should use when generating time values.
 	//
 	// Default: UTC
 	Location string
 
+	// JobTimeout defines how long a job function can run before its context is cancelled and the run is considered TIMED_OUT.
+	//
+	// Timeouts can also be set per job definition (See DefinitionOptions) or per job schedule (Ex.: In(time.Hour).Timeout(...)).
+	//
+	// A job function that does not return within 5 seconds after its context is cancelled is abandoned:
+	// its worker is freed to run other jobs, while the function keeps running on the background until it returns.
+	// So job functions should always return as soon as their context is done.
+	//
+	// Default: no timeout
+	JobTimeout time.Duration
+
+	// RetryPolicy defines how jobs should be retried when their job function fails.
+	//
+	// Jobs scheduled with their own retry policy (Ex.: In(time.Hour).Retry(...)) will use their own policy instead.
+	//
+	// Default: no retries, the job is set as FAILED on the first error
+	RetryPolicy *RetryPolicy
+
+	// WorkerID identifies this scheduler instance when claiming jobs to run.
+	//
+	// When running multiple instances of the application against the same job database,
+	// each instance must have a unique worker ID, so that a job is only ran by the instance that claimed it.
+	//
+	// Default: the machine hostname followed by the process ID (Ex.: "my-pod-7d9f-1")
+	WorkerID string
+
+	// LeaseDuration represents for how long a scheduler instance holds a job that it claimed to run.
+	//
+	// While the job runs, its lease is renewed every third of this duration.
+	// If the instance dies mid-run, the lease expires and the job is recovered by the next processing cycle.
+	//
+	// Default: 5 minutes
+	LeaseDuration time.Duration
+
+	// MaxOrphanings defines how many times a job can have its lease expired while running before it is set as FAILED.
+	// Until then, orphaned jobs are set back as PENDING to run again.
+	//
+	// If set to a negative value, orphaned jobs will always be set back as PENDING.
+	//
+	// Default: 3
+	MaxOrphanings int
+
+	// RunHistory defines if every job run should be recorded on the job database,
+	// with its start, end, outcome, error, attempt and worker ID (See ListRuns).
+	//
+	// Default: false
+	RunHistory bool
+
+	// RunRetention defines for how long the recorded job runs are kept on the job database, when the RunHistory is enabled.
+	//
+	// Older runs are deleted by the processing cycles.
+	//
+	// Default: runs are kept forever
+	RunRetention time.Duration
+
 	// DeleteOnDone defines if, when a job is done, the job should be deleted from the database.
 	//
 	// Default: false
@@ -65,4 +166,46 @@ type MongoJobDBConfig struct {
 	//
 	// Default: scheduler-jobs
 	CollName string
+
+	// RunsCollName its the collection name that the library should use to save the job runs, when the run history is enabled
+	//
+	// Default: scheduler-runs
+	RunsCollName string
+}
+
+type PostgresJobDBConfig struct {
+	// Conn its the PostgreSQL connection pool that the user can provide.
+	Conn *sql.DB
+
+	// TableName its the table name that the library should use to save jobs.
+	// It can be qualified by a schema (Ex.: "my_schema.scheduler_jobs").
+	//
+	// Default: scheduler_jobs
+	TableName string
+
+	// RunsTableName its the table name that the library should use to save the job runs, when the run history is enabled.
+	// It can be qualified by a schema (Ex.: "my_schema.scheduler_runs").
+	//
+	// Default: scheduler_runs
+	RunsTableName string
+}
+
+type RedisJobDBConfig struct {
+	// Client its the Redis client that the user can provide.
+	Client RedisClient
+
+	// Prefix its the prefix of every key that the library should use to save jobs.
+	//
+	// Since the jobs are written by Lua scripts that touch many keys at once,
+	// on a Redis Cluster the prefix should be a hash tag (Ex.: "{my-app-jobs}"), so that every key is on the same slot.
+	//
+	// Default: {scheduler}
+	Prefix string
+}
+
+type FileJobDBConfig struct {
+	// Path its the path of the file that the library should use to save jobs.
+	//
+	// The file is created if it does not exist, but its directory should already exist.
+	Path string
 }
@@ -0,0 +1,20 @@
+package scheduler
+
+// ConflictPolicy defines what happens when a job is scheduled with a unique key that is already used by another job
+type ConflictPolicy string
+
+const (
+	// KEEP keeps the existing job, and the new job is not saved.
+	// The existing job is kept whatever its status is, so once it is DONE, FAILED or CANCELED,
+	// the key is held until the job is deleted, and no new job is scheduled with it
+	KEEP = ConflictPolicy("KEEP")
+	// REPLACE replaces the existing job with the new job, keeping the existing job ID
+	REPLACE = ConflictPolicy("REPLACE")
+	// REJECT keeps the existing job, and returns ErrDuplicateJob
+	REJECT = ConflictPolicy("REJECT")
+)
+
+// String returns the conflict policy in string notation
+func (c ConflictPolicy) String() string {
+	return string(c)
+}
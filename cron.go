@@ -0,0 +1,470 @@
+package scheduler
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// cronMacros is a mapping of the cron macros to their respective cron expressions
+	cronMacros = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+
+	// cronMonths is a mapping of the month names accepted on cron expressions to their respective values
+	cronMonths = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+
+	// cronWeekdays is a mapping of the weekday names accepted on cron expressions to their respective values
+	cronWeekdays = map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+
+	// cronFieldRegex matches the characters that can be used on a cron expression field
+	cronFieldRegex = regexp.MustCompile(`^[0-9A-Za-z*?,/#-]+$`)
+
+	// cronSearchLimit represents how many years ahead the next occurrence of a cron expression is searched for
+	cronSearchLimit = 5
+)
+
+// cronField represents the bounds of a cron expression field
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	secondField  = cronField{"second", 0, 59, nil}
+	minuteField  = cronField{"minute", 0, 59, nil}
+	hourField    = cronField{"hour", 0, 23, nil}
+	domField     = cronField{"day of month", 1, 31, nil}
+	monthField   = cronField{"month", 1, 12, cronMonths}
+	weekdayField = cronField{"day of week", 0, 7, cronWeekdays}
+)
+
+// cronNthWeekday represents the nth weekday of the month (Ex.: "MON#2", the second monday of the month)
+type cronNthWeekday struct {
+	weekday time.Weekday
+	n       int
+}
+
+// cronSchedule represents a parsed cron expression
+type cronSchedule struct {
+	second, minute, hour, month uint64
+
+	// dom represents the days of the month
+	dom uint64
+	// domStar defines if the day of month field is unrestricted ("*" or "?")
+	domStar bool
+	// lastDom defines if the last day of the month matches ("L")
+	lastDom bool
+	// lastWeekdayDom defines if the last weekday (monday to friday) of the month matches ("LW")
+	lastWeekdayDom bool
+	// nearestWeekdayDom represents the days of the month which nearest weekday matches (Ex.: "15W")
+	nearestWeekdayDom uint64
+
+	// dow represents the days of the week
+	dow uint64
+	// dowStar defines if the day of week field is unrestricted ("*" or "?")
+	dowStar bool
+	// lastDow represents the days of the week that match on their last occurrence in the month (Ex.: "5L")
+	lastDow uint64
+	// nthDow represents the nth days of the week of the month that match (Ex.: "MON#2")
+	nthDow []cronNthWeekday
+}
+
+// isCronExpression returns true if the schedule string looks like a cron expression
+func isCronExpression(schedule string) bool {
+	schedule = strings.TrimSpace(schedule)
+	if strings.HasPrefix(schedule, "@") {
+		return true
+	}
+
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 && len(fields) != 6 {
+		return false
+	}
+
+	for _, f := range fields {
+		if !cronFieldRegex.MatchString(f) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseCronExpression parses a 5 field (minute, hour, day of month, month, day of week)
+// or 6 field (second, minute, hour, day of month, month, day of week) cron expression, or a cron macro (Ex.: "@daily")
+func parseCronExpression(expression string) (cs *cronSchedule, err error) {
+	expr := strings.TrimSpace(expression)
+	if strings.HasPrefix(expr, "@") {
+		macro, found := cronMacros[strings.ToLower(expr)]
+		if !found {
+			return nil, fmt.Errorf("Failed to parse cron expression '%s', unknown macro: %s", expression, expr)
+		}
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("Failed to parse cron expression '%s', expected 5 or 6 fields, found %d", expression, len(fields))
+	}
+
+	cs = &cronSchedule{}
+
+	parsers := []struct {
+		field cronField
+		value *uint64
+	}{
+		{secondField, &cs.second},
+		{minuteField, &cs.minute},
+		{hourField, &cs.hour},
+		{monthField, &cs.month},
+	}
+	indexes := []int{0, 1, 2, 4}
+	for i, p := range parsers {
+		*p.value, err = parseCronField(fields[indexes[i]], p.field)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse cron expression '%s', %v", expression, err)
+		}
+	}
+
+	err = cs.parseDom(fields[3])
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse cron expression '%s', %v", expression, err)
+	}
+
+	err = cs.parseDow(fields[5])
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse cron expression '%s', %v", expression, err)
+	}
+
+	return
+}
+
+// parseDom parses the day of month field, that also accepts the "?", "L", "LW" and "W" values
+func (cs *cronSchedule) parseDom(field string) error {
+	if field == "*" || field == "?" {
+		cs.domStar = true
+		cs.dom = bitRange(domField.min, domField.max)
+		return nil
+	}
+
+	for _, part := range strings.Split(field, ",") {
+		upper := strings.ToUpper(part)
+
+		switch {
+		case upper == "L":
+			cs.lastDom = true
+
+		case upper == "LW":
+			cs.lastWeekdayDom = true
+
+		case strings.HasSuffix(upper, "W"):
+			day, err := parseCronValue(strings.TrimSuffix(upper, "W"), domField)
+			if err != nil {
+				return err
+			}
+			cs.nearestWeekdayDom |= 1 << uint(day)
+
+		default:
+			bits, err := parseCronField(part, domField)
+			if err != nil {
+				return err
+			}
+			cs.dom |= bits
+		}
+	}
+
+	return nil
+}
+
+// parseDow parses the day of week field, that also accepts the "?", "L" and "#" values
+func (cs *cronSchedule) parseDow(field string) error {
+	if field == "*" || field == "?" {
+		cs.dowStar = true
+		cs.dow = bitRange(0, 6)
+		return nil
+	}
+
+	for _, part := range strings.Split(field, ",") {
+		upper := strings.ToUpper(part)
+
+		switch {
+		case strings.Contains(upper, "#"):
+			values := strings.SplitN(upper, "#", 2)
+			weekday, err := parseCronValue(values[0], weekdayField)
+			if err != nil {
+				return err
+			}
+
+			n, err := strconv.Atoi(values[1])
+			if err != nil || n < 1 || n > 5 {
+				return fmt.Errorf("invalid %s: %s, the occurrence after '#' should be between 1 and 5", weekdayField.name, part)
+			}
+
+			cs.nthDow = append(cs.nthDow, cronNthWeekday{time.Weekday(weekday % 7), n})
+
+		case len(upper) > 1 && strings.HasSuffix(upper, "L"):
+			weekday, err := parseCronValue(strings.TrimSuffix(upper, "L"), weekdayField)
+			if err != nil {
+				return err
+			}
+			cs.lastDow |= 1 << uint(weekday%7)
+
+		default:
+			bits, err := parseCronField(part, weekdayField)
+			if err != nil {
+				return err
+			}
+
+			// 7 is also sunday
+			if bits&(1<<7) != 0 {
+				bits = bits&^(1<<7) | 1
+			}
+			cs.dow |= bits
+		}
+	}
+
+	return nil
+}
+
+// parseCronField parses a list of ranges and steps (Ex.: "1-5", "*/15", "0,30", "MON-FRI") into a bitset
+func parseCronField(field string, cf cronField) (bits uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		var b uint64
+		b, err = parseCronRange(part, cf)
+		if err != nil {
+			return
+		}
+
+		bits |= b
+	}
+
+	return
+}
+
+// parseCronRange parses a single range with an optional step (Ex.: "*", "1-5", "*/15", "10/5") into a bitset
+func parseCronRange(part string, cf cronField) (uint64, error) {
+	rangeAndStep := strings.SplitN(part, "/", 2)
+
+	start, end := cf.min, cf.max
+	step := 1
+
+	switch r := rangeAndStep[0]; {
+	case r == "*" || r == "?":
+
+	case strings.Contains(r, "-"):
+		bounds := strings.SplitN(r, "-", 2)
+
+		var err error
+		start, err = parseCronValue(bounds[0], cf)
+		if err != nil {
+			return 0, err
+		}
+
+		end, err = parseCronValue(bounds[1], cf)
+		if err != nil {
+			return 0, err
+		}
+
+	default:
+		var err error
+		start, err = parseCronValue(r, cf)
+		if err != nil {
+			return 0, err
+		}
+
+		// a single value with no step only matches itself
+		if len(rangeAndStep) == 1 {
+			end = start
+		}
+	}
+
+	if len(rangeAndStep) == 2 {
+		var err error
+		step, err = strconv.Atoi(rangeAndStep[1])
+		if err != nil || step < 1 {
+			return 0, fmt.Errorf("invalid %s step: %s", cf.name, part)
+		}
+	}
+
+	if start > end {
+		return 0, fmt.Errorf("invalid %s range: %s, the start is greater than the end", cf.name, part)
+	}
+
+	var bits uint64
+	for i := start; i <= end; i += step {
+		bits |= 1 << uint(i)
+	}
+
+	return bits, nil
+}
+
+// parseCronValue parses a single numeric or named value (Ex.: "5", "JAN", "mon") of a cron expression field
+func parseCronValue(value string, cf cronField) (int, error) {
+	if v, found := cf.names[strings.ToLower(value)]; found {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %s", cf.name, value)
+	}
+
+	if v < cf.min || v > cf.max {
+		return 0, fmt.Errorf("invalid %s: %s, it should be between %d and %d", cf.name, value, cf.min, cf.max)
+	}
+
+	return v, nil
+}
+
+// bitRange returns a bitset with every bit between min and max set
+func bitRange(min, max int) (bits uint64) {
+	for i := min; i <= max; i++ {
+		bits |= 1 << uint(i)
+	}
+
+	return
+}
+
+// hasBit returns true if the bit on the given position is set
+func hasBit(bits uint64, position int) bool {
+	return bits&(1<<uint(position)) != 0
+}
+
+// next returns the next time after now that matches the cron schedule, in the now location.
+//
+// The schedule fields are matched against the wall clock, so when a matching wall clock is skipped by a DST transition,
+// the job runs after the transition (Ex.: 02:30 becomes 03:30), as the other schedule formats do.
+func (cs *cronSchedule) next(now time.Time) (time.Time, error) {
+	loc := now.Location()
+	t := now.Truncate(time.Second).Add(time.Second)
+
+	// wall its the wall clock being matched, that differs from the wall clock of t when it is skipped by a DST transition
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	yearLimit := wall.Year() + cronSearchLimit
+
+	moveTo := func(year int, month time.Month, day, hour, min, sec int) {
+		wall = time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+		t = laterThan(t, dateIn(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), loc))
+	}
+
+	for wall.Year() <= yearLimit {
+		if !hasBit(cs.month, int(wall.Month())) {
+			moveTo(wall.Year(), wall.Month()+1, 1, 0, 0, 0)
+			continue
+		}
+
+		if !cs.matchesDay(wall) {
+			moveTo(wall.Year(), wall.Month(), wall.Day()+1, 0, 0, 0)
+			continue
+		}
+
+		if !hasBit(cs.hour, wall.Hour()) {
+			moveTo(wall.Year(), wall.Month(), wall.Day(), wall.Hour()+1, 0, 0)
+			continue
+		}
+
+		if !hasBit(cs.minute, wall.Minute()) {
+			moveTo(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute()+1, 0)
+			continue
+		}
+
+		if !hasBit(cs.second, wall.Second()) || !t.After(now) {
+			moveTo(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second()+1)
+			continue
+		}
+
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("no date matches the cron expression in the next %d years", cronSearchLimit)
+}
+
+// matchesDay returns true if the day of t matches the day of month and day of week fields.
+//
+// As in the standard cron, when both fields are restricted, the day matches if any of them matches.
+func (cs *cronSchedule) matchesDay(t time.Time) bool {
+	day := t.Day()
+	weekday := t.Weekday()
+	lastDay := daysIn(t.Month(), t.Year())
+
+	domMatches := hasBit(cs.dom, day) ||
+		(cs.lastDom && day == lastDay) ||
+		(cs.lastWeekdayDom && day == lastWeekdayOfMonth(t.Year(), t.Month(), t.Location()))
+	for d := domField.min; d <= domField.max && !domMatches; d++ {
+		if hasBit(cs.nearestWeekdayDom, d) && d <= lastDay {
+			domMatches = day == nearestWeekday(t.Year(), t.Month(), d, t.Location())
+		}
+	}
+
+	dowMatches := hasBit(cs.dow, int(weekday)) ||
+		(hasBit(cs.lastDow, int(weekday)) && day+7 > lastDay)
+	for _, nth := range cs.nthDow {
+		if nth.weekday == weekday && (day-1)/7+1 == nth.n {
+			dowMatches = true
+		}
+	}
+
+	if cs.domStar || cs.dowStar {
+		return domMatches && dowMatches
+	}
+
+	return domMatches || dowMatches
+}
+
+// daysIn returns the number of days of the month in the given year
+func daysIn(m time.Month, year int) int {
+	return time.Date(year, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// lastWeekdayOfMonth returns the last day of the month that is a weekday (monday to friday)
+func lastWeekdayOfMonth(year int, m time.Month, loc *time.Location) int {
+	day := daysIn(m, year)
+	for {
+		switch time.Date(year, m, day, 0, 0, 0, 0, loc).Weekday() {
+		case time.Saturday, time.Sunday:
+			day--
+		default:
+			return day
+		}
+	}
+}
+
+// nearestWeekday returns the weekday (monday to friday) nearest to the given day, without leaving the month
+func nearestWeekday(year int, m time.Month, day int, loc *time.Location) int {
+	lastDay := daysIn(m, year)
+
+	switch time.Date(year, m, day, 0, 0, 0, 0, loc).Weekday() {
+	case time.Saturday:
+		if day == 1 {
+			return day + 2
+		}
+		return day - 1
+	case time.Sunday:
+		if day == lastDay {
+			return day - 2
+		}
+		return day + 1
+	default:
+		return day
+	}
+}
@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronExpression(t *testing.T) {
+	// monday, 2024-01-15 10:20:30
+	now := time.Date(2024, time.January, 15, 10, 20, 30, 0, time.UTC)
+
+	t.Run("Should schedule a 5 field cron expression correctly", func(t *testing.T) {
+		result, err := getNextScheduleDate("*/15 * * * *", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 15, 10, 30, 0, 0, time.UTC), result)
+
+		result, err = getNextScheduleDate("0 9 * * *", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 16, 9, 0, 0, 0, time.UTC), result)
+	})
+	t.Run("Should schedule a 6 field cron expression correctly", func(t *testing.T) {
+		result, err := getNextScheduleDate("*/10 * * * * *", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 15, 10, 20, 40, 0, time.UTC), result)
+
+		result, err = getNextScheduleDate("15 30 9 * * *", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 16, 9, 30, 15, 0, time.UTC), result)
+	})
+	t.Run("Should schedule ranges, lists and names correctly", func(t *testing.T) {
+		result, err := getNextScheduleDate("0 8,12 * * SAT,SUN", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 20, 8, 0, 0, 0, time.UTC), result)
+
+		result, err = getNextScheduleDate("0 0 1 mar *", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), result)
+
+		result, err = getNextScheduleDate("0 12 * * 7", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 21, 12, 0, 0, 0, time.UTC), result)
+
+		result, err = getNextScheduleDate("10 11-15/2 * * *", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 15, 11, 10, 0, 0, time.UTC), result)
+	})
+	t.Run("Should match any of the day fields when both are restricted", func(t *testing.T) {
+		result, err := getNextScheduleDate("0 0 20 * FRI", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 19, 0, 0, 0, 0, time.UTC), result)
+	})
+	t.Run("Should schedule the last day of the month correctly", func(t *testing.T) {
+		result, err := getNextScheduleDate("0 18 L * *", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 31, 18, 0, 0, 0, time.UTC), result)
+
+		result, err = getNextScheduleDate("0 0 L 2 *", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), result)
+	})
+	t.Run("Should schedule the last weekday of the month correctly", func(t *testing.T) {
+		// 2024-03-31 is a sunday
+		result, err := getNextScheduleDate("0 0 LW 3 *", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.March, 29, 0, 0, 0, 0, time.UTC), result)
+	})
+	t.Run("Should schedule the nearest weekday correctly", func(t *testing.T) {
+		// 2024-06-01 is a saturday, and the nearest weekday without leaving the month is monday
+		result, err := getNextScheduleDate("0 0 1W 6 *", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.June, 3, 0, 0, 0, 0, time.UTC), result)
+
+		// 2024-06-16 is a sunday
+		result, err = getNextScheduleDate("0 0 16W 6 *", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.June, 17, 0, 0, 0, 0, time.UTC), result)
+
+		// 2024-06-15 is a saturday
+		result, err = getNextScheduleDate("0 0 15W 6 *", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.June, 14, 0, 0, 0, 0, time.UTC), result)
+	})
+	t.Run("Should schedule the last occurrence of a weekday correctly", func(t *testing.T) {
+		result, err := getNextScheduleDate("0 0 * * 5L", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 26, 0, 0, 0, 0, time.UTC), result)
+	})
+	t.Run("Should schedule the nth occurrence of a weekday correctly", func(t *testing.T) {
+		result, err := getNextScheduleDate("0 12 * * FRI#2", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.February, 9, 12, 0, 0, 0, time.UTC), result)
+
+		result, err = getNextScheduleDate("0 12 * * MON#3", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC), result)
+	})
+	t.Run("Should schedule the cron macros correctly", func(t *testing.T) {
+		result, err := getNextScheduleDate("@hourly", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 15, 11, 0, 0, 0, time.UTC), result)
+
+		result, err = getNextScheduleDate("@daily", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 16, 0, 0, 0, 0, time.UTC), result)
+
+		result, err = getNextScheduleDate("@weekly", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 21, 0, 0, 0, 0, time.UTC), result)
+
+		result, err = getNextScheduleDate("@monthly", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC), result)
+
+		result, err = getNextScheduleDate("@yearly", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), result)
+	})
+	t.Run("Should compute the next occurrence on the location of now", func(t *testing.T) {
+		loc, err := time.LoadLocation("America/Sao_Paulo")
+		assert.NoError(t, err)
+
+		// now is 07:20:30 on the location
+		result, err := getNextScheduleDate("0 9 * * *", now.In(loc))
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 15, 9, 0, 0, 0, loc), result)
+	})
+	t.Run("Should fail if the cron expression is invalid", func(t *testing.T) {
+		_, err := getNextScheduleDate("60 * * * *", now)
+		assert.Equal(t, "Failed to parse cron expression '60 * * * *', invalid minute: 60, it should be between 0 and 59", err.Error())
+
+		_, err = getNextScheduleDate("* * * * * * *", now)
+		assert.Error(t, err)
+
+		_, err = getNextScheduleDate("0 0 * * MON#6", now)
+		assert.Error(t, err)
+
+		_, err = getNextScheduleDate("0 0 5-1 * *", now)
+		assert.Error(t, err)
+
+		_, err = getNextScheduleDate("@fortnightly", now)
+		assert.Equal(t, "Failed to parse cron expression '@fortnightly', unknown macro: @fortnightly", err.Error())
+	})
+	t.Run("Should fail if the cron expression never matches", func(t *testing.T) {
+		_, err := getNextScheduleDate("0 0 30 2 *", now)
+		assert.Error(t, err)
+	})
+}
@@ -1,23 +1,128 @@
 package scheduler
 
+import (
+	"context"
+	"time"
+)
+
 // JobDatabase represents a database that can manipulate job documents
+//
+// Every method receives a context, that is cancelled when the database call times out.
 type JobDatabase interface {
 	// InitJobDB its a function that will be called at the beggining of the library instantiation.
 	//
 	// It should start the job database an make it ready to read and write jobs.
-	InitJobDB() error
+	InitJobDB(ctx context.Context) error
 
 	// ListExpiredSchedules should list jobs that are ready to run
-	ListExpiredSchedules() ([]*Job, error)
+	ListExpiredSchedules(ctx context.Context) ([]*Job, error)
+
+	// ClaimJob should atomically mark the job as RUNNING and owned by j.Owner,
+	// but only if the job is still PENDING and its NextRunAt is still j.NextRunAt on the database.
+	//
+	// Checking the NextRunAt value makes sure that a job run, that was already ran and re-scheduled by another scheduler instance,
+	// is not claimed again from a stale listing.
+	//
+	// It should return true if the job was claimed, and false if another scheduler instance claimed it first.
+	ClaimJob(ctx context.Context, j Job) (bool, error)
+
+	// RenewLease should save the job LeaseExpiresAt value,
+	// but only if the job is still RUNNING and owned by j.Owner on the database.
+	//
+	// It should return true if the lease was renewed, and false if the job is not owned by j.Owner anymore.
+	RenewLease(ctx context.Context, j Job) (bool, error)
+
+	// ListExpiredLeases should list RUNNING jobs which lease has already expired
+	ListExpiredLeases(ctx context.Context) ([]*Job, error)
+
+	// RecoverJob should save the job in its current state on the job database,
+	// but only if the job is still RUNNING and its lease has expired on the database.
+	//
+	// It should return true if the job was recovered, and false otherwise.
+	RecoverJob(ctx context.Context, j Job) (bool, error)
 
-	// List should list jobs given the Finder
-	List(f Finder) ([]*Job, error)
+	// ReleaseJob should set the job back as PENDING, clearing its Owner and LeaseExpiresAt,
+	// but only if the job is still RUNNING and owned by j.Owner on the database.
+	//
+	// It should return true if the job was released, and false if the job is not owned by j.Owner anymore.
+	ReleaseJob(ctx context.Context, j Job) (bool, error)
+
+	// FinishJob should save the job in its current state on the job database, when its run is over,
+	// but only if the job is still RUNNING and owned by owner on the database.
+	//
+	// It should return true if the job was saved, and false if the job is not owned by owner anymore.
+	FinishJob(ctx context.Context, j Job, owner string) (bool, error)
+
+	// List should list jobs given the Finder,
+	// sorted and paginated according to the Finder SortBy, SortDescending, Limit and Offset values.
+	//
+	// The Finder Fields can be ignored, the listed jobs are projected by the library.
+	List(ctx context.Context, f Finder) ([]*Job, error)
+
+	// Count should count the jobs given the Finder, ignoring its sorting and pagination values
+	Count(ctx context.Context, f Finder) (int, error)
+
+	// GetJob should return the job with the given ID.
+	//
+	// It should return ErrJobNotFound if no job has the given ID.
+	GetJob(ctx context.Context, id string) (*Job, error)
+
+	// UpdateJob should save the job in its current state on the job database,
+	// but only if the job exists, is not RUNNING, and its Status and NextRunAt are still the ones of old (the job as it was read before the update) on the database.
+	//
+	// Checking the old values makes sure that a job that was ran or re-scheduled since it was read,
+	// is not overwritten with stale values.
+	//
+	// It should return true if the job was updated, and false otherwise.
+	UpdateJob(ctx context.Context, j Job, old Job) (bool, error)
 
 	// SaveJob should save a job in its current state on the job database
 	//
 	// It should receive a job struct, and "upsert" it in the database. (If it's a new job, should insert a new job, if its an existent job, should update the existent job).
-	SaveJob(j Job) error
+	//
+	// It should return the job ID, that is the ID assigned by the database when its a new job.
+	SaveJob(ctx context.Context, j Job) (id string, err error)
+
+	// SaveUniqueJob should insert the new job, unless another job with the same UniqueKey already exists on the database.
+	//
+	// In that case, if replace is true, the existing job should be updated to the new job state, keeping its ID,
+	// unless the existing job is RUNNING, when ErrJobRunning should be returned.
+	//
+	// It should return the ID of the saved or existing job, and true if the new job was inserted or replaced the existing one.
+	SaveUniqueJob(ctx context.Context, j Job, replace bool) (id string, saved bool, err error)
 
 	// DeleteJob should delete a job completely from the database
-	DeleteJob(j Job) error
+	DeleteJob(ctx context.Context, j Job) error
+
+	// CancelMany should set every job found given the Finder as CANCELED, skipping the RUNNING jobs,
+	// and return how many jobs were canceled.
+	//
+	// The Finder sorting and pagination values should be ignored.
+	CancelMany(ctx context.Context, f Finder) (int, error)
+
+	// DeleteMany should delete every job found given the Finder, skipping the RUNNING jobs,
+	// and return how many jobs were deleted.
+	//
+	// The Finder sorting and pagination values should be ignored.
+	DeleteMany(ctx context.Context, f Finder) (int, error)
+
+	// RescheduleMany should add delta to the NextRunAt of every job found given the Finder, skipping the RUNNING jobs,
+	// and return how many jobs were re-scheduled.
+	//
+	// The Finder sorting and pagination values should be ignored.
+	RescheduleMany(ctx context.Context, f Finder, delta time.Duration) (int, error)
+
+	// SaveRun should insert the job run record on the database.
+	//
+	// It is only called when the run history is enabled.
+	SaveRun(ctx context.Context, r JobRun) error
+
+	// ListRuns should list the job runs given the RunFinder, the most recent runs first
+	ListRuns(ctx context.Context, f RunFinder) ([]*JobRun, error)
+
+	// DeleteRunsBefore should delete the job runs that started before t,
+	// and return how many runs were deleted.
+	//
+	// It is only called when the run history is enabled with a retention.
+	DeleteRunsBefore(ctx context.Context, t time.Time) (int, error)
 }
@@ -1,26 +1,94 @@
 package scheduler
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
 // emptyDB represents an empty job database that always returns an error
 type emptyDB struct{}
 
-func (edb *emptyDB) InitJobDB() error {
+func (edb *emptyDB) InitJobDB(ctx context.Context) error {
 	return errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
 }
 
-func (edb *emptyDB) List(f Finder) ([]*Job, error) {
+func (edb *emptyDB) List(ctx context.Context, f Finder) ([]*Job, error) {
 	return []*Job{}, errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
 }
 
-func (edb *emptyDB) ListExpiredSchedules() ([]*Job, error) {
+func (edb *emptyDB) Count(ctx context.Context, f Finder) (int, error) {
+	return 0, errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
+}
+
+func (edb *emptyDB) ListExpiredSchedules(ctx context.Context) ([]*Job, error) {
+	return []*Job{}, errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
+}
+
+func (edb *emptyDB) ClaimJob(ctx context.Context, j Job) (bool, error) {
+	return false, errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
+}
+
+func (edb *emptyDB) RenewLease(ctx context.Context, j Job) (bool, error) {
+	return false, errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
+}
+
+func (edb *emptyDB) ListExpiredLeases(ctx context.Context) ([]*Job, error) {
 	return []*Job{}, errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
 }
 
-func (edb *emptyDB) SaveJob(j Job) error {
+func (edb *emptyDB) RecoverJob(ctx context.Context, j Job) (bool, error) {
+	return false, errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
+}
+
+func (edb *emptyDB) ReleaseJob(ctx context.Context, j Job) (bool, error) {
+	return false, errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
+}
+
+func (edb *emptyDB) FinishJob(ctx context.Context, j Job, owner string) (bool, error) {
+	return false, errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
+}
+
+func (edb *emptyDB) GetJob(ctx context.Context, id string) (*Job, error) {
+	return nil, errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
+}
+
+func (edb *emptyDB) UpdateJob(ctx context.Context, j Job, old Job) (bool, error) {
+	return false, errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
+}
+
+func (edb *emptyDB) SaveUniqueJob(ctx context.Context, j Job, replace bool) (string, bool, error) {
+	return "", false, errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
+}
+
+func (edb *emptyDB) SaveJob(ctx context.Context, j Job) (string, error) {
+	return "", errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
+}
+
+func (edb *emptyDB) DeleteJob(ctx context.Context, j Job) error {
 	return errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
 }
 
-func (edb *emptyDB) DeleteJob(j Job) error {
+func (edb *emptyDB) CancelMany(ctx context.Context, f Finder) (int, error) {
+	return 0, errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
+}
+
+func (edb *emptyDB) DeleteMany(ctx context.Context, f Finder) (int, error) {
+	return 0, errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
+}
+
+func (edb *emptyDB) RescheduleMany(ctx context.Context, f Finder, delta time.Duration) (int, error) {
+	return 0, errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
+}
+
+func (edb *emptyDB) SaveRun(ctx context.Context, r JobRun) error {
 	return errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
 }
+
+func (edb *emptyDB) ListRuns(ctx context.Context, f RunFinder) ([]*JobRun, error) {
+	return nil, errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
+}
+
+func (edb *emptyDB) DeleteRunsBefore(ctx context.Context, t time.Time) (int, error) {
+	return 0, errors.New("Tried to access the scheduler DB to manage jobs, but the go-scheduler library was not instantiated")
+}
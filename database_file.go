@@ -0,0 +1,322 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileJobDB represents a job database that keeps the jobs and the job runs in memory,
+// and persists them on a local file after every change, so that they survive restarts.
+//
+// It is safe for concurrent use, but the file should not be shared between multiple processes.
+//
+// Since the whole file is re-written on every change, its suitable for CLI tools and edge deployments
+// with a moderate amount of jobs, that need persistence without running a database server.
+type FileJobDB struct {
+	// mem holds the jobs and the job runs while the process runs
+	mem *MemoryJobDB
+
+	// path its the path of the file that the jobs and the job runs are persisted on
+	path string
+
+	// mu serializes the writes to the file
+	mu sync.Mutex
+}
+
+// fileSnapshot represents the content of the file that the jobs and the job runs are persisted on
+type fileSnapshot struct {
+	Seq  uint64    `json:"seq"`
+	Jobs []fileJob `json:"jobs"`
+	Runs []JobRun  `json:"runs"`
+}
+
+// fileJob represents a job persisted on the file, along with its creation order
+type fileJob struct {
+	Seq uint64 `json:"seq"`
+	Job Job    `json:"job"`
+}
+
+// NewFileJobDB creates a job database persisted on the file at the given path.
+//
+// The file is read when the library starts, and created if it does not exist yet.
+//
+// Ex.:
+//
+//	scheduler.Init(scheduler.Config{DB: scheduler.NewFileJobDB("/var/lib/my-app/jobs.json")})
+func NewFileJobDB(path string) *FileJobDB {
+	return &FileJobDB{
+		mem:  NewMemoryJobDB(),
+		path: path,
+	}
+}
+
+func (db *FileJobDB) InitJobDB(ctx context.Context) error {
+	b, err := os.ReadFile(db.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// no jobs were persisted yet
+	case err != nil:
+		return fmt.Errorf("Failed to read the job file, %v", err)
+	default:
+		var snap fileSnapshot
+		err = json.Unmarshal(b, &snap)
+		if err != nil {
+			return fmt.Errorf("Failed to decode the job file, %v", err)
+		}
+		db.mem.restore(snap)
+	}
+
+	// persisting right away makes sure the file can be written before any job is scheduled
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return db.persist()
+}
+
+func (db *FileJobDB) ListExpiredSchedules(ctx context.Context) ([]*Job, error) {
+	return db.mem.ListExpiredSchedules(ctx)
+}
+
+func (db *FileJobDB) ClaimJob(ctx context.Context, j Job) (bool, error) {
+	return db.persistIf(func() (bool, error) {
+		return db.mem.ClaimJob(ctx, j)
+	})
+}
+
+func (db *FileJobDB) RenewLease(ctx context.Context, j Job) (bool, error) {
+	return db.persistIf(func() (bool, error) {
+		return db.mem.RenewLease(ctx, j)
+	})
+}
+
+func (db *FileJobDB) ListExpiredLeases(ctx context.Context) ([]*Job, error) {
+	return db.mem.ListExpiredLeases(ctx)
+}
+
+func (db *FileJobDB) RecoverJob(ctx context.Context, j Job) (bool, error) {
+	return db.persistIf(func() (bool, error) {
+		return db.mem.RecoverJob(ctx, j)
+	})
+}
+
+func (db *FileJobDB) ReleaseJob(ctx context.Context, j Job) (bool, error) {
+	return db.persistIf(func() (bool, error) {
+		return db.mem.ReleaseJob(ctx, j)
+	})
+}
+
+func (db *FileJobDB) FinishJob(ctx context.Context, j Job, owner string) (bool, error) {
+	return db.persistIf(func() (bool, error) {
+		return db.mem.FinishJob(ctx, j, owner)
+	})
+}
+
+func (db *FileJobDB) List(ctx context.Context, f Finder) ([]*Job, error) {
+	return db.mem.List(ctx, f)
+}
+
+func (db *FileJobDB) Count(ctx context.Context, f Finder) (int, error) {
+	return db.mem.Count(ctx, f)
+}
+
+func (db *FileJobDB) GetJob(ctx context.Context, id string) (*Job, error) {
+	return db.mem.GetJob(ctx, id)
+}
+
+func (db *FileJobDB) UpdateJob(ctx context.Context, j Job, old Job) (bool, error) {
+	return db.persistIf(func() (bool, error) {
+		return db.mem.UpdateJob(ctx, j, old)
+	})
+}
+
+func (db *FileJobDB) SaveJob(ctx context.Context, j Job) (string, error) {
+	var id string
+	err := db.change(func() (bool, error) {
+		id, _ = db.mem.SaveJob(ctx, j)
+		return true, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (db *FileJobDB) SaveUniqueJob(ctx context.Context, j Job, replace bool) (id string, saved bool, err error) {
+	err = db.change(func() (bool, error) {
+		id, saved, err = db.mem.SaveUniqueJob(ctx, j, replace)
+		return saved, err
+	})
+	if err != nil {
+		return "", false, err
+	}
+
+	return id, saved, nil
+}
+
+func (db *FileJobDB) DeleteJob(ctx context.Context, j Job) error {
+	return db.change(func() (bool, error) {
+		return true, db.mem.DeleteJob(ctx, j)
+	})
+}
+
+func (db *FileJobDB) CancelMany(ctx context.Context, f Finder) (int, error) {
+	return db.persistIfAny(func() (int, error) {
+		return db.mem.CancelMany(ctx, f)
+	})
+}
+
+func (db *FileJobDB) DeleteMany(ctx context.Context, f Finder) (int, error) {
+	return db.persistIfAny(func() (int, error) {
+		return db.mem.DeleteMany(ctx, f)
+	})
+}
+
+func (db *FileJobDB) RescheduleMany(ctx context.Context, f Finder, delta time.Duration) (int, error) {
+	return db.persistIfAny(func() (int, error) {
+		return db.mem.RescheduleMany(ctx, f, delta)
+	})
+}
+
+func (db *FileJobDB) SaveRun(ctx context.Context, r JobRun) error {
+	return db.change(func() (bool, error) {
+		return true, db.mem.SaveRun(ctx, r)
+	})
+}
+
+func (db *FileJobDB) ListRuns(ctx context.Context, f RunFinder) ([]*JobRun, error) {
+	return db.mem.ListRuns(ctx, f)
+}
+
+func (db *FileJobDB) DeleteRunsBefore(ctx context.Context, t time.Time) (int, error) {
+	return db.persistIfAny(func() (int, error) {
+		return db.mem.DeleteRunsBefore(ctx, t)
+	})
+}
+
+// persistIf makes the change and persists the jobs if the change was made
+func (db *FileJobDB) persistIf(fn func() (bool, error)) (changed bool, err error) {
+	err = db.change(func() (bool, error) {
+		changed, err = fn()
+		return changed, err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return changed, nil
+}
+
+// persistIfAny makes the change and persists the jobs if any job or job run was changed
+func (db *FileJobDB) persistIfAny(fn func() (int, error)) (changed int, err error) {
+	err = db.change(func() (bool, error) {
+		changed, err = fn()
+		return changed > 0, err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return changed, nil
+}
+
+// change makes the change on the jobs held in memory, and persists them on the file if the change was made.
+//
+// The changes are serialized, and if the file can't be written, the change is rolled back,
+// so that the jobs held in memory never drift from the ones on the file.
+func (db *FileJobDB) change(fn func() (bool, error)) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	before := db.mem.snapshot()
+
+	changed, err := fn()
+	if err != nil || !changed {
+		return err
+	}
+
+	err = db.persist()
+	if err != nil {
+		db.mem.restore(before)
+	}
+
+	return err
+}
+
+// persist writes the current jobs and job runs to the file, the caller must hold the write lock.
+//
+// The snapshot is written to a temporary file that then replaces the file,
+// so that the file is never left half written if the process dies while writing it.
+func (db *FileJobDB) persist() error {
+	// the snapshot is taken while holding the write lock, so that the file always ends with the most recent jobs
+	b, err := json.Marshal(db.mem.snapshot())
+	if err != nil {
+		return fmt.Errorf("Failed to encode the job file, %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(db.path), filepath.Base(db.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("Failed to write the job file, %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(b)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to write the job file, %v", err)
+	}
+
+	err = os.Rename(tmp.Name(), db.path)
+	if err != nil {
+		return fmt.Errorf("Failed to write the job file, %v", err)
+	}
+
+	return nil
+}
+
+// snapshot returns a copy of the jobs and the job runs, that can be persisted
+func (db *MemoryJobDB) snapshot() fileSnapshot {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	snap := fileSnapshot{
+		Seq:  db.seq,
+		Jobs: make([]fileJob, 0, len(db.jobs)),
+		Runs: append([]JobRun{}, db.runs...),
+	}
+
+	for _, mj := range db.jobs {
+		snap.Jobs = append(snap.Jobs, fileJob{Seq: mj.seq, Job: copyJob(mj.job)})
+	}
+
+	sort.Slice(snap.Jobs, func(a, b int) bool {
+		return snap.Jobs[a].Seq < snap.Jobs[b].Seq
+	})
+
+	return snap
+}
+
+// restore replaces the jobs and the job runs with the ones of the snapshot
+func (db *MemoryJobDB) restore(snap fileSnapshot) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.seq = snap.Seq
+	db.jobs = make(map[string]*memoryJob, len(snap.Jobs))
+	for _, fj := range snap.Jobs {
+		db.jobs[fj.Job.ID] = &memoryJob{seq: fj.Seq, job: fj.Job}
+	}
+	db.runs = snap.Runs
+}
@@ -0,0 +1,123 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileJobDB(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should create the file when it does not exist", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jobs.json")
+
+		err := NewFileJobDB(path).InitJobDB(ctx)
+		assert.NoError(t, err)
+		assert.FileExists(t, path)
+	})
+	t.Run("Should fail if the file directory does not exist", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "a missing directory", "jobs.json")
+
+		err := NewFileJobDB(path).InitJobDB(ctx)
+		assert.ErrorContains(t, err, "Failed to write the job file")
+	})
+	t.Run("Should fail if the file is not a job file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jobs.json")
+		os.WriteFile(path, []byte("not a job file"), 0o600)
+
+		err := NewFileJobDB(path).InitJobDB(ctx)
+		assert.ErrorContains(t, err, "Failed to decode the job file")
+	})
+	t.Run("Should keep the jobs, their order and the job runs after the file is opened again", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jobs.json")
+
+		db := NewFileJobDB(path)
+		assert.NoError(t, db.InitJobDB(ctx))
+
+		now := time.Now().UTC()
+		firstID, _ := db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: PENDING, NextRunAt: now, Data: map[string]any{"key": "value"}})
+		secondID, _ := db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: PENDING, NextRunAt: now, RetryPolicy: &RetryPolicy{MaxAttempts: 3}})
+		claimed, err := db.ClaimJob(ctx, Job{ID: secondID, Owner: "my-worker", NextRunAt: now, LeaseExpiresAt: &now})
+		assert.NoError(t, err)
+		assert.True(t, claimed)
+		db.SaveRun(ctx, JobRun{JobID: firstID, Outcome: RUN_SUCCEEDED, StartedAt: now})
+
+		reopened := NewFileJobDB(path)
+		assert.NoError(t, reopened.InitJobDB(ctx))
+
+		js, _ := reopened.List(ctx, Finder{})
+		assert.Len(t, js, 2)
+		assert.Equal(t, firstID, js[0].ID)
+		assert.Equal(t, "value", js[0].Data["key"])
+		assert.True(t, js[1].IsRunning())
+		assert.Equal(t, "my-worker", js[1].Owner)
+		assert.Equal(t, 3, js[1].RetryPolicy.MaxAttempts)
+
+		rs, _ := reopened.ListRuns(ctx, RunFinder{JobID: firstID})
+		assert.Len(t, rs, 1)
+
+		// new jobs keep getting new IDs
+		thirdID, _ := reopened.SaveJob(ctx, Job{Name: "MYMOCKJOB!"})
+		assert.NotContains(t, []string{firstID, secondID}, thirdID)
+	})
+	t.Run("Should roll back the change if the file can't be written", func(t *testing.T) {
+		db := NewFileJobDB(filepath.Join(t.TempDir(), "jobs.json"))
+		assert.NoError(t, db.InitJobDB(ctx))
+
+		now := time.Now().UTC()
+		id, _ := db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: PENDING, NextRunAt: now})
+
+		db.path = filepath.Join(t.TempDir(), "a missing directory", "jobs.json")
+
+		claimed, err := db.ClaimJob(ctx, Job{ID: id, Owner: "my-worker", NextRunAt: now, LeaseExpiresAt: &now})
+		assert.ErrorContains(t, err, "Failed to write the job file")
+		assert.False(t, claimed)
+
+		_, err = db.SaveJob(ctx, Job{Name: "ANOTHERJOB!", Status: PENDING, NextRunAt: now})
+		assert.Error(t, err)
+
+		js, _ := db.List(ctx, Finder{})
+		if assert.Len(t, js, 1) {
+			assert.Equal(t, PENDING, js[0].Status)
+			assert.Empty(t, js[0].Owner)
+		}
+	})
+	t.Run("Should persist the bulk changes", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jobs.json")
+
+		db := NewFileJobDB(path)
+		assert.NoError(t, db.InitJobDB(ctx))
+		db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: PENDING})
+		db.SaveJob(ctx, Job{Name: "ANOTHERJOB!", Status: PENDING})
+
+		canceled, err := db.CancelMany(ctx, Finder{Name: "MYMOCKJOB!"})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, canceled)
+
+		reopened := NewFileJobDB(path)
+		assert.NoError(t, reopened.InitJobDB(ctx))
+
+		count, _ := reopened.Count(ctx, Finder{Status: "CANCELED"})
+		assert.Equal(t, 1, count)
+	})
+	t.Run("Should be used by the library when a file path is configured", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jobs.json")
+
+		s, err := New(Config{File: &FileJobDBConfig{Path: path}, ProcessingRate: time.Hour})
+		assert.NoError(t, err)
+		defer s.Stop()
+
+		s.Define("MYMOCKJOB!", func(ctx context.Context, j *Job) error { return nil })
+		_, err = s.In(time.Hour).Do("MYMOCKJOB!")
+		assert.NoError(t, err)
+
+		assert.IsType(t, &FileJobDB{}, s.db)
+		count, _ := s.Count(Finder{Name: "MYMOCKJOB!"})
+		assert.Equal(t, 1, count)
+	})
+}
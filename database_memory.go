@@ -0,0 +1,415 @@
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MemoryJobDB represents a job database that keeps the jobs and the job runs in memory.
+//
+// It is safe for concurrent use, but the jobs are lost when the process ends,
+// and they can not be shared between multiple processes.
+//
+// Its suitable for tests, and for ephemeral single process applications.
+type MemoryJobDB struct {
+	mu sync.RWMutex
+
+	// seq its the last sequence number assigned to a job or a job run, that is also used as their ID
+	seq uint64
+
+	// jobs holds the jobs by their ID
+	jobs map[string]*memoryJob
+
+	// runs holds the job runs, in the order they were saved
+	runs []JobRun
+}
+
+// memoryJob represents a job kept in memory, along with its creation order
+type memoryJob struct {
+	seq uint64
+	job Job
+}
+
+// NewMemoryJobDB creates an empty in-memory job database.
+//
+// Ex.:
+//
+//	scheduler.Init(scheduler.Config{DB: scheduler.NewMemoryJobDB()})
+func NewMemoryJobDB() *MemoryJobDB {
+	return &MemoryJobDB{
+		jobs: map[string]*memoryJob{},
+	}
+}
+
+func (db *MemoryJobDB) InitJobDB(ctx context.Context) error {
+	return nil
+}
+
+func (db *MemoryJobDB) ListExpiredSchedules(ctx context.Context) ([]*Job, error) {
+	now := NowFromContext(ctx)
+
+	return db.filter(func(j Job) bool {
+		return j.Status == PENDING && !j.NextRunAt.After(now)
+	}), nil
+}
+
+func (db *MemoryJobDB) ClaimJob(ctx context.Context, j Job) (bool, error) {
+	return db.update(j.ID, func(stored *Job) bool {
+		if stored.Status != PENDING || !stored.NextRunAt.Equal(j.NextRunAt) {
+			return false
+		}
+
+		stored.Status = RUNNING
+		stored.Owner = j.Owner
+		stored.LeaseExpiresAt = copyTime(j.LeaseExpiresAt)
+		return true
+	}), nil
+}
+
+func (db *MemoryJobDB) RenewLease(ctx context.Context, j Job) (bool, error) {
+	return db.update(j.ID, func(stored *Job) bool {
+		if stored.Status != RUNNING || stored.Owner != j.Owner {
+			return false
+		}
+
+		stored.LeaseExpiresAt = copyTime(j.LeaseExpiresAt)
+		return true
+	}), nil
+}
+
+func (db *MemoryJobDB) ListExpiredLeases(ctx context.Context) ([]*Job, error) {
+	now := NowFromContext(ctx)
+
+	return db.filter(func(j Job) bool {
+		return j.Status == RUNNING && j.LeaseExpiresAt != nil && !j.LeaseExpiresAt.After(now)
+	}), nil
+}
+
+func (db *MemoryJobDB) RecoverJob(ctx context.Context, j Job) (bool, error) {
+	now := NowFromContext(ctx)
+
+	return db.update(j.ID, func(stored *Job) bool {
+		if stored.Status != RUNNING || stored.LeaseExpiresAt == nil || stored.LeaseExpiresAt.After(now) {
+			return false
+		}
+
+		*stored = copyJob(j)
+		return true
+	}), nil
+}
+
+func (db *MemoryJobDB) ReleaseJob(ctx context.Context, j Job) (bool, error) {
+	return db.update(j.ID, func(stored *Job) bool {
+		if stored.Status != RUNNING || stored.Owner != j.Owner {
+			return false
+		}
+
+		stored.Status = PENDING
+		stored.release()
+		return true
+	}), nil
+}
+
+func (db *MemoryJobDB) FinishJob(ctx context.Context, j Job, owner string) (bool, error) {
+	return db.update(j.ID, func(stored *Job) bool {
+		if stored.Status != RUNNING || stored.Owner != owner {
+			return false
+		}
+
+		*stored = copyJob(j)
+		return true
+	}), nil
+}
+
+func (db *MemoryJobDB) List(ctx context.Context, f Finder) ([]*Job, error) {
+	return f.sortAndPaginate(db.filter(f.matches)), nil
+}
+
+func (db *MemoryJobDB) Count(ctx context.Context, f Finder) (int, error) {
+	return len(db.filter(f.matches)), nil
+}
+
+func (db *MemoryJobDB) GetJob(ctx context.Context, id string) (*Job, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	mj, ok := db.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	j := copyJob(mj.job)
+	return &j, nil
+}
+
+func (db *MemoryJobDB) UpdateJob(ctx context.Context, j Job, old Job) (bool, error) {
+	return db.update(j.ID, func(stored *Job) bool {
+		if stored.Status == RUNNING || stored.Status != old.Status || !stored.NextRunAt.Equal(old.NextRunAt) {
+			return false
+		}
+
+		*stored = copyJob(j)
+		return true
+	}), nil
+}
+
+func (db *MemoryJobDB) SaveJob(ctx context.Context, j Job) (id string, err error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if j.ID == "" {
+		// no ID, then its a new job
+		return db.insert(j), nil
+	}
+
+	mj, ok := db.jobs[j.ID]
+	if !ok {
+		db.seq++
+		mj = &memoryJob{seq: db.seq}
+		db.jobs[j.ID] = mj
+	}
+	mj.job = copyJob(j)
+
+	return j.ID, nil
+}
+
+func (db *MemoryJobDB) SaveUniqueJob(ctx context.Context, j Job, replace bool) (id string, saved bool, err error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for id, mj := range db.jobs {
+		if mj.job.UniqueKey != j.UniqueKey {
+			continue
+		}
+
+		if !replace {
+			return id, false, nil
+		}
+
+		if mj.job.Status == RUNNING {
+			return "", false, ErrJobRunning
+		}
+
+		j.ID = id
+		mj.job = copyJob(j)
+		return id, true, nil
+	}
+
+	return db.insert(j), true, nil
+}
+
+func (db *MemoryJobDB) DeleteJob(ctx context.Context, j Job) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	delete(db.jobs, j.ID)
+	return nil
+}
+
+func (db *MemoryJobDB) CancelMany(ctx context.Context, f Finder) (int, error) {
+	return db.updateMany(f, func(stored *Job) bool {
+		if stored.Status == CANCELED {
+			return false
+		}
+
+		stored.Status = CANCELED
+		return true
+	}), nil
+}
+
+func (db *MemoryJobDB) DeleteMany(ctx context.Context, f Finder) (int, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	deleted := 0
+	for id, mj := range db.jobs {
+		if mj.job.Status == RUNNING || !f.matches(mj.job) {
+			continue
+		}
+
+		delete(db.jobs, id)
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+func (db *MemoryJobDB) RescheduleMany(ctx context.Context, f Finder, delta time.Duration) (int, error) {
+	return db.updateMany(f, func(stored *Job) bool {
+		if delta == 0 {
+			return false
+		}
+
+		stored.NextRunAt = stored.NextRunAt.Add(delta)
+		return true
+	}), nil
+}
+
+func (db *MemoryJobDB) SaveRun(ctx context.Context, r JobRun) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.seq++
+	r.ID = strconv.FormatUint(db.seq, 10)
+	db.runs = append(db.runs, r)
+
+	return nil
+}
+
+func (db *MemoryJobDB) ListRuns(ctx context.Context, f RunFinder) ([]*JobRun, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	rs := []*JobRun{}
+	for _, r := range db.runs {
+		if !matchesRun(f, r) {
+			continue
+		}
+
+		r := r
+		rs = append(rs, &r)
+	}
+
+	// the most recent runs first
+	sort.SliceStable(rs, func(a, b int) bool {
+		return rs[a].StartedAt.After(rs[b].StartedAt)
+	})
+
+	if f.Limit > 0 && f.Limit < len(rs) {
+		rs = rs[:f.Limit]
+	}
+
+	return rs, nil
+}
+
+func (db *MemoryJobDB) DeleteRunsBefore(ctx context.Context, t time.Time) (int, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	kept := db.runs[:0]
+	for _, r := range db.runs {
+		if r.StartedAt.Before(t) {
+			continue
+		}
+
+		kept = append(kept, r)
+	}
+
+	deleted := len(db.runs) - len(kept)
+	db.runs = kept
+
+	return deleted, nil
+}
+
+// insert inserts the new job, assigning it an ID.
+//
+// It expects the database to be locked.
+func (db *MemoryJobDB) insert(j Job) string {
+	db.seq++
+	j.ID = strconv.FormatUint(db.seq, 10)
+	db.jobs[j.ID] = &memoryJob{seq: db.seq, job: copyJob(j)}
+
+	return j.ID
+}
+
+// filter returns copies of the jobs that match the given function, in their creation order
+func (db *MemoryJobDB) filter(match func(j Job) bool) []*Job {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	mjs := []*memoryJob{}
+	for _, mj := range db.jobs {
+		if match(mj.job) {
+			mjs = append(mjs, mj)
+		}
+	}
+
+	sort.Slice(mjs, func(a, b int) bool {
+		return mjs[a].seq < mjs[b].seq
+	})
+
+	js := make([]*Job, len(mjs))
+	for i, mj := range mjs {
+		j := copyJob(mj.job)
+		js[i] = &j
+	}
+
+	return js
+}
+
+// update changes the stored job with the given ID with the update function,
+// that should return false if the job should not be changed.
+//
+// Returns true if the job was changed.
+func (db *MemoryJobDB) update(id string, update func(stored *Job) bool) bool {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	mj, ok := db.jobs[id]
+	if !ok {
+		return false
+	}
+
+	return update(&mj.job)
+}
+
+// updateMany changes every stored job found given the finder with the update function, skipping the RUNNING jobs.
+//
+// Returns how many jobs were changed.
+func (db *MemoryJobDB) updateMany(f Finder, update func(stored *Job) bool) int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	updated := 0
+	for _, mj := range db.jobs {
+		if mj.job.Status == RUNNING || !f.matches(mj.job) {
+			continue
+		}
+
+		if update(&mj.job) {
+			updated++
+		}
+	}
+
+	return updated
+}
+
+// matchesRun returns true if the job run is found given the run finder
+func matchesRun(f RunFinder, r JobRun) bool {
+	return (f.JobID == "" || r.JobID == f.JobID) &&
+		(f.JobName == "" || r.JobName == f.JobName) &&
+		(f.Outcome == "" || r.Outcome == f.Outcome) &&
+		inTimeRange(&r.StartedAt, f.StartedAfter, f.StartedBefore)
+}
+
+// copyJob copies the job, so that the stored job does not share its pointers and extra data with the job outside the database
+func copyJob(j Job) Job {
+	j.scheduler = nil
+	j.LeaseExpiresAt = copyTime(j.LeaseExpiresAt)
+	j.LastRunAt = copyTime(j.LastRunAt)
+	j.ScheduleLimitDate = copyTime(j.ScheduleLimitDate)
+	j.ScheduleAnchor = copyTime(j.ScheduleAnchor)
+	j.FailedAt = copyTime(j.FailedAt)
+
+	if j.RetryPolicy != nil {
+		rp := *j.RetryPolicy
+		j.RetryPolicy = &rp
+	}
+
+	j.Data = copyData(j.Data)
+
+	return j
+}
+
+// copyTime copies the time pointer
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	c := *t
+	return &c
+}
@@ -0,0 +1,243 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryJobDB(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should save a new job with a new ID, and get it back", func(t *testing.T) {
+		db := NewMemoryJobDB()
+
+		id, err := db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: PENDING, Data: map[string]any{"key": "value"}})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, id)
+
+		j, err := db.GetJob(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, id, j.ID)
+		assert.Equal(t, "MYMOCKJOB!", j.Name)
+
+		_, err = db.GetJob(ctx, "an unknown ID")
+		assert.ErrorIs(t, err, ErrJobNotFound)
+	})
+	t.Run("Should not share the job extra data with the stored job", func(t *testing.T) {
+		db := NewMemoryJobDB()
+
+		data := map[string]any{"key": "value"}
+		id, _ := db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Data: data})
+		data["key"] = "changed"
+
+		j, _ := db.GetJob(ctx, id)
+		j.Data["key"] = "changed again"
+
+		j, _ = db.GetJob(ctx, id)
+		assert.Equal(t, "value", j.Data["key"])
+	})
+	t.Run("Should claim a PENDING job only once", func(t *testing.T) {
+		db := NewMemoryJobDB()
+
+		nextRunAt := time.Now().Add(-time.Minute)
+		id, _ := db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: PENDING, NextRunAt: nextRunAt})
+
+		js, err := db.ListExpiredSchedules(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, js, 1)
+
+		lea := time.Now().Add(time.Minute)
+		claims := make(chan bool, 10)
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				claimed, _ := db.ClaimJob(ctx, Job{ID: id, Owner: "my-worker", NextRunAt: nextRunAt, LeaseExpiresAt: &lea})
+				claims <- claimed
+			}()
+		}
+		wg.Wait()
+		close(claims)
+
+		claimed := 0
+		for c := range claims {
+			if c {
+				claimed++
+			}
+		}
+		assert.Equal(t, 1, claimed)
+
+		j, _ := db.GetJob(ctx, id)
+		assert.True(t, j.IsRunning())
+		assert.Equal(t, "my-worker", j.Owner)
+	})
+	t.Run("Should renew and recover leases only when they match", func(t *testing.T) {
+		db := NewMemoryJobDB()
+
+		lea := time.Now().Add(-time.Minute)
+		id, _ := db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: RUNNING, Owner: "my-worker", LeaseExpiresAt: &lea})
+
+		renewed, _ := db.RenewLease(ctx, Job{ID: id, Owner: "another worker", LeaseExpiresAt: &lea})
+		assert.False(t, renewed)
+
+		js, _ := db.ListExpiredLeases(ctx)
+		assert.Len(t, js, 1)
+
+		js[0].Status = PENDING
+		js[0].release()
+		recovered, _ := db.RecoverJob(ctx, *js[0])
+		assert.True(t, recovered)
+
+		recovered, _ = db.RecoverJob(ctx, *js[0])
+		assert.False(t, recovered)
+	})
+	t.Run("Should not update a RUNNING job", func(t *testing.T) {
+		db := NewMemoryJobDB()
+
+		id, _ := db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: RUNNING})
+
+		updated, err := db.UpdateJob(ctx, Job{ID: id, Name: "MYMOCKJOB!", Status: PENDING}, Job{ID: id, Status: RUNNING})
+		assert.NoError(t, err)
+		assert.False(t, updated)
+
+		updated, _ = db.UpdateJob(ctx, Job{ID: "an unknown ID"}, Job{ID: "an unknown ID"})
+		assert.False(t, updated)
+	})
+	t.Run("Should list and count the jobs given the finder", func(t *testing.T) {
+		db := NewMemoryJobDB()
+
+		now := time.Now()
+		for i, status := range []ScheduleStatus{PENDING, FAILED, PENDING, DONE, PENDING} {
+			db.SaveJob(ctx, Job{
+				Name:      "MYMOCKJOB!",
+				Status:    status,
+				NextRunAt: now.Add(time.Duration(5-i) * time.Hour),
+				Data:      map[string]any{"tenant": map[string]any{"id": i % 2}},
+			})
+		}
+		db.SaveJob(ctx, Job{Name: "ANOTHERJOB!", Status: PENDING, NextRunAt: now})
+
+		js, _ := db.List(ctx, Finder{Name: "MYMOCKJOB!", Status: "PENDING"})
+		assert.Len(t, js, 3)
+
+		js, _ = db.List(ctx, Finder{Statuses: []ScheduleStatus{FAILED, DONE}})
+		assert.Len(t, js, 2)
+
+		js, _ = db.List(ctx, Finder{Data: map[string]any{"tenant.id": 1.0}})
+		assert.Len(t, js, 2)
+
+		js, _ = db.List(ctx, Finder{Name: "MYMOCKJOB!", NextRunAfter: now.Add(2 * time.Hour), NextRunBefore: now.Add(4 * time.Hour)})
+		assert.Len(t, js, 2)
+
+		js, _ = db.List(ctx, Finder{SortBy: SORT_BY_NEXT_RUN_AT, Limit: 2, Offset: 1})
+		assert.Len(t, js, 2)
+		assert.Equal(t, now.Add(time.Hour), js[0].NextRunAt)
+		assert.Equal(t, now.Add(2*time.Hour), js[1].NextRunAt)
+
+		js, _ = db.List(ctx, Finder{Offset: 10})
+		assert.Empty(t, js)
+
+		count, _ := db.Count(ctx, Finder{Name: "MYMOCKJOB!", Limit: 1})
+		assert.Equal(t, 5, count)
+	})
+	t.Run("Should keep, replace or reject a unique job", func(t *testing.T) {
+		db := NewMemoryJobDB()
+
+		id, saved, err := db.SaveUniqueJob(ctx, Job{Name: "MYMOCKJOB!", UniqueKey: "MYKEY", Status: PENDING}, false)
+		assert.NoError(t, err)
+		assert.True(t, saved)
+
+		existingID, saved, _ := db.SaveUniqueJob(ctx, Job{Name: "MYMOCKJOB!", UniqueKey: "MYKEY"}, false)
+		assert.Equal(t, id, existingID)
+		assert.False(t, saved)
+
+		replacedID, saved, _ := db.SaveUniqueJob(ctx, Job{Name: "MYMOCKJOB!", UniqueKey: "MYKEY", Status: PENDING, Data: map[string]any{"new": true}}, true)
+		assert.Equal(t, id, replacedID)
+		assert.True(t, saved)
+
+		j, _ := db.GetJob(ctx, id)
+		assert.Equal(t, true, j.Data["new"])
+
+		db.ClaimJob(ctx, Job{ID: id})
+		_, _, err = db.SaveUniqueJob(ctx, Job{Name: "MYMOCKJOB!", UniqueKey: "MYKEY"}, true)
+		assert.ErrorIs(t, err, ErrJobRunning)
+	})
+	t.Run("Should cancel, re-schedule and delete many jobs, skipping the RUNNING jobs", func(t *testing.T) {
+		db := NewMemoryJobDB()
+
+		now := time.Now()
+		db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: PENDING, NextRunAt: now})
+		db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: FAILED, NextRunAt: now})
+		db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: RUNNING, NextRunAt: now})
+
+		rescheduled, _ := db.RescheduleMany(ctx, Finder{Name: "MYMOCKJOB!"}, time.Hour)
+		assert.Equal(t, 2, rescheduled)
+
+		count, _ := db.Count(ctx, Finder{NextRunAfter: now.Add(time.Hour)})
+		assert.Equal(t, 2, count)
+
+		canceled, _ := db.CancelMany(ctx, Finder{Name: "MYMOCKJOB!"})
+		assert.Equal(t, 2, canceled)
+
+		canceled, _ = db.CancelMany(ctx, Finder{Name: "MYMOCKJOB!"})
+		assert.Equal(t, 0, canceled)
+
+		deleted, _ := db.DeleteMany(ctx, Finder{})
+		assert.Equal(t, 2, deleted)
+
+		js, _ := db.List(ctx, Finder{})
+		assert.Len(t, js, 1)
+		assert.True(t, js[0].IsRunning())
+	})
+	t.Run("Should save, list and delete job runs", func(t *testing.T) {
+		db := NewMemoryJobDB()
+
+		now := time.Now()
+		db.SaveRun(ctx, JobRun{JobID: "1", Outcome: RUN_SUCCEEDED, StartedAt: now.Add(-2 * time.Hour)})
+		db.SaveRun(ctx, JobRun{JobID: "1", Outcome: RUN_FAILED, StartedAt: now.Add(-time.Hour)})
+		db.SaveRun(ctx, JobRun{JobID: "2", Outcome: RUN_FAILED, StartedAt: now})
+
+		rs, _ := db.ListRuns(ctx, RunFinder{JobID: "1"})
+		assert.Len(t, rs, 2)
+		assert.Equal(t, RUN_FAILED, rs[0].Outcome)
+		assert.NotEmpty(t, rs[0].ID)
+
+		rs, _ = db.ListRuns(ctx, RunFinder{Outcome: RUN_FAILED, Limit: 1})
+		assert.Len(t, rs, 1)
+		assert.Equal(t, "2", rs[0].JobID)
+
+		deleted, _ := db.DeleteRunsBefore(ctx, now.Add(-30*time.Minute))
+		assert.Equal(t, 2, deleted)
+
+		rs, _ = db.ListRuns(ctx, RunFinder{})
+		assert.Len(t, rs, 1)
+	})
+	t.Run("Should run the scheduled jobs on a scheduler", func(t *testing.T) {
+		s, _, _ := mockScheduler()
+		s.db = NewMemoryJobDB()
+
+		ran := make(chan *Job, 1)
+		s.Define("MYMOCKJOB!", func(ctx context.Context, j *Job) error {
+			ran <- j
+			return nil
+		})
+
+		j, err := s.In(0).Do("MYMOCKJOB!", map[string]any{"key": "value"})
+		assert.NoError(t, err)
+
+		s.process()
+		<-s.runningJobsDone()
+
+		assert.Equal(t, "value", (<-ran).Data["key"])
+
+		j, err = s.Get(j.ID)
+		assert.NoError(t, err)
+		assert.True(t, j.IsDone())
+		assert.NotNil(t, j.LastRunAt)
+	})
+}
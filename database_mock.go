@@ -1,20 +1,30 @@
 package scheduler
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	"github.com/delivery-much/mock-helper/mock"
 )
 
 type databaseMock struct {
 	mock.Mock
+
+	// mu guards the mock, since it can be called by concurrently running jobs
+	mu sync.Mutex
 }
 
 func newDatabaseMock() *databaseMock {
 	return &databaseMock{
-		mock.NewMock(),
+		Mock: mock.NewMock(),
 	}
 }
 
-func (dm *databaseMock) InitJobDB() (err error) {
+func (dm *databaseMock) InitJobDB(ctx context.Context) (err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
 	dm.RegisterMethodCall("InitJobDB")
 
 	res := dm.GetMethodResponse("InitJobDB")
@@ -25,7 +35,10 @@ func (dm *databaseMock) InitJobDB() (err error) {
 	return res.GetError(0)
 }
 
-func (dm *databaseMock) List(f Finder) (js []*Job, err error) {
+func (dm *databaseMock) List(ctx context.Context, f Finder) (js []*Job, err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
 	dm.RegisterMethodCall("List", f)
 
 	res := dm.GetMethodResponse("List")
@@ -36,7 +49,67 @@ func (dm *databaseMock) List(f Finder) (js []*Job, err error) {
 	return res.Get(0).([]*Job), res.GetError(1)
 }
 
-func (dm *databaseMock) ListExpiredSchedules() (js []*Job, err error) {
+func (dm *databaseMock) Count(ctx context.Context, f Finder) (count int, err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	dm.RegisterMethodCall("Count", f)
+
+	res := dm.GetMethodResponse("Count")
+	if len(res) == 0 {
+		return
+	}
+
+	return res.GetInt(0), res.GetError(1)
+}
+
+func (dm *databaseMock) GetJob(ctx context.Context, id string) (j *Job, err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	dm.RegisterMethodCall("GetJob", id)
+
+	res := dm.GetMethodResponse("GetJob")
+	if len(res) == 0 {
+		return
+	}
+
+	j, _ = res.Get(0).(*Job)
+	return j, res.GetError(1)
+}
+
+func (dm *databaseMock) UpdateJob(ctx context.Context, j Job, old Job) (updated bool, err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	dm.RegisterMethodCall("UpdateJob", j, old)
+
+	res := dm.GetMethodResponse("UpdateJob")
+	if len(res) == 0 {
+		return
+	}
+
+	return res.GetBool(0), res.GetError(1)
+}
+
+func (dm *databaseMock) SaveUniqueJob(ctx context.Context, j Job, replace bool) (id string, saved bool, err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	dm.RegisterMethodCall("SaveUniqueJob", j, replace)
+
+	res := dm.GetMethodResponse("SaveUniqueJob")
+	if len(res) == 0 {
+		return
+	}
+
+	return res.GetString(0), res.GetBool(1), res.GetError(2)
+}
+
+func (dm *databaseMock) ListExpiredSchedules(ctx context.Context) (js []*Job, err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
 	dm.RegisterMethodCall("ListExpiredSchedules")
 
 	res := dm.GetMethodResponse("ListExpiredSchedules")
@@ -47,18 +120,109 @@ func (dm *databaseMock) ListExpiredSchedules() (js []*Job, err error) {
 	return res.Get(0).([]*Job), res.GetError(1)
 }
 
-func (dm *databaseMock) SaveJob(j Job) (err error) {
+func (dm *databaseMock) ClaimJob(ctx context.Context, j Job) (claimed bool, err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	dm.RegisterMethodCall("ClaimJob", j)
+
+	res := dm.GetMethodResponse("ClaimJob")
+	if len(res) == 0 {
+		return
+	}
+
+	return res.GetBool(0), res.GetError(1)
+}
+
+func (dm *databaseMock) RenewLease(ctx context.Context, j Job) (renewed bool, err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	dm.RegisterMethodCall("RenewLease", j)
+
+	res := dm.GetMethodResponse("RenewLease")
+	if len(res) == 0 {
+		return
+	}
+
+	return res.GetBool(0), res.GetError(1)
+}
+
+func (dm *databaseMock) ListExpiredLeases(ctx context.Context) (js []*Job, err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	dm.RegisterMethodCall("ListExpiredLeases")
+
+	res := dm.GetMethodResponse("ListExpiredLeases")
+	if len(res) == 0 {
+		return
+	}
+
+	return res.Get(0).([]*Job), res.GetError(1)
+}
+
+func (dm *databaseMock) RecoverJob(ctx context.Context, j Job) (recovered bool, err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	dm.RegisterMethodCall("RecoverJob", j)
+
+	res := dm.GetMethodResponse("RecoverJob")
+	if len(res) == 0 {
+		return
+	}
+
+	return res.GetBool(0), res.GetError(1)
+}
+
+func (dm *databaseMock) ReleaseJob(ctx context.Context, j Job) (released bool, err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	dm.RegisterMethodCall("ReleaseJob", j)
+
+	res := dm.GetMethodResponse("ReleaseJob")
+	if len(res) == 0 {
+		return
+	}
+
+	return res.GetBool(0), res.GetError(1)
+}
+
+func (dm *databaseMock) FinishJob(ctx context.Context, j Job, owner string) (finished bool, err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	dm.RegisterMethodCall("FinishJob", j, owner)
+
+	res := dm.GetMethodResponse("FinishJob")
+	if len(res) == 0 {
+		// the job is still owned by the scheduler, unless stated otherwise
+		return true, nil
+	}
+
+	return res.GetBool(0), res.GetError(1)
+}
+
+func (dm *databaseMock) SaveJob(ctx context.Context, j Job) (id string, err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
 	dm.RegisterMethodCall("SaveJob", j)
 
 	res := dm.GetMethodResponse("SaveJob")
 	if len(res) == 0 {
-		return
+		return j.ID, nil
 	}
 
-	return res.GetError(0)
+	return res.GetString(0), res.GetError(1)
 }
 
-func (dm *databaseMock) DeleteJob(j Job) (err error) {
+func (dm *databaseMock) DeleteJob(ctx context.Context, j Job) (err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
 	dm.RegisterMethodCall("DeleteJob")
 
 	res := dm.GetMethodResponse("DeleteJob")
@@ -68,3 +232,88 @@ func (dm *databaseMock) DeleteJob(j Job) (err error) {
 
 	return res.GetError(0)
 }
+
+func (dm *databaseMock) CancelMany(ctx context.Context, f Finder) (canceled int, err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	dm.RegisterMethodCall("CancelMany", f)
+
+	res := dm.GetMethodResponse("CancelMany")
+	if len(res) == 0 {
+		return
+	}
+
+	return res.GetInt(0), res.GetError(1)
+}
+
+func (dm *databaseMock) DeleteMany(ctx context.Context, f Finder) (deleted int, err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	dm.RegisterMethodCall("DeleteMany", f)
+
+	res := dm.GetMethodResponse("DeleteMany")
+	if len(res) == 0 {
+		return
+	}
+
+	return res.GetInt(0), res.GetError(1)
+}
+
+func (dm *databaseMock) RescheduleMany(ctx context.Context, f Finder, delta time.Duration) (rescheduled int, err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	dm.RegisterMethodCall("RescheduleMany", f, delta)
+
+	res := dm.GetMethodResponse("RescheduleMany")
+	if len(res) == 0 {
+		return
+	}
+
+	return res.GetInt(0), res.GetError(1)
+}
+
+func (dm *databaseMock) SaveRun(ctx context.Context, r JobRun) (err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	dm.RegisterMethodCall("SaveRun", r)
+
+	res := dm.GetMethodResponse("SaveRun")
+	if len(res) == 0 {
+		return
+	}
+
+	return res.GetError(0)
+}
+
+func (dm *databaseMock) ListRuns(ctx context.Context, f RunFinder) (rs []*JobRun, err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	dm.RegisterMethodCall("ListRuns", f)
+
+	res := dm.GetMethodResponse("ListRuns")
+	if len(res) == 0 {
+		return
+	}
+
+	rs, _ = res.Get(0).([]*JobRun)
+	return rs, res.GetError(1)
+}
+
+func (dm *databaseMock) DeleteRunsBefore(ctx context.Context, t time.Time) (deleted int, err error) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	dm.RegisterMethodCall("DeleteRunsBefore", t)
+
+	res := dm.GetMethodResponse("DeleteRunsBefore")
+	if len(res) == 0 {
+		return
+	}
+
+	return res.GetInt(0), res.GetError(1)
+}
@@ -3,30 +3,37 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type mongoJobDB struct {
-	conn     *mongo.Client
-	dbName   string
-	collName string
+	conn         *mongo.Client
+	dbName       string
+	collName     string
+	runsCollName string
 }
 
 func newMongo(
 	conn *mongo.Client,
 	dbName,
-	collName string) JobDatabase {
+	collName,
+	runsCollName string) JobDatabase {
 	return &mongoJobDB{
 		conn,
 		dbName,
 		collName,
+		runsCollName,
 	}
 }
 
-func (db *mongoJobDB) InitJobDB() (err error) {
+func (db *mongoJobDB) InitJobDB(ctx context.Context) (err error) {
 	expiredIndex := mongo.IndexModel{
 		Keys: bson.D{
 			{Key: "next_run_at", Value: 1},
@@ -40,115 +47,410 @@ func (db *mongoJobDB) InitJobDB() (err error) {
 		},
 	}
 
+	leaseIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "lease_expires_at", Value: 1},
+			{Key: "status", Value: 1},
+		},
+	}
+
 	nameIndex := mongo.IndexModel{
 		Keys: bson.D{
 			{Key: "name", Value: 1},
 		},
 	}
 
+	uniqueKeyIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "unique_key", Value: 1},
+		},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"unique_key": bson.M{"$type": "string"}}),
+	}
+
 	_, err = db.conn.
 		Database(db.dbName).
 		Collection(db.collName).
 		Indexes().
-		CreateMany(context.Background(), []mongo.IndexModel{
+		CreateMany(ctx, []mongo.IndexModel{
 			expiredIndex,
 			statusIndex,
+			leaseIndex,
 			nameIndex,
+			uniqueKeyIndex,
+		})
+	if err != nil {
+		return
+	}
+
+	runsJobIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "job_id", Value: 1},
+			{Key: "started_at", Value: -1},
+		},
+	}
+
+	runsStartedIndex := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "started_at", Value: -1},
+		},
+	}
+
+	_, err = db.conn.
+		Database(db.dbName).
+		Collection(db.runsCollName).
+		Indexes().
+		CreateMany(ctx, []mongo.IndexModel{
+			runsJobIndex,
+			runsStartedIndex,
 		})
 
 	return
 }
 
-func (db *mongoJobDB) ListExpiredSchedules() (js []*Job, err error) {
-	ctx := context.TODO()
+func (db *mongoJobDB) ListExpiredSchedules(ctx context.Context) (js []*Job, err error) {
 	f := bson.M{
-		"next_run_at": bson.M{"$lte": now()},
+		"next_run_at": bson.M{"$lte": NowFromContext(ctx)},
 		"status":      PENDING.String(),
 	}
 
-	cursor, err := db.conn.
+	return db.find(ctx, f)
+}
+
+func (db *mongoJobDB) ClaimJob(ctx context.Context, j Job) (claimed bool, err error) {
+	doc := marshalJob(j)
+	if doc.ID == nil {
+		err = fmt.Errorf("Failed to claim job, invalid job ID '%s'", j.ID)
+		return
+	}
+
+	filter := bson.M{
+		"_id":         doc.ID,
+		"status":      PENDING.String(),
+		"next_run_at": j.NextRunAt,
+	}
+	update := bson.M{"$set": bson.M{
+		"status":           RUNNING.String(),
+		"owner":            j.Owner,
+		"lease_expires_at": j.LeaseExpiresAt,
+	}}
+
+	res, err := db.conn.
 		Database(db.dbName).
 		Collection(db.collName).
-		Find(ctx, f)
+		UpdateOne(
+			ctx,
+			filter,
+			update,
+		)
 	if err != nil {
 		return
 	}
 
-	defer cursor.Close(ctx)
+	claimed = res.MatchedCount == 1
+	return
+}
 
-	for cursor.Next(ctx) {
-		var jd jobDocument
-		err = cursor.Decode(&jd)
-		if err != nil {
-			continue
-		}
-		j := unmarshalJob(jd)
-		js = append(js, &j)
+func (db *mongoJobDB) RenewLease(ctx context.Context, j Job) (renewed bool, err error) {
+	doc := marshalJob(j)
+	if doc.ID == nil {
+		err = fmt.Errorf("Failed to renew job lease, invalid job ID '%s'", j.ID)
+		return
+	}
+
+	filter := bson.M{
+		"_id":    doc.ID,
+		"status": RUNNING.String(),
+		"owner":  j.Owner,
 	}
+	update := bson.M{"$set": bson.M{
+		"lease_expires_at": j.LeaseExpiresAt,
+	}}
 
+	res, err := db.conn.
+		Database(db.dbName).
+		Collection(db.collName).
+		UpdateOne(
+			ctx,
+			filter,
+			update,
+		)
+	if err != nil {
+		return
+	}
+
+	renewed = res.MatchedCount == 1
 	return
 }
 
-func (db *mongoJobDB) List(f Finder) (js []*Job, err error) {
-	ctx := context.TODO()
-	filter := parseListFilter(f)
+func (db *mongoJobDB) ListExpiredLeases(ctx context.Context) (js []*Job, err error) {
+	f := bson.M{
+		"lease_expires_at": bson.M{"$lte": NowFromContext(ctx)},
+		"status":           RUNNING.String(),
+	}
+
+	return db.find(ctx, f)
+}
 
-	cursor, err := db.conn.
+func (db *mongoJobDB) RecoverJob(ctx context.Context, j Job) (recovered bool, err error) {
+	doc := marshalJob(j)
+	if doc.ID == nil {
+		err = fmt.Errorf("Failed to recover job, invalid job ID '%s'", j.ID)
+		return
+	}
+
+	filter := bson.M{
+		"_id":              doc.ID,
+		"status":           RUNNING.String(),
+		"lease_expires_at": bson.M{"$lte": NowFromContext(ctx)},
+	}
+
+	res, err := db.conn.
 		Database(db.dbName).
 		Collection(db.collName).
-		Find(ctx, filter)
+		ReplaceOne(
+			ctx,
+			filter,
+			doc,
+		)
 	if err != nil {
 		return
 	}
 
-	defer cursor.Close(ctx)
+	recovered = res.MatchedCount == 1
+	return
+}
 
-	for cursor.Next(ctx) {
-		var jd jobDocument
-		err = cursor.Decode(&jd)
-		if err != nil {
-			continue
-		}
-		j := unmarshalJob(jd)
-		js = append(js, &j)
+func (db *mongoJobDB) ReleaseJob(ctx context.Context, j Job) (released bool, err error) {
+	doc := marshalJob(j)
+	if doc.ID == nil {
+		err = fmt.Errorf("Failed to release job, invalid job ID '%s'", j.ID)
+		return
+	}
+
+	filter := bson.M{
+		"_id":    doc.ID,
+		"status": RUNNING.String(),
+		"owner":  j.Owner,
+	}
+	update := bson.M{"$set": bson.M{
+		"status":           PENDING.String(),
+		"owner":            "",
+		"lease_expires_at": nil,
+	}}
+
+	res, err := db.conn.
+		Database(db.dbName).
+		Collection(db.collName).
+		UpdateOne(
+			ctx,
+			filter,
+			update,
+		)
+	if err != nil {
+		return
 	}
 
+	released = res.MatchedCount == 1
 	return
 }
 
-func (db *mongoJobDB) SaveJob(j Job) (err error) {
+func (db *mongoJobDB) FinishJob(ctx context.Context, j Job, owner string) (finished bool, err error) {
+	doc := marshalJob(j)
+	if doc.ID == nil {
+		err = fmt.Errorf("Failed to finish job, invalid job ID '%s'", j.ID)
+		return
+	}
+
+	filter := bson.M{
+		"_id":    doc.ID,
+		"status": RUNNING.String(),
+		"owner":  owner,
+	}
+
+	res, err := db.conn.
+		Database(db.dbName).
+		Collection(db.collName).
+		ReplaceOne(
+			ctx,
+			filter,
+			doc,
+		)
+	if err != nil {
+		return
+	}
+
+	finished = res.MatchedCount == 1
+	return
+}
+
+func (db *mongoJobDB) List(ctx context.Context, f Finder) (js []*Job, err error) {
+	return db.find(ctx, parseListFilter(f), parseListOptions(f))
+}
+
+func (db *mongoJobDB) Count(ctx context.Context, f Finder) (count int, err error) {
+	res, err := db.conn.
+		Database(db.dbName).
+		Collection(db.collName).
+		CountDocuments(ctx, parseListFilter(f))
+	if err != nil {
+		return
+	}
+
+	count = int(res)
+	return
+}
+
+func (db *mongoJobDB) GetJob(ctx context.Context, id string) (*Job, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrJobNotFound
+	}
+
+	js, err := db.find(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(js) == 0 {
+		return nil, ErrJobNotFound
+	}
+
+	return js[0], nil
+}
+
+func (db *mongoJobDB) UpdateJob(ctx context.Context, j Job, old Job) (updated bool, err error) {
+	doc := marshalJob(j)
+	if doc.ID == nil {
+		err = fmt.Errorf("Failed to update job, invalid job ID '%s'", j.ID)
+		return
+	}
+
+	filter := bson.M{
+		"_id":         doc.ID,
+		"status":      bson.M{"$eq": old.Status.String(), "$ne": RUNNING.String()},
+		"next_run_at": old.NextRunAt,
+	}
+
+	res, err := db.conn.
+		Database(db.dbName).
+		Collection(db.collName).
+		ReplaceOne(
+			ctx,
+			filter,
+			doc,
+		)
+	if err != nil {
+		return
+	}
+
+	updated = res.MatchedCount == 1
+	return
+}
+
+func (db *mongoJobDB) SaveUniqueJob(ctx context.Context, j Job, replace bool) (id string, saved bool, err error) {
+	doc := marshalJob(j)
+	doc.ID = nil
+
+	filter := bson.M{"unique_key": j.UniqueKey}
+
+	var res *mongo.UpdateResult
+	if replace {
+		// the whole document is replaced, so no field of the existing job is left behind,
+		// and a RUNNING job does not match, so the upsert tries to insert a new job and fails with a duplicate key error
+		filter["status"] = bson.M{"$ne": RUNNING.String()}
+		res, err = db.conn.
+			Database(db.dbName).
+			Collection(db.collName).
+			ReplaceOne(
+				ctx,
+				filter,
+				doc,
+				options.Replace().SetUpsert(true),
+			)
+	} else {
+		// without replacing, the new job is only set when its inserted
+		res, err = db.conn.
+			Database(db.dbName).
+			Collection(db.collName).
+			UpdateOne(
+				ctx,
+				filter,
+				bson.M{"$setOnInsert": doc},
+				options.Update().SetUpsert(true),
+			)
+	}
+
+	switch {
+	case mongo.IsDuplicateKeyError(err) && replace:
+		return "", false, ErrJobRunning
+	case mongo.IsDuplicateKeyError(err):
+		// the job was inserted concurrently by another scheduler instance, so it is kept
+	case err != nil:
+		return "", false, err
+	default:
+		if oid, ok := res.UpsertedID.(primitive.ObjectID); ok {
+			return oid.Hex(), true, nil
+		}
+	}
+
+	js, err := db.find(ctx, bson.M{"unique_key": j.UniqueKey})
+	if err != nil {
+		return "", false, err
+	}
+	if len(js) == 0 {
+		return "", false, ErrJobNotFound
+	}
+
+	return js[0].ID, replace, nil
+}
+
+func (db *mongoJobDB) SaveJob(ctx context.Context, j Job) (id string, err error) {
 	doc := marshalJob(j)
 
 	if doc.ID == nil {
 		// no ID, then its a new job
-		_, err = db.conn.
+		res, err := db.conn.
 			Database(db.dbName).
 			Collection(db.collName).
 			InsertOne(
-				context.TODO(),
+				ctx,
 				doc,
 			)
-		return
+		if err != nil {
+			return "", err
+		}
+
+		oid, ok := res.InsertedID.(primitive.ObjectID)
+		if !ok {
+			return "", fmt.Errorf("Unexpected inserted ID type %T", res.InsertedID)
+		}
+
+		return oid.Hex(), nil
 	}
 
-	options := options.Update().SetUpsert(true)
-	update := bson.M{"$set": doc}
+	// the whole document is replaced, so the fields that were cleared on the job (Ex.: its last error) are not left behind
+	options := options.Replace().SetUpsert(true)
 	filter := bson.M{"_id": doc.ID}
 
 	_, err = db.conn.
 		Database(db.dbName).
 		Collection(db.collName).
-		UpdateOne(
-			context.TODO(),
+		ReplaceOne(
+			ctx,
 			filter,
-			update,
+			doc,
 			options,
 		)
+	if err != nil {
+		return "", err
+	}
 
-	return
+	return doc.ID.Hex(), nil
 }
 
-func (db *mongoJobDB) DeleteJob(j Job) (err error) {
+func (db *mongoJobDB) DeleteJob(ctx context.Context, j Job) (err error) {
 	doc := marshalJob(j)
 	filter := bson.M{"_id": doc.ID}
 
@@ -156,13 +458,145 @@ func (db *mongoJobDB) DeleteJob(j Job) (err error) {
 		Database(db.dbName).
 		Collection(db.collName).
 		DeleteOne(
-			context.TODO(),
+			ctx,
 			filter,
 		)
 
 	return
 }
 
+func (db *mongoJobDB) CancelMany(ctx context.Context, f Finder) (canceled int, err error) {
+	update := bson.M{"$set": bson.M{
+		"status": CANCELED.String(),
+	}}
+
+	res, err := db.conn.
+		Database(db.dbName).
+		Collection(db.collName).
+		UpdateMany(ctx, parseBulkFilter(f), update)
+	if err != nil {
+		return
+	}
+
+	canceled = int(res.ModifiedCount)
+	return
+}
+
+func (db *mongoJobDB) DeleteMany(ctx context.Context, f Finder) (deleted int, err error) {
+	res, err := db.conn.
+		Database(db.dbName).
+		Collection(db.collName).
+		DeleteMany(ctx, parseBulkFilter(f))
+	if err != nil {
+		return
+	}
+
+	deleted = int(res.DeletedCount)
+	return
+}
+
+func (db *mongoJobDB) RescheduleMany(ctx context.Context, f Finder, delta time.Duration) (rescheduled int, err error) {
+	// an update pipeline, so that the delta (in milliseconds) is added to the current next_run_at of each job
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"next_run_at": bson.M{"$add": bson.A{"$next_run_at", delta.Milliseconds()}},
+		}}},
+	}
+
+	res, err := db.conn.
+		Database(db.dbName).
+		Collection(db.collName).
+		UpdateMany(ctx, parseBulkFilter(f), update)
+	if err != nil {
+		return
+	}
+
+	rescheduled = int(res.ModifiedCount)
+	return
+}
+
+// find finds the jobs that match the given filter on the job collection
+func (db *mongoJobDB) find(ctx context.Context, filter bson.M, opts ...*options.FindOptions) (js []*Job, err error) {
+	cursor, err := db.conn.
+		Database(db.dbName).
+		Collection(db.collName).
+		Find(ctx, filter, opts...)
+	if err != nil {
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var jd jobDocument
+		err = cursor.Decode(&jd)
+		if err != nil {
+			continue
+		}
+		j := unmarshalJob(jd)
+		js = append(js, &j)
+	}
+
+	return
+}
+
+func (db *mongoJobDB) SaveRun(ctx context.Context, r JobRun) (err error) {
+	doc := marshalRun(r)
+	doc.ID = nil
+
+	_, err = db.conn.
+		Database(db.dbName).
+		Collection(db.runsCollName).
+		InsertOne(
+			ctx,
+			doc,
+		)
+
+	return
+}
+
+func (db *mongoJobDB) ListRuns(ctx context.Context, f RunFinder) (rs []*JobRun, err error) {
+	options := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}})
+	if f.Limit > 0 {
+		options.SetLimit(int64(f.Limit))
+	}
+
+	cursor, err := db.conn.
+		Database(db.dbName).
+		Collection(db.runsCollName).
+		Find(ctx, parseRunFilter(f), options)
+	if err != nil {
+		return
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var rd runDocument
+		err = cursor.Decode(&rd)
+		if err != nil {
+			continue
+		}
+		r := unmarshalRun(rd)
+		rs = append(rs, &r)
+	}
+
+	return
+}
+
+func (db *mongoJobDB) DeleteRunsBefore(ctx context.Context, t time.Time) (deleted int, err error) {
+	res, err := db.conn.
+		Database(db.dbName).
+		Collection(db.runsCollName).
+		DeleteMany(ctx, bson.M{"started_at": bson.M{"$lt": t}})
+	if err != nil {
+		return
+	}
+
+	deleted = int(res.DeletedCount)
+	return
+}
+
 func parseListFilter(f Finder) (filter bson.M) {
 	filter = bson.M{}
 
@@ -170,8 +604,20 @@ func parseListFilter(f Finder) (filter bson.M) {
 		filter["name"] = f.Name
 	}
 
-	if f.Status != "" {
-		filter["status"] = f.Status
+	statuses := f.statuses()
+	if len(statuses) == 1 {
+		filter["status"] = statuses[0].String()
+	}
+	if len(statuses) > 1 {
+		ss := make([]string, len(statuses))
+		for i, status := range statuses {
+			ss[i] = status.String()
+		}
+		filter["status"] = bson.M{"$in": ss}
+	}
+
+	if f.ScheduleType != "" {
+		filter["schedule_type"] = f.ScheduleType.String()
 	}
 
 	if f.Data != nil {
@@ -181,5 +627,112 @@ func parseListFilter(f Finder) (filter bson.M) {
 		}
 	}
 
+	if r := parseTimeRange(f.NextRunAfter, f.NextRunBefore); len(r) > 0 {
+		filter["next_run_at"] = r
+	}
+
+	if r := parseTimeRange(f.LastRunAfter, f.LastRunBefore); len(r) > 0 {
+		filter["last_run_at"] = r
+	}
+
+	return
+}
+
+// parseBulkFilter parses the finder into a filter of the bulk operations, that never match the RUNNING jobs
+func parseBulkFilter(f Finder) bson.M {
+	return bson.M{"$and": bson.A{
+		parseListFilter(f),
+		bson.M{"status": bson.M{"$ne": RUNNING.String()}},
+	}}
+}
+
+// parseTimeRange parses the time range into a filter that matches the times after or equal to after, and before before.
+//
+// A zero time leaves its side of the range open.
+func parseTimeRange(after, before time.Time) (r bson.M) {
+	r = bson.M{}
+	if !after.IsZero() {
+		r["$gte"] = after
+	}
+	if !before.IsZero() {
+		r["$lt"] = before
+	}
+
+	return
+}
+
+// sortFields maps the finder sort fields to the job document fields
+var sortFields = map[SortField]string{
+	SORT_BY_NEXT_RUN_AT: "next_run_at",
+	SORT_BY_LAST_RUN_AT: "last_run_at",
+	SORT_BY_NAME:        "name",
+	SORT_BY_STATUS:      "status",
+}
+
+// parseListOptions parses the finder sorting and pagination into the find options.
+//
+// The jobs are always sorted by their ID last, so that the pages are stable.
+func parseListOptions(f Finder) *options.FindOptions {
+	order := 1
+	if f.SortDescending {
+		order = -1
+	}
+
+	sort := bson.D{}
+	if field, ok := sortFields[f.SortBy]; ok {
+		sort = append(sort, bson.E{Key: field, Value: order})
+	}
+	sort = append(sort, bson.E{Key: "_id", Value: order})
+
+	opts := options.Find().SetSort(sort)
+	if f.Limit > 0 {
+		opts.SetLimit(int64(f.Limit))
+	}
+	if f.Offset > 0 {
+		opts.SetSkip(int64(f.Offset))
+	}
+	if len(f.Fields) > 0 {
+		opts.SetProjection(parseListProjection(f))
+	}
+
+	return opts
+}
+
+// parseListProjection translates the finder fields into the job document fields that should be listed
+func parseListProjection(f Finder) bson.M {
+	t := reflect.TypeOf(jobDocument{})
+
+	projection := bson.M{}
+	for _, field := range f.Fields {
+		sf, ok := t.FieldByName(field)
+		if !ok {
+			continue
+		}
+
+		projection[strings.Split(sf.Tag.Get("bson"), ",")[0]] = 1
+	}
+
+	return projection
+}
+
+func parseRunFilter(f RunFinder) (filter bson.M) {
+	filter = bson.M{}
+
+	if f.JobID != "" {
+		filter["job_id"] = f.JobID
+	}
+
+	if f.JobName != "" {
+		filter["job_name"] = f.JobName
+	}
+
+	if f.Outcome != "" {
+		filter["outcome"] = f.Outcome.String()
+	}
+
+	if r := parseTimeRange(f.StartedAfter, f.StartedBefore); len(r) > 0 {
+		filter["started_at"] = r
+	}
+
 	return
 }
@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseListFilter(t *testing.T) {
+	t.Run("Should not filter anything if the finder is empty", func(t *testing.T) {
+		assert.Equal(t, bson.M{}, parseListFilter(Finder{}))
+	})
+	t.Run("Should filter by the exact values of the finder", func(t *testing.T) {
+		filter := parseListFilter(Finder{
+			Name:         "MYMOCKJOB!",
+			Status:       "PENDING",
+			ScheduleType: RECURRENT,
+			Data:         map[string]any{"key": "value"},
+		})
+
+		assert.Equal(t, bson.M{
+			"name":          "MYMOCKJOB!",
+			"status":        "PENDING",
+			"schedule_type": "RECURRENT",
+			"data.key":      "value",
+		}, filter)
+	})
+	t.Run("Should filter by any of the finder statuses", func(t *testing.T) {
+		filter := parseListFilter(Finder{
+			Status:   "CANCELED",
+			Statuses: []ScheduleStatus{FAILED, TIMED_OUT},
+		})
+
+		assert.Equal(t, bson.M{"$in": []string{"FAILED", "TIMED_OUT", "CANCELED"}}, filter["status"])
+	})
+	t.Run("Should filter by the finder time ranges", func(t *testing.T) {
+		now := time.Now()
+		filter := parseListFilter(Finder{
+			NextRunAfter:  now,
+			NextRunBefore: now.Add(time.Hour),
+			LastRunBefore: now,
+		})
+
+		assert.Equal(t, bson.M{"$gte": now, "$lt": now.Add(time.Hour)}, filter["next_run_at"])
+		assert.Equal(t, bson.M{"$lt": now}, filter["last_run_at"])
+	})
+}
+
+func TestParseListOptions(t *testing.T) {
+	t.Run("Should sort the jobs by their ID if no sort field is set", func(t *testing.T) {
+		opts := parseListOptions(Finder{})
+
+		assert.Equal(t, bson.D{{Key: "_id", Value: 1}}, opts.Sort)
+		assert.Nil(t, opts.Limit)
+		assert.Nil(t, opts.Skip)
+	})
+	t.Run("Should sort and paginate the jobs given the finder", func(t *testing.T) {
+		opts := parseListOptions(Finder{
+			SortBy:         SORT_BY_NEXT_RUN_AT,
+			SortDescending: true,
+			Limit:          10,
+			Offset:         20,
+		})
+
+		assert.Equal(t, bson.D{{Key: "next_run_at", Value: -1}, {Key: "_id", Value: -1}}, opts.Sort)
+		assert.Equal(t, int64(10), *opts.Limit)
+		assert.Equal(t, int64(20), *opts.Skip)
+		assert.Nil(t, opts.Projection)
+	})
+	t.Run("Should project the jobs given the finder fields", func(t *testing.T) {
+		opts := parseListOptions(Finder{Fields: []string{"Name", "ScheduleType", "NextRunAt"}})
+
+		assert.Equal(t, bson.M{"name": 1, "schedule_type": 1, "next_run_at": 1}, opts.Projection)
+	})
+}
+
+func TestParseBulkFilter(t *testing.T) {
+	t.Run("Should never match the RUNNING jobs", func(t *testing.T) {
+		filter := parseBulkFilter(Finder{Name: "MYMOCKJOB!"})
+
+		assert.Equal(t, bson.M{"$and": bson.A{
+			bson.M{"name": "MYMOCKJOB!"},
+			bson.M{"status": bson.M{"$ne": "RUNNING"}},
+		}}, filter)
+	})
+}
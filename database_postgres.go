@@ -0,0 +1,891 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type postgresJobDB struct {
+	conn *sql.DB
+
+	// table and runsTable are the quoted names of the jobs and job runs tables
+	table     string
+	runsTable string
+
+	// migrationsTable its the quoted name of the table that tracks the applied migrations
+	migrationsTable string
+
+	// indexPrefix its the prefix of the index names, given by the jobs table name
+	indexPrefix string
+}
+
+// postgresTableName matches the table names that the library accepts, optionally qualified by a schema
+var postgresTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+func newPostgres(
+	conn *sql.DB,
+	tableName,
+	runsTableName string) (JobDatabase, error) {
+	for _, name := range []string{tableName, runsTableName} {
+		if !postgresTableName.MatchString(name) {
+			return nil, fmt.Errorf("Invalid postgres table name '%s'", name)
+		}
+	}
+
+	parts := strings.Split(tableName, ".")
+
+	return &postgresJobDB{
+		conn,
+		quoteIdentifier(tableName),
+		quoteIdentifier(runsTableName),
+		quoteIdentifier(tableName + "_migrations"),
+		parts[len(parts)-1],
+	}, nil
+}
+
+// quoteIdentifier quotes each part of the table name, so that it can be used on the queries
+func quoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = `"` + part + `"`
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// postgresMigrations returns the statements that create and change the library tables, in order.
+//
+// The applied migrations are tracked by their position, so new migrations should only ever be appended.
+func (db *postgresJobDB) postgresMigrations() []string {
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			name TEXT NOT NULL,
+			data JSONB,
+			schedule_type TEXT NOT NULL,
+			status TEXT NOT NULL,
+			owner TEXT NOT NULL DEFAULT '',
+			lease_expires_at TIMESTAMPTZ,
+			orphan_count INTEGER NOT NULL DEFAULT 0,
+			next_run_at TIMESTAMPTZ NOT NULL,
+			last_run_at TIMESTAMPTZ,
+			schedule_string TEXT NOT NULL DEFAULT '',
+			schedule_limit_date TIMESTAMPTZ,
+			location TEXT NOT NULL DEFAULT '',
+			retry_policy JSONB,
+			timeout BIGINT NOT NULL DEFAULT 0,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			failed_at TIMESTAMPTZ,
+			failure_count INTEGER NOT NULL DEFAULT 0,
+			unique_key TEXT UNIQUE
+		)`, db.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS "%s_expired_idx" ON %s (status, next_run_at)`, db.indexPrefix, db.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS "%s_lease_idx" ON %s (status, lease_expires_at)`, db.indexPrefix, db.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS "%s_name_idx" ON %s (name)`, db.indexPrefix, db.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS "%s_data_idx" ON %s USING GIN (data jsonb_path_ops)`, db.indexPrefix, db.table),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			job_id TEXT NOT NULL,
+			job_name TEXT NOT NULL,
+			attempt INTEGER NOT NULL,
+			worker_id TEXT NOT NULL,
+			started_at TIMESTAMPTZ NOT NULL,
+			finished_at TIMESTAMPTZ NOT NULL,
+			outcome TEXT NOT NULL,
+			error TEXT NOT NULL DEFAULT ''
+		)`, db.runsTable),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS "%s_runs_job_idx" ON %s (job_id, started_at DESC)`, db.indexPrefix, db.runsTable),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS "%s_runs_started_idx" ON %s (started_at DESC)`, db.indexPrefix, db.runsTable),
+		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS schedule_anchor TIMESTAMPTZ`, db.table),
+	}
+}
+
+func (db *postgresJobDB) InitJobDB(ctx context.Context) (err error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// the lock is held until the transaction ends, so that concurrent instances do not migrate the tables at the same time
+	_, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, db.table)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		version INTEGER PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`, db.migrationsTable))
+	if err != nil {
+		return
+	}
+
+	var version int
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT COALESCE(MAX(version), 0) FROM %s`, db.migrationsTable)).Scan(&version)
+	if err != nil {
+		return
+	}
+
+	migrations := db.postgresMigrations()
+	for v := version + 1; v <= len(migrations); v++ {
+		_, err = tx.ExecContext(ctx, migrations[v-1])
+		if err != nil {
+			return fmt.Errorf("Failed to apply migration %d, %v", v, err)
+		}
+
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (version) VALUES ($1)`, db.migrationsTable), v)
+		if err != nil {
+			return
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (db *postgresJobDB) ListExpiredSchedules(ctx context.Context) ([]*Job, error) {
+	// the jobs being claimed by other instances are locked, and are skipped instead of waited for
+	q := &pgQuery{}
+	query := fmt.Sprintf(
+		`SELECT %s FROM %s WHERE status = %s AND next_run_at <= %s ORDER BY next_run_at, id FOR UPDATE SKIP LOCKED`,
+		jobSelectColumns, db.table, q.arg(PENDING.String()), q.arg(NowFromContext(ctx)),
+	)
+
+	return db.queryJobs(ctx, query, q.args)
+}
+
+func (db *postgresJobDB) ClaimJob(ctx context.Context, j Job) (bool, error) {
+	id, err := parsePostgresID(j.ID)
+	if err != nil {
+		return false, fmt.Errorf("Failed to claim job, invalid job ID '%s'", j.ID)
+	}
+
+	// SKIP LOCKED makes the instances that lose the race for the job row skip it right away,
+	// instead of waiting for the winner transaction to claim it
+	q := &pgQuery{}
+	query := fmt.Sprintf(
+		`UPDATE %s SET status = %s, owner = %s, lease_expires_at = %s
+		WHERE id = (SELECT id FROM %s WHERE id = %s AND status = %s AND next_run_at = %s FOR UPDATE SKIP LOCKED)`,
+		db.table, q.arg(RUNNING.String()), q.arg(j.Owner), q.arg(j.LeaseExpiresAt),
+		db.table, q.arg(id), q.arg(PENDING.String()), q.arg(j.NextRunAt),
+	)
+
+	claimed, err := db.exec(ctx, query, q.args)
+	return claimed == 1, err
+}
+
+func (db *postgresJobDB) RenewLease(ctx context.Context, j Job) (bool, error) {
+	id, err := parsePostgresID(j.ID)
+	if err != nil {
+		return false, fmt.Errorf("Failed to renew job lease, invalid job ID '%s'", j.ID)
+	}
+
+	q := &pgQuery{}
+	query := fmt.Sprintf(
+		`UPDATE %s SET lease_expires_at = %s WHERE id = %s AND status = %s AND owner = %s`,
+		db.table, q.arg(j.LeaseExpiresAt), q.arg(id), q.arg(RUNNING.String()), q.arg(j.Owner),
+	)
+
+	renewed, err := db.exec(ctx, query, q.args)
+	return renewed == 1, err
+}
+
+func (db *postgresJobDB) ListExpiredLeases(ctx context.Context) ([]*Job, error) {
+	q := &pgQuery{}
+	query := fmt.Sprintf(
+		`SELECT %s FROM %s WHERE status = %s AND lease_expires_at <= %s ORDER BY id`,
+		jobSelectColumns, db.table, q.arg(RUNNING.String()), q.arg(NowFromContext(ctx)),
+	)
+
+	return db.queryJobs(ctx, query, q.args)
+}
+
+func (db *postgresJobDB) RecoverJob(ctx context.Context, j Job) (bool, error) {
+	id, err := parsePostgresID(j.ID)
+	if err != nil {
+		return false, fmt.Errorf("Failed to recover job, invalid job ID '%s'", j.ID)
+	}
+
+	q := &pgQuery{}
+	set, err := jobAssignments(q, j)
+	if err != nil {
+		return false, err
+	}
+
+	query := fmt.Sprintf(
+		`UPDATE %s SET %s WHERE id = %s AND status = %s AND lease_expires_at <= %s`,
+		db.table, set, q.arg(id), q.arg(RUNNING.String()), q.arg(NowFromContext(ctx)),
+	)
+
+	recovered, err := db.exec(ctx, query, q.args)
+	return recovered == 1, err
+}
+
+func (db *postgresJobDB) ReleaseJob(ctx context.Context, j Job) (bool, error) {
+	id, err := parsePostgresID(j.ID)
+	if err != nil {
+		return false, fmt.Errorf("Failed to release job, invalid job ID '%s'", j.ID)
+	}
+
+	q := &pgQuery{}
+	query := fmt.Sprintf(
+		`UPDATE %s SET status = %s, owner = '', lease_expires_at = NULL WHERE id = %s AND status = %s AND owner = %s`,
+		db.table, q.arg(PENDING.String()), q.arg(id), q.arg(RUNNING.String()), q.arg(j.Owner),
+	)
+
+	released, err := db.exec(ctx, query, q.args)
+	return released == 1, err
+}
+
+func (db *postgresJobDB) FinishJob(ctx context.Context, j Job, owner string) (bool, error) {
+	id, err := parsePostgresID(j.ID)
+	if err != nil {
+		return false, fmt.Errorf("Failed to finish job, invalid job ID '%s'", j.ID)
+	}
+
+	q := &pgQuery{}
+	set, err := jobAssignments(q, j)
+	if err != nil {
+		return false, err
+	}
+
+	query := fmt.Sprintf(
+		`UPDATE %s SET %s WHERE id = %s AND status = %s AND owner = %s`,
+		db.table, set, q.arg(id), q.arg(RUNNING.String()), q.arg(owner),
+	)
+
+	finished, err := db.exec(ctx, query, q.args)
+	return finished == 1, err
+}
+
+func (db *postgresJobDB) List(ctx context.Context, f Finder) ([]*Job, error) {
+	q := &pgQuery{}
+	where, err := parsePostgresFilter(q, f)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(
+		`SELECT %s FROM %s WHERE %s %s`,
+		jobSelectColumns, db.table, where, parsePostgresOrder(q, f),
+	)
+
+	return db.queryJobs(ctx, query, q.args)
+}
+
+func (db *postgresJobDB) Count(ctx context.Context, f Finder) (count int, err error) {
+	q := &pgQuery{}
+	where, err := parsePostgresFilter(q, f)
+	if err != nil {
+		return
+	}
+
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s`, db.table, where)
+	err = db.conn.QueryRowContext(ctx, query, q.args...).Scan(&count)
+	return
+}
+
+func (db *postgresJobDB) GetJob(ctx context.Context, id string) (*Job, error) {
+	pid, err := parsePostgresID(id)
+	if err != nil {
+		return nil, ErrJobNotFound
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1`, jobSelectColumns, db.table)
+	j, err := scanJob(db.conn.QueryRowContext(ctx, query, pid))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJobNotFound
+	}
+
+	return j, err
+}
+
+func (db *postgresJobDB) UpdateJob(ctx context.Context, j Job, old Job) (bool, error) {
+	id, err := parsePostgresID(j.ID)
+	if err != nil {
+		return false, fmt.Errorf("Failed to update job, invalid job ID '%s'", j.ID)
+	}
+
+	q := &pgQuery{}
+	set, err := jobAssignments(q, j)
+	if err != nil {
+		return false, err
+	}
+
+	query := fmt.Sprintf(
+		`UPDATE %s SET %s WHERE id = %s AND status <> %s AND status = %s AND next_run_at = %s`,
+		db.table, set, q.arg(id), q.arg(RUNNING.String()), q.arg(old.Status.String()), q.arg(old.NextRunAt),
+	)
+
+	updated, err := db.exec(ctx, query, q.args)
+	return updated == 1, err
+}
+
+func (db *postgresJobDB) SaveJob(ctx context.Context, j Job) (id string, err error) {
+	q := &pgQuery{}
+	values, err := jobValues(q, j)
+	if err != nil {
+		return
+	}
+
+	if j.ID == "" {
+		// no ID, then its a new job
+		query := fmt.Sprintf(
+			`INSERT INTO %s (%s) VALUES (%s) RETURNING id`,
+			db.table, strings.Join(jobColumns, ", "), strings.Join(values, ", "),
+		)
+
+		return db.queryID(ctx, query, q.args)
+	}
+
+	pid, err := parsePostgresID(j.ID)
+	if err != nil {
+		return "", fmt.Errorf("Failed to save job, invalid job ID '%s'", j.ID)
+	}
+
+	query := fmt.Sprintf(
+		`INSERT INTO %s (id, %s) VALUES (%s, %s) ON CONFLICT (id) DO UPDATE SET %s RETURNING id`,
+		db.table, strings.Join(jobColumns, ", "), q.arg(pid), strings.Join(values, ", "), excludedAssignments(),
+	)
+
+	id, err = db.queryID(ctx, query, q.args)
+	if err != nil {
+		return
+	}
+
+	// a job inserted with its own ID does not advance the ID sequence,
+	// so the sequence is moved past the ID, for the jobs inserted later not to be given the same ID
+	sq := &pgQuery{}
+	query = fmt.Sprintf(
+		`SELECT setval(seq::regclass, %s) FROM pg_get_serial_sequence(%s, 'id') AS seq
+		WHERE %s > COALESCE(pg_sequence_last_value(seq::regclass), 0)`,
+		sq.arg(pid), sq.arg(db.table), sq.arg(pid),
+	)
+	if _, err = db.exec(ctx, query, sq.args); err != nil {
+		return "", fmt.Errorf("Failed to advance the job ID sequence, %v", err)
+	}
+
+	return
+}
+
+func (db *postgresJobDB) SaveUniqueJob(ctx context.Context, j Job, replace bool) (id string, saved bool, err error) {
+	q := &pgQuery{}
+	values, err := jobValues(q, j)
+	if err != nil {
+		return
+	}
+
+	// without replacing, the new job is only inserted if no job holds its unique key
+	conflict := `DO NOTHING`
+	if replace {
+		// a RUNNING job is not updated, so that no row is returned
+		conflict = fmt.Sprintf(`DO UPDATE SET %s WHERE existing.status <> %s`, excludedAssignments(), q.arg(RUNNING.String()))
+	}
+
+	query := fmt.Sprintf(
+		`INSERT INTO %s AS existing (%s) VALUES (%s) ON CONFLICT (unique_key) %s RETURNING id`,
+		db.table, strings.Join(jobColumns, ", "), strings.Join(values, ", "), conflict,
+	)
+
+	id, err = db.queryID(ctx, query, q.args)
+	switch {
+	case err == nil:
+		return id, true, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return "", false, err
+	case replace:
+		return "", false, ErrJobRunning
+	}
+
+	id, err = db.queryID(ctx, fmt.Sprintf(`SELECT id FROM %s WHERE unique_key = $1`, db.table), []any{j.UniqueKey})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, ErrJobNotFound
+	}
+
+	return id, false, err
+}
+
+func (db *postgresJobDB) DeleteJob(ctx context.Context, j Job) error {
+	id, err := parsePostgresID(j.ID)
+	if err != nil {
+		return fmt.Errorf("Failed to delete job, invalid job ID '%s'", j.ID)
+	}
+
+	_, err = db.exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, db.table), []any{id})
+	return err
+}
+
+func (db *postgresJobDB) CancelMany(ctx context.Context, f Finder) (int, error) {
+	q := &pgQuery{}
+	where, err := parsePostgresFilter(q, f)
+	if err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf(
+		`UPDATE %s SET status = %s WHERE %s AND status NOT IN (%s, %s)`,
+		db.table, q.arg(CANCELED.String()), where, q.arg(RUNNING.String()), q.arg(CANCELED.String()),
+	)
+
+	return db.exec(ctx, query, q.args)
+}
+
+func (db *postgresJobDB) DeleteMany(ctx context.Context, f Finder) (int, error) {
+	q := &pgQuery{}
+	where, err := parsePostgresFilter(q, f)
+	if err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf(
+		`DELETE FROM %s WHERE %s AND status <> %s`,
+		db.table, where, q.arg(RUNNING.String()),
+	)
+
+	return db.exec(ctx, query, q.args)
+}
+
+func (db *postgresJobDB) RescheduleMany(ctx context.Context, f Finder, delta time.Duration) (int, error) {
+	if delta == 0 {
+		return 0, nil
+	}
+
+	q := &pgQuery{}
+	where, err := parsePostgresFilter(q, f)
+	if err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf(
+		`UPDATE %s SET next_run_at = next_run_at + %s * INTERVAL '1 microsecond' WHERE %s AND status <> %s`,
+		db.table, q.arg(delta.Microseconds()), where, q.arg(RUNNING.String()),
+	)
+
+	return db.exec(ctx, query, q.args)
+}
+
+func (db *postgresJobDB) SaveRun(ctx context.Context, r JobRun) error {
+	query := fmt.Sprintf(
+		`INSERT INTO %s (job_id, job_name, attempt, worker_id, started_at, finished_at, outcome, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		db.runsTable,
+	)
+
+	_, err := db.exec(ctx, query, []any{
+		r.JobID, r.JobName, r.Attempt, r.WorkerID, r.StartedAt, r.FinishedAt, r.Outcome.String(), r.Error,
+	})
+	return err
+}
+
+func (db *postgresJobDB) ListRuns(ctx context.Context, f RunFinder) (rs []*JobRun, err error) {
+	q := &pgQuery{}
+	query := fmt.Sprintf(
+		`SELECT id, job_id, job_name, attempt, worker_id, started_at, finished_at, outcome, error
+		FROM %s WHERE %s ORDER BY started_at DESC, id DESC`,
+		db.runsTable, parsePostgresRunFilter(q, f),
+	)
+	if f.Limit > 0 {
+		query += " LIMIT " + q.arg(f.Limit)
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	rs = []*JobRun{}
+	for rows.Next() {
+		var (
+			r       JobRun
+			id      int64
+			outcome string
+		)
+		err = rows.Scan(&id, &r.JobID, &r.JobName, &r.Attempt, &r.WorkerID, &r.StartedAt, &r.FinishedAt, &outcome, &r.Error)
+		if err != nil {
+			return nil, err
+		}
+
+		r.ID = strconv.FormatInt(id, 10)
+		r.Outcome = RunOutcome(outcome)
+		rs = append(rs, &r)
+	}
+
+	return rs, rows.Err()
+}
+
+func (db *postgresJobDB) DeleteRunsBefore(ctx context.Context, t time.Time) (int, error) {
+	return db.exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE started_at < $1`, db.runsTable), []any{t})
+}
+
+// exec executes the query, and returns how many rows were affected
+func (db *postgresJobDB) exec(ctx context.Context, query string, args []any) (int, error) {
+	res, err := db.conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := res.RowsAffected()
+	return int(affected), err
+}
+
+// queryID executes the query, and returns the ID it returned.
+//
+// Returns sql.ErrNoRows if the query returned no rows.
+func (db *postgresJobDB) queryID(ctx context.Context, query string, args []any) (string, error) {
+	var id int64
+	err := db.conn.QueryRowContext(ctx, query, args...).Scan(&id)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.FormatInt(id, 10), nil
+}
+
+// queryJobs executes the query, and returns the jobs it selected
+func (db *postgresJobDB) queryJobs(ctx context.Context, query string, args []any) (js []*Job, err error) {
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	js = []*Job{}
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		js = append(js, j)
+	}
+
+	return js, rows.Err()
+}
+
+// pgQuery holds the arguments of a query while it is built
+type pgQuery struct {
+	args []any
+}
+
+// arg adds the value as an argument of the query, and returns its placeholder
+func (q *pgQuery) arg(v any) string {
+	q.args = append(q.args, v)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+// jobColumns are the job table columns, other than the ID, in the order their values are given by jobValues
+var jobColumns = []string{
+	"name",
+	"data",
+	"schedule_type",
+	"status",
+	"owner",
+	"lease_expires_at",
+	"orphan_count",
+	"next_run_at",
+	"last_run_at",
+	"schedule_string",
+	"schedule_limit_date",
+	"location",
+	"retry_policy",
+	"timeout",
+	"attempts",
+	"last_error",
+	"failed_at",
+	"failure_count",
+	"unique_key",
+	"schedule_anchor",
+}
+
+// jobSelectColumns are the job table columns selected by the queries, in the order they are scanned by scanJob
+var jobSelectColumns = "id, " + strings.Join(jobColumns, ", ")
+
+// jobValues adds the job values as arguments of the query, and returns their placeholders in the order of the job columns
+func jobValues(q *pgQuery, j Job) ([]string, error) {
+	var data, retryPolicy any
+	if j.Data != nil {
+		b, err := json.Marshal(j.Data)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to encode the job data, %v", err)
+		}
+		data = string(b)
+	}
+
+	if j.RetryPolicy != nil {
+		b, err := json.Marshal(j.RetryPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to encode the job retry policy, %v", err)
+		}
+		retryPolicy = string(b)
+	}
+
+	var uniqueKey any
+	if j.UniqueKey != "" {
+		uniqueKey = j.UniqueKey
+	}
+
+	return []string{
+		q.arg(j.Name),
+		q.arg(data) + "::jsonb",
+		q.arg(j.ScheduleType.String()),
+		q.arg(j.Status.String()),
+		q.arg(j.Owner),
+		q.arg(j.LeaseExpiresAt),
+		q.arg(j.OrphanCount),
+		q.arg(j.NextRunAt),
+		q.arg(j.LastRunAt),
+		q.arg(j.ScheduleString),
+		q.arg(j.ScheduleLimitDate),
+		q.arg(j.Location),
+		q.arg(retryPolicy) + "::jsonb",
+		q.arg(int64(j.Timeout)),
+		q.arg(j.Attempts),
+		q.arg(j.LastError),
+		q.arg(j.FailedAt),
+		q.arg(j.FailureCount),
+		q.arg(uniqueKey),
+		q.arg(j.ScheduleAnchor),
+	}, nil
+}
+
+// jobAssignments adds the job values as arguments of the query, and returns the assignments of every job column
+func jobAssignments(q *pgQuery, j Job) (string, error) {
+	values, err := jobValues(q, j)
+	if err != nil {
+		return "", err
+	}
+
+	set := make([]string, len(jobColumns))
+	for i, column := range jobColumns {
+		set[i] = column + " = " + values[i]
+	}
+
+	return strings.Join(set, ", "), nil
+}
+
+// excludedAssignments returns the assignments of every job column to the values of the job that conflicted on an insert
+func excludedAssignments() string {
+	set := make([]string, len(jobColumns))
+	for i, column := range jobColumns {
+		set[i] = column + " = EXCLUDED." + column
+	}
+
+	return strings.Join(set, ", ")
+}
+
+// rowScanner represents a query result that can be scanned, either a single row or many rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanJob scans the job, selected with the job select columns
+func scanJob(row rowScanner) (*Job, error) {
+	var (
+		j                                                      Job
+		id, timeout                                            int64
+		data, retryPolicy                                      []byte
+		scheduleType, status                                   string
+		leaseExpiresAt, lastRunAt, limitDate, failedAt, anchor sql.NullTime
+		uniqueKey                                              sql.NullString
+	)
+
+	err := row.Scan(
+		&id,
+		&j.Name,
+		&data,
+		&scheduleType,
+		&status,
+		&j.Owner,
+		&leaseExpiresAt,
+		&j.OrphanCount,
+		&j.NextRunAt,
+		&lastRunAt,
+		&j.ScheduleString,
+		&limitDate,
+		&j.Location,
+		&retryPolicy,
+		&timeout,
+		&j.Attempts,
+		&j.LastError,
+		&failedAt,
+		&j.FailureCount,
+		&uniqueKey,
+		&anchor,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if data != nil {
+		err = json.Unmarshal(data, &j.Data)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode the data of job %d, %v", id, err)
+		}
+	}
+
+	if retryPolicy != nil {
+		j.RetryPolicy = &RetryPolicy{}
+		err = json.Unmarshal(retryPolicy, j.RetryPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode the retry policy of job %d, %v", id, err)
+		}
+	}
+
+	j.ID = strconv.FormatInt(id, 10)
+	j.ScheduleType = ScheduleType(scheduleType)
+	j.Status = ScheduleStatus(status)
+	j.LeaseExpiresAt = nullTime(leaseExpiresAt)
+	j.LastRunAt = nullTime(lastRunAt)
+	j.ScheduleLimitDate = nullTime(limitDate)
+	j.ScheduleAnchor = nullTime(anchor)
+	j.FailedAt = nullTime(failedAt)
+	j.Timeout = time.Duration(timeout)
+	j.UniqueKey = uniqueKey.String
+
+	return &j, nil
+}
+
+// nullTime returns a pointer to the time, or nil if the time is null
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
+
+// parsePostgresID parses the job ID into the job table ID
+func parsePostgresID(id string) (int64, error) {
+	return strconv.ParseInt(id, 10, 64)
+}
+
+// parsePostgresFilter parses the finder into the conditions of a WHERE clause,
+// adding the finder values as arguments of the query
+func parsePostgresFilter(q *pgQuery, f Finder) (string, error) {
+	conds := []string{}
+
+	if f.Name != "" {
+		conds = append(conds, "name = "+q.arg(f.Name))
+	}
+
+	if statuses := f.statuses(); len(statuses) > 0 {
+		ss := make([]string, len(statuses))
+		for i, status := range statuses {
+			ss[i] = q.arg(status.String())
+		}
+		conds = append(conds, fmt.Sprintf("status IN (%s)", strings.Join(ss, ", ")))
+	}
+
+	if f.ScheduleType != "" {
+		conds = append(conds, "schedule_type = "+q.arg(f.ScheduleType.String()))
+	}
+
+	if len(f.Data) > 0 {
+		// the data fields can be paths to nested fields (Ex.: "address.city"),
+		// so the data is matched by containing the nested object that has the values on those paths
+		contained := map[string]any{}
+		for field, value := range f.Data {
+			m := contained
+			keys := strings.Split(field, ".")
+			for _, key := range keys[:len(keys)-1] {
+				nested, ok := m[key].(map[string]any)
+				if !ok {
+					nested = map[string]any{}
+					m[key] = nested
+				}
+				m = nested
+			}
+			m[keys[len(keys)-1]] = value
+		}
+
+		b, err := json.Marshal(contained)
+		if err != nil {
+			return "", fmt.Errorf("Failed to encode the finder data, %v", err)
+		}
+		conds = append(conds, "data @> "+q.arg(string(b))+"::jsonb")
+	}
+
+	conds = append(conds, parsePostgresTimeRange(q, "next_run_at", f.NextRunAfter, f.NextRunBefore)...)
+	conds = append(conds, parsePostgresTimeRange(q, "last_run_at", f.LastRunAfter, f.LastRunBefore)...)
+
+	if len(conds) == 0 {
+		return "TRUE", nil
+	}
+
+	return strings.Join(conds, " AND "), nil
+}
+
+// parsePostgresTimeRange parses the time range of the column into the conditions that match the times after or equal to after, and before before.
+//
+// A zero time leaves its side of the range open.
+func parsePostgresTimeRange(q *pgQuery, column string, after, before time.Time) (conds []string) {
+	if !after.IsZero() {
+		conds = append(conds, column+" >= "+q.arg(after))
+	}
+	if !before.IsZero() {
+		conds = append(conds, column+" < "+q.arg(before))
+	}
+
+	return
+}
+
+// parsePostgresOrder parses the finder sorting and pagination into the ORDER BY, LIMIT and OFFSET clauses.
+//
+// The jobs are always sorted by their ID last, so that the pages are stable,
+// and the null values are sorted as lesser than any other value, the same way as the other databases.
+func parsePostgresOrder(q *pgQuery, f Finder) string {
+	order := "ASC NULLS FIRST"
+	if f.SortDescending {
+		order = "DESC NULLS LAST"
+	}
+
+	columns := []string{}
+	if column, ok := sortFields[f.SortBy]; ok {
+		columns = append(columns, column+" "+order)
+	}
+	columns = append(columns, "id "+order)
+
+	clauses := "ORDER BY " + strings.Join(columns, ", ")
+	if f.Limit > 0 {
+		clauses += " LIMIT " + q.arg(f.Limit)
+	}
+	if f.Offset > 0 {
+		clauses += " OFFSET " + q.arg(f.Offset)
+	}
+
+	return clauses
+}
+
+// parsePostgresRunFilter parses the run finder into the conditions of a WHERE clause,
+// adding the run finder values as arguments of the query
+func parsePostgresRunFilter(q *pgQuery, f RunFinder) string {
+	conds := []string{}
+
+	if f.JobID != "" {
+		conds = append(conds, "job_id = "+q.arg(f.JobID))
+	}
+
+	if f.JobName != "" {
+		conds = append(conds, "job_name = "+q.arg(f.JobName))
+	}
+
+	if f.Outcome != "" {
+		conds = append(conds, "outcome = "+q.arg(f.Outcome.String()))
+	}
+
+	conds = append(conds, parsePostgresTimeRange(q, "started_at", f.StartedAfter, f.StartedBefore)...)
+
+	if len(conds) == 0 {
+		return "TRUE"
+	}
+
+	return strings.Join(conds, " AND ")
+}
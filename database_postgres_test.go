@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPostgres(t *testing.T) {
+	t.Run("Should quote the table names", func(t *testing.T) {
+		db, err := newPostgres(nil, "my_schema.scheduler_jobs", "scheduler_runs")
+		assert.NoError(t, err)
+
+		pdb := db.(*postgresJobDB)
+		assert.Equal(t, `"my_schema"."scheduler_jobs"`, pdb.table)
+		assert.Equal(t, `"scheduler_runs"`, pdb.runsTable)
+		assert.Equal(t, `"my_schema"."scheduler_jobs_migrations"`, pdb.migrationsTable)
+		assert.Equal(t, "scheduler_jobs", pdb.indexPrefix)
+	})
+	t.Run("Should fail if a table name is invalid", func(t *testing.T) {
+		_, err := newPostgres(nil, "scheduler_jobs", `runs"; DROP TABLE users; --`)
+		assert.EqualError(t, err, `Invalid postgres table name 'runs"; DROP TABLE users; --'`)
+	})
+}
+
+func TestParsePostgresFilter(t *testing.T) {
+	t.Run("Should match every job if the finder is empty", func(t *testing.T) {
+		q := &pgQuery{}
+		where, err := parsePostgresFilter(q, Finder{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "TRUE", where)
+		assert.Empty(t, q.args)
+	})
+	t.Run("Should filter by every value of the finder", func(t *testing.T) {
+		now := time.Now()
+		q := &pgQuery{}
+		where, err := parsePostgresFilter(q, Finder{
+			Name:          "MYMOCKJOB!",
+			Status:        "CANCELED",
+			Statuses:      []ScheduleStatus{FAILED},
+			ScheduleType:  RECURRENT,
+			Data:          map[string]any{"tenant.id": 42},
+			NextRunAfter:  now,
+			LastRunBefore: now,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "name = $1 AND status IN ($2, $3) AND schedule_type = $4 AND data @> $5::jsonb AND next_run_at >= $6 AND last_run_at < $7", where)
+		assert.Equal(t, []any{"MYMOCKJOB!", "FAILED", "CANCELED", "RECURRENT", `{"tenant":{"id":42}}`, now, now}, q.args)
+	})
+}
+
+func TestParsePostgresOrder(t *testing.T) {
+	t.Run("Should sort the jobs by their ID if no sort field is set", func(t *testing.T) {
+		q := &pgQuery{}
+		assert.Equal(t, "ORDER BY id ASC NULLS FIRST", parsePostgresOrder(q, Finder{}))
+	})
+	t.Run("Should sort and paginate the jobs given the finder", func(t *testing.T) {
+		q := &pgQuery{args: []any{"a previous argument"}}
+		order := parsePostgresOrder(q, Finder{SortBy: SORT_BY_LAST_RUN_AT, SortDescending: true, Limit: 10, Offset: 20})
+
+		assert.Equal(t, "ORDER BY last_run_at DESC NULLS LAST, id DESC NULLS LAST LIMIT $2 OFFSET $3", order)
+		assert.Equal(t, []any{"a previous argument", 10, 20}, q.args)
+	})
+}
+
+// rowMock represents a single selected row, that is scanned by copying its values
+type rowMock []any
+
+func (r rowMock) Scan(dest ...any) error {
+	for i, d := range dest {
+		switch d := d.(type) {
+		case *int64:
+			*d = r[i].(int64)
+		case *int:
+			*d = r[i].(int)
+		case *string:
+			*d = r[i].(string)
+		case *[]byte:
+			if r[i] != nil {
+				*d = []byte(r[i].(string))
+			}
+		case *time.Time:
+			*d = r[i].(time.Time)
+		default:
+			// the nullable values are given as they were passed to the query (Ex.: *time.Time)
+			assignNullable(d, r[i])
+		}
+	}
+
+	return nil
+}
+
+// assignNullable assigns the argument of a nullable column to its scan destination
+func assignNullable(dest any, arg any) {
+	switch d := dest.(type) {
+	case *sql.NullTime:
+		if t, ok := arg.(*time.Time); ok && t != nil {
+			*d = sql.NullTime{Time: *t, Valid: true}
+		}
+	case *sql.NullString:
+		if s, ok := arg.(string); ok {
+			*d = sql.NullString{String: s, Valid: true}
+		}
+	}
+}
+
+func TestScanJob(t *testing.T) {
+	t.Run("Should scan the job saved with the job values", func(t *testing.T) {
+		now := time.Now().UTC()
+		j := Job{
+			Name:           "MYMOCKJOB!",
+			Data:           map[string]any{"key": "value"},
+			ScheduleType:   RECURRENT,
+			Status:         PENDING,
+			NextRunAt:      now,
+			LastRunAt:      &now,
+			ScheduleString: "@daily",
+			Location:       "America/Sao_Paulo",
+			RetryPolicy:    &RetryPolicy{MaxAttempts: 3, Backoff: EXPONENTIAL, Delay: time.Minute},
+			Timeout:        time.Hour,
+			LastError:      "MOCK ERROR!",
+			FailureCount:   2,
+			UniqueKey:      "MYKEY",
+		}
+
+		q := &pgQuery{}
+		_, err := jobValues(q, j)
+		assert.NoError(t, err)
+
+		row := rowMock(append([]any{int64(42)}, q.args...))
+		scanned, err := scanJob(row)
+		assert.NoError(t, err)
+
+		j.ID = "42"
+		assert.Equal(t, j, *scanned)
+	})
+}
@@ -0,0 +1,961 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// RedisClient represents a connection to a Redis server, that can run any redis command.
+//
+// Its usually an adapter over the Redis client used by the application. Ex.: with go-redis:
+//
+//	type redisClient struct{ *redis.Client }
+//
+//	func (c redisClient) Do(ctx context.Context, args ...any) (any, error) {
+//		res, err := c.Client.Do(ctx, args...).Result()
+//		if errors.Is(err, redis.Nil) {
+//			return nil, nil
+//		}
+//		return res, err
+//	}
+type RedisClient interface {
+	// Do runs the redis command given by the arguments (Ex.: "HGETALL", "mykey"), and returns its reply.
+	//
+	// Status and bulk string replies should be returned as strings, integer replies as int64,
+	// array replies as []any, map replies as []any or map[any]any, and nil replies as nil with a nil error.
+	Do(ctx context.Context, args ...any) (any, error)
+}
+
+// redisCompareAndSet its the lua script that atomically runs every write of the redis job database.
+//
+// The redis commands given on the arguments are only ran if each one of the hash fields given on the keys
+// still has the expected value, so that the jobs and their indexes are written as a whole,
+// and only if they were not changed since they were read (Ex.: by another scheduler instance claiming the job).
+//
+// Every key touched by the script is declared on KEYS, so that a Redis Cluster can check that they are all on the same slot.
+//
+// KEYS: the hashes checked by the conditions, followed by the key of each command.
+// ARGV: the number of conditions, the field and the expected value of each condition (a missing field is expected as an empty string),
+// followed by each command as its number of arguments, its name and its arguments, without its key.
+//
+// Returns 1 if the commands were ran, or 0 if a condition did not hold.
+const redisCompareAndSet = `
+local conds = tonumber(ARGV[1])
+for i = 1, conds do
+	local value = redis.call('HGET', KEYS[i], ARGV[2*i])
+	if value == false then value = '' end
+	if value ~= ARGV[2*i+1] then return 0 end
+end
+local a, k = 2*conds + 2, conds + 1
+while a <= #ARGV do
+	local n = tonumber(ARGV[a])
+	redis.call(ARGV[a+1], KEYS[k], unpack(ARGV, a+2, a+n))
+	a, k = a + n + 1, k + 1
+end
+return 1
+`
+
+// redisMaxRetries defines how many times a write is retried when the job was changed after it was read
+const redisMaxRetries = 10
+
+type redisJobDB struct {
+	client RedisClient
+	prefix string
+}
+
+func newRedis(client RedisClient, prefix string) JobDatabase {
+	return &redisJobDB{
+		client,
+		prefix,
+	}
+}
+
+// the keys of the redis job database:
+//
+//   - <prefix>:seq      its the counter that assigns the job and job run IDs
+//   - <prefix>:job:<id> its the hash that holds the job fields
+//   - <prefix>:data:<id> its the hash that holds the job extra data, each value encoded as JSON
+//   - <prefix>:jobs     its the sorted set of every job ID, scored by its creation order
+//   - <prefix>:pending  its the sorted set of the PENDING job IDs, scored by their NextRunAt
+//   - <prefix>:leases   its the sorted set of the RUNNING job IDs, scored by their LeaseExpiresAt
+//   - <prefix>:name:<name> and <prefix>:status:<status> are the sets of the job IDs with each name and status
+//   - <prefix>:unique   its the hash of the job unique keys to the IDs of the jobs that hold them
+//   - <prefix>:run:<id> its the job run, encoded as JSON
+//   - <prefix>:runs     its the sorted set of every job run ID, scored by their StartedAt
+func (db *redisJobDB) key(parts ...string) string {
+	k := db.prefix
+	for _, p := range parts {
+		k += ":" + p
+	}
+
+	return k
+}
+
+func (db *redisJobDB) InitJobDB(ctx context.Context) error {
+	_, err := db.client.Do(ctx, "PING")
+	return err
+}
+
+func (db *redisJobDB) ListExpiredSchedules(ctx context.Context) ([]*Job, error) {
+	return db.jobsByScore(ctx, db.key("pending"), NowFromContext(ctx))
+}
+
+func (db *redisJobDB) ClaimJob(ctx context.Context, j Job) (bool, error) {
+	return db.change(ctx, j.ID, func(stored *Job) bool {
+		// the times are stored with millisecond precision
+		if stored.Status != PENDING || stored.NextRunAt.UnixMilli() != j.NextRunAt.UnixMilli() {
+			return false
+		}
+
+		stored.Status = RUNNING
+		stored.Owner = j.Owner
+		stored.LeaseExpiresAt = j.LeaseExpiresAt
+		return true
+	})
+}
+
+func (db *redisJobDB) RenewLease(ctx context.Context, j Job) (bool, error) {
+	return db.change(ctx, j.ID, func(stored *Job) bool {
+		if stored.Status != RUNNING || stored.Owner != j.Owner {
+			return false
+		}
+
+		stored.LeaseExpiresAt = j.LeaseExpiresAt
+		return true
+	})
+}
+
+func (db *redisJobDB) ListExpiredLeases(ctx context.Context) ([]*Job, error) {
+	return db.jobsByScore(ctx, db.key("leases"), NowFromContext(ctx))
+}
+
+func (db *redisJobDB) RecoverJob(ctx context.Context, j Job) (bool, error) {
+	now := NowFromContext(ctx)
+
+	return db.change(ctx, j.ID, func(stored *Job) bool {
+		if stored.Status != RUNNING || stored.LeaseExpiresAt == nil || stored.LeaseExpiresAt.After(now) {
+			return false
+		}
+
+		*stored = j
+		return true
+	})
+}
+
+func (db *redisJobDB) ReleaseJob(ctx context.Context, j Job) (bool, error) {
+	return db.change(ctx, j.ID, func(stored *Job) bool {
+		if stored.Status != RUNNING || stored.Owner != j.Owner {
+			return false
+		}
+
+		stored.Status = PENDING
+		stored.release()
+		return true
+	})
+}
+
+func (db *redisJobDB) FinishJob(ctx context.Context, j Job, owner string) (bool, error) {
+	return db.change(ctx, j.ID, func(stored *Job) bool {
+		if stored.Status != RUNNING || stored.Owner != owner {
+			return false
+		}
+
+		*stored = j
+		return true
+	})
+}
+
+func (db *redisJobDB) List(ctx context.Context, f Finder) ([]*Job, error) {
+	js, err := db.find(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.sortAndPaginate(js), nil
+}
+
+func (db *redisJobDB) Count(ctx context.Context, f Finder) (int, error) {
+	js, err := db.find(ctx, f)
+	return len(js), err
+}
+
+func (db *redisJobDB) GetJob(ctx context.Context, id string) (*Job, error) {
+	rj, err := db.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rj == nil {
+		return nil, ErrJobNotFound
+	}
+
+	return &rj.job, nil
+}
+
+func (db *redisJobDB) UpdateJob(ctx context.Context, j Job, old Job) (bool, error) {
+	return db.change(ctx, j.ID, func(stored *Job) bool {
+		// the times are stored with millisecond precision
+		if stored.Status == RUNNING || stored.Status != old.Status || stored.NextRunAt.UnixMilli() != old.NextRunAt.UnixMilli() {
+			return false
+		}
+
+		*stored = j
+		return true
+	})
+}
+
+func (db *redisJobDB) SaveJob(ctx context.Context, j Job) (string, error) {
+	if j.ID == "" {
+		// no ID, then its a new job
+		id, err := db.nextID(ctx)
+		if err != nil {
+			return "", err
+		}
+		j.ID = id
+	}
+
+	for i := 0; i < redisMaxRetries; i++ {
+		old, err := db.get(ctx, j.ID)
+		if err != nil {
+			return "", err
+		}
+
+		saved, err := db.write(ctx, old, &j)
+		if err != nil || saved {
+			return j.ID, err
+		}
+	}
+
+	return "", fmt.Errorf("Failed to save job %s, it was changed concurrently too many times", j.ID)
+}
+
+func (db *redisJobDB) SaveUniqueJob(ctx context.Context, j Job, replace bool) (id string, saved bool, err error) {
+	for i := 0; i < redisMaxRetries; i++ {
+		id, err = db.stringReply(db.client.Do(ctx, "HGET", db.key("unique"), j.UniqueKey))
+		if err != nil {
+			return "", false, err
+		}
+
+		var old *redisJob
+		if id != "" {
+			old, err = db.get(ctx, id)
+			if err != nil {
+				return "", false, err
+			}
+		}
+
+		switch {
+		case old == nil:
+			// no job holds the unique key, so the new job is inserted
+			j.ID, err = db.nextID(ctx)
+		case !replace:
+			return id, false, nil
+		case old.job.Status == RUNNING:
+			return "", false, ErrJobRunning
+		default:
+			j.ID = id
+		}
+		if err != nil {
+			return "", false, err
+		}
+
+		saved, err = db.write(ctx, old, &j, redisCondition{db.key("unique"), j.UniqueKey, id})
+		if err != nil || saved {
+			return j.ID, saved, err
+		}
+	}
+
+	return "", false, fmt.Errorf("Failed to save unique job %s, it was changed concurrently too many times", j.UniqueKey)
+}
+
+func (db *redisJobDB) DeleteJob(ctx context.Context, j Job) error {
+	_, err := db.remove(ctx, j.ID, func(stored *Job) bool { return true })
+	return err
+}
+
+func (db *redisJobDB) CancelMany(ctx context.Context, f Finder) (int, error) {
+	return db.changeMany(ctx, f, func(id string) (bool, error) {
+		return db.change(ctx, id, func(stored *Job) bool {
+			if stored.Status == RUNNING || stored.Status == CANCELED || !f.matches(*stored) {
+				return false
+			}
+
+			stored.Status = CANCELED
+			return true
+		})
+	})
+}
+
+func (db *redisJobDB) DeleteMany(ctx context.Context, f Finder) (int, error) {
+	return db.changeMany(ctx, f, func(id string) (bool, error) {
+		return db.remove(ctx, id, func(stored *Job) bool {
+			return stored.Status != RUNNING && f.matches(*stored)
+		})
+	})
+}
+
+func (db *redisJobDB) RescheduleMany(ctx context.Context, f Finder, delta time.Duration) (int, error) {
+	if delta == 0 {
+		return 0, nil
+	}
+
+	return db.changeMany(ctx, f, func(id string) (bool, error) {
+		return db.change(ctx, id, func(stored *Job) bool {
+			if stored.Status == RUNNING || !f.matches(*stored) {
+				return false
+			}
+
+			stored.NextRunAt = stored.NextRunAt.Add(delta)
+			return true
+		})
+	})
+}
+
+func (db *redisJobDB) SaveRun(ctx context.Context, r JobRun) error {
+	id, err := db.nextID(ctx)
+	if err != nil {
+		return err
+	}
+	r.ID = id
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("Failed to encode the job run, %v", err)
+	}
+
+	_, err = db.compareAndSet(ctx, nil, [][]any{
+		{"SET", db.key("run", id), string(b)},
+		{"ZADD", db.key("runs"), r.StartedAt.UnixMilli(), id},
+	})
+	return err
+}
+
+func (db *redisJobDB) ListRuns(ctx context.Context, f RunFinder) ([]*JobRun, error) {
+	max, min := "+inf", "-inf"
+	if !f.StartedBefore.IsZero() {
+		max = "(" + strconv.FormatInt(f.StartedBefore.UnixMilli(), 10)
+	}
+	if !f.StartedAfter.IsZero() {
+		min = strconv.FormatInt(f.StartedAfter.UnixMilli(), 10)
+	}
+
+	ids, err := db.stringsReply(db.client.Do(ctx, "ZREVRANGEBYSCORE", db.key("runs"), max, min))
+	if err != nil {
+		return nil, err
+	}
+
+	rs := []*JobRun{}
+	for _, id := range ids {
+		b, err := db.stringReply(db.client.Do(ctx, "GET", db.key("run", id)))
+		if err != nil {
+			return nil, err
+		}
+		if b == "" {
+			// the run was deleted after it was listed
+			continue
+		}
+
+		var r JobRun
+		err = json.Unmarshal([]byte(b), &r)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode the job run %s, %v", id, err)
+		}
+
+		if matchesRun(f, r) {
+			rs = append(rs, &r)
+		}
+		if f.Limit > 0 && len(rs) == f.Limit {
+			break
+		}
+	}
+
+	return rs, nil
+}
+
+func (db *redisJobDB) DeleteRunsBefore(ctx context.Context, t time.Time) (int, error) {
+	ids, err := db.stringsReply(db.client.Do(ctx, "ZRANGEBYSCORE", db.key("runs"), "-inf", "("+strconv.FormatInt(t.UnixMilli(), 10)))
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	cmds := [][]any{}
+	for _, id := range ids {
+		cmds = append(cmds,
+			[]any{"DEL", db.key("run", id)},
+			[]any{"ZREM", db.key("runs"), id},
+		)
+	}
+
+	_, err = db.compareAndSet(ctx, nil, cmds)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(ids), nil
+}
+
+// redisJob represents a job read from the redis job database, along with the values needed to write it back
+type redisJob struct {
+	job Job
+
+	// seq its the creation order of the job
+	seq int64
+
+	// version its incremented on every write of the job, so that a write only happens if the job was not changed since it was read
+	version string
+}
+
+// redisCondition represents a hash field that should have the expected value for a write to happen
+type redisCondition struct {
+	key      string
+	field    string
+	expected string
+}
+
+// nextID returns a new job or job run ID
+func (db *redisJobDB) nextID(ctx context.Context) (string, error) {
+	seq, err := db.intReply(db.client.Do(ctx, "INCR", db.key("seq")))
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.FormatInt(seq, 10), nil
+}
+
+// get reads the job with the given ID, or returns nil if the job does not exist
+func (db *redisJobDB) get(ctx context.Context, id string) (*redisJob, error) {
+	fields, err := db.hashReply(db.client.Do(ctx, "HGETALL", db.key("job", id)))
+	if err != nil || len(fields) == 0 {
+		return nil, err
+	}
+
+	data, err := db.hashReply(db.client.Do(ctx, "HGETALL", db.key("data", id)))
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRedisJob(id, fields, data)
+}
+
+// change changes the job with the given ID with the change function, that should return false if the job should not be changed.
+//
+// If the job was changed concurrently, its read and changed again.
+//
+// Returns true if the job was changed.
+func (db *redisJobDB) change(ctx context.Context, id string, change func(stored *Job) bool) (bool, error) {
+	for i := 0; i < redisMaxRetries; i++ {
+		old, err := db.get(ctx, id)
+		if err != nil || old == nil {
+			return false, err
+		}
+
+		j := old.job
+		if !change(&j) {
+			return false, nil
+		}
+		j.ID = id
+
+		changed, err := db.write(ctx, old, &j)
+		if err != nil || changed {
+			return changed, err
+		}
+	}
+
+	return false, fmt.Errorf("Failed to change job %s, it was changed concurrently too many times", id)
+}
+
+// remove deletes the job with the given ID, if the should function returns true.
+//
+// Returns true if the job was deleted.
+func (db *redisJobDB) remove(ctx context.Context, id string, should func(stored *Job) bool) (bool, error) {
+	for i := 0; i < redisMaxRetries; i++ {
+		old, err := db.get(ctx, id)
+		if err != nil || old == nil {
+			return false, err
+		}
+
+		if !should(&old.job) {
+			return false, nil
+		}
+
+		cmds := db.unindex(old)
+		cmds = append(cmds,
+			[]any{"DEL", db.key("job", id)},
+			[]any{"DEL", db.key("data", id)},
+			[]any{"ZREM", db.key("jobs"), id},
+		)
+
+		removed, err := db.compareAndSet(ctx, []redisCondition{{db.key("job", id), "version", old.version}}, cmds)
+		if err != nil || removed {
+			return removed, err
+		}
+	}
+
+	return false, fmt.Errorf("Failed to delete job %s, it was changed concurrently too many times", id)
+}
+
+// changeMany runs the change function for every job found given the finder, that is checked again by the change itself
+//
+// Returns how many jobs were changed.
+func (db *redisJobDB) changeMany(ctx context.Context, f Finder, change func(id string) (bool, error)) (int, error) {
+	js, err := db.find(ctx, f)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, j := range js {
+		ok, err := change(j.ID)
+		if err != nil {
+			return changed, err
+		}
+		if ok {
+			changed++
+		}
+	}
+
+	return changed, nil
+}
+
+// write writes the job and its indexes, replacing the old job, if the old job was not changed since it was read
+// and the extra conditions hold.
+//
+// Returns false if the job was not written.
+func (db *redisJobDB) write(ctx context.Context, old *redisJob, j *Job, conds ...redisCondition) (bool, error) {
+	id := j.ID
+	version, seq := "", int64(0)
+	if old != nil {
+		version, seq = old.version, old.seq
+	} else {
+		var err error
+		seq, err = strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			// a job saved with an ID that was not assigned by the database is listed after the existing jobs
+			seq = time.Now().UnixNano()
+		}
+	}
+
+	newVersion := 1
+	if version != "" {
+		newVersion, _ = strconv.Atoi(version)
+		newVersion++
+	}
+
+	fields, data, err := encodeRedisJob(*j)
+	if err != nil {
+		return false, err
+	}
+
+	cmds := [][]any{}
+	if old != nil {
+		cmds = db.unindex(old)
+	}
+
+	hset := []any{"HSET", db.key("job", id), "version", newVersion, "seq", seq}
+	hset = append(hset, fields...)
+	cmds = append(cmds,
+		[]any{"DEL", db.key("job", id)},
+		hset,
+		[]any{"DEL", db.key("data", id)},
+	)
+	if len(data) > 0 {
+		cmds = append(cmds, append([]any{"HSET", db.key("data", id)}, data...))
+	}
+	cmds = append(cmds, db.index(seq, *j)...)
+
+	conds = append([]redisCondition{{db.key("job", id), "version", version}}, conds...)
+	return db.compareAndSet(ctx, conds, cmds)
+}
+
+// index returns the commands that add the job to the indexes
+func (db *redisJobDB) index(seq int64, j Job) [][]any {
+	cmds := [][]any{
+		{"ZADD", db.key("jobs"), seq, j.ID},
+		{"SADD", db.key("name", j.Name), j.ID},
+		{"SADD", db.key("status", j.Status.String()), j.ID},
+	}
+
+	if j.Status == PENDING {
+		cmds = append(cmds, []any{"ZADD", db.key("pending"), j.NextRunAt.UnixMilli(), j.ID})
+	}
+
+	if j.Status == RUNNING && j.LeaseExpiresAt != nil {
+		cmds = append(cmds, []any{"ZADD", db.key("leases"), j.LeaseExpiresAt.UnixMilli(), j.ID})
+	}
+
+	if j.UniqueKey != "" {
+		cmds = append(cmds, []any{"HSET", db.key("unique"), j.UniqueKey, j.ID})
+	}
+
+	return cmds
+}
+
+// unindex returns the commands that remove the job from the indexes
+func (db *redisJobDB) unindex(old *redisJob) [][]any {
+	id := old.job.ID
+	cmds := [][]any{
+		{"SREM", db.key("name", old.job.Name), id},
+		{"SREM", db.key("status", old.job.Status.String()), id},
+		{"ZREM", db.key("pending"), id},
+		{"ZREM", db.key("leases"), id},
+	}
+
+	if old.job.UniqueKey != "" {
+		cmds = append(cmds, []any{"HDEL", db.key("unique"), old.job.UniqueKey})
+	}
+
+	return cmds
+}
+
+// compareAndSet runs the commands atomically, if every condition holds.
+//
+// Returns false if a condition did not hold.
+func (db *redisJobDB) compareAndSet(ctx context.Context, conds []redisCondition, cmds [][]any) (bool, error) {
+	// every command has a single key, right after its name
+	args := []any{"EVAL", redisCompareAndSet, len(conds) + len(cmds)}
+	for _, c := range conds {
+		args = append(args, c.key)
+	}
+	for _, cmd := range cmds {
+		args = append(args, cmd[1])
+	}
+
+	args = append(args, len(conds))
+	for _, c := range conds {
+		args = append(args, c.field, c.expected)
+	}
+	for _, cmd := range cmds {
+		args = append(args, len(cmd)-1, cmd[0])
+		args = append(args, cmd[2:]...)
+	}
+
+	ok, err := db.intReply(db.client.Do(ctx, args...))
+	return ok == 1, err
+}
+
+// find returns the jobs found given the finder, in their creation order.
+//
+// The jobs are looked up by the name and status indexes when possible, and then filtered by the whole finder.
+func (db *redisJobDB) find(ctx context.Context, f Finder) ([]*Job, error) {
+	var ids []string
+
+	if f.Name != "" {
+		nameIDs, err := db.stringsReply(db.client.Do(ctx, "SMEMBERS", db.key("name", f.Name)))
+		if err != nil {
+			return nil, err
+		}
+		ids = nameIDs
+	}
+
+	if statuses := f.statuses(); len(statuses) > 0 {
+		statusIDs := []string{}
+		for _, status := range statuses {
+			sids, err := db.stringsReply(db.client.Do(ctx, "SMEMBERS", db.key("status", status.String())))
+			if err != nil {
+				return nil, err
+			}
+			statusIDs = append(statusIDs, sids...)
+		}
+
+		if ids == nil {
+			ids = statusIDs
+		} else {
+			ids = intersect(ids, statusIDs)
+		}
+	}
+
+	if ids == nil {
+		var err error
+		ids, err = db.stringsReply(db.client.Do(ctx, "ZRANGE", db.key("jobs"), 0, -1))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return db.getMany(ctx, ids, f.matches)
+}
+
+// jobsByScore returns the jobs of the sorted set which score is lesser than or equal to the given time
+func (db *redisJobDB) jobsByScore(ctx context.Context, key string, t time.Time) ([]*Job, error) {
+	ids, err := db.stringsReply(db.client.Do(ctx, "ZRANGEBYSCORE", key, "-inf", t.UnixMilli()))
+	if err != nil {
+		return nil, err
+	}
+
+	return db.getMany(ctx, ids, func(j Job) bool { return true })
+}
+
+// getMany reads the jobs with the given IDs that match the given function, in their creation order
+func (db *redisJobDB) getMany(ctx context.Context, ids []string, match func(j Job) bool) ([]*Job, error) {
+	rjs := []*redisJob{}
+	for _, id := range ids {
+		rj, err := db.get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		// the job may have been deleted after it was looked up
+		if rj != nil && match(rj.job) {
+			rjs = append(rjs, rj)
+		}
+	}
+
+	sort.Slice(rjs, func(a, b int) bool {
+		return rjs[a].seq < rjs[b].seq
+	})
+
+	js := make([]*Job, len(rjs))
+	for i, rj := range rjs {
+		js[i] = &rj.job
+	}
+
+	return js, nil
+}
+
+// intersect returns the values of a that are also in b
+func intersect(a, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, v := range b {
+		inB[v] = true
+	}
+
+	values := []string{}
+	for _, v := range a {
+		if inB[v] {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// encodeRedisJob encodes the job into the fields and values of the job hash, and of the job data hash
+func encodeRedisJob(j Job) (fields []any, data []any, err error) {
+	set := func(field string, value any) {
+		fields = append(fields, field, value)
+	}
+	setTime := func(field string, t *time.Time) {
+		if t != nil {
+			set(field, t.UnixMilli())
+		}
+	}
+
+	set("name", j.Name)
+	set("schedule_type", j.ScheduleType.String())
+	set("status", j.Status.String())
+	set("owner", j.Owner)
+	setTime("lease_expires_at", j.LeaseExpiresAt)
+	set("orphan_count", j.OrphanCount)
+	setTime("next_run_at", &j.NextRunAt)
+	setTime("last_run_at", j.LastRunAt)
+	set("schedule_string", j.ScheduleString)
+	setTime("schedule_limit_date", j.ScheduleLimitDate)
+	setTime("schedule_anchor", j.ScheduleAnchor)
+	set("location", j.Location)
+	set("timeout", int64(j.Timeout))
+	set("attempts", j.Attempts)
+	set("last_error", j.LastError)
+	setTime("failed_at", j.FailedAt)
+	set("failure_count", j.FailureCount)
+	set("unique_key", j.UniqueKey)
+
+	if j.RetryPolicy != nil {
+		b, err := json.Marshal(j.RetryPolicy)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to encode the job retry policy, %v", err)
+		}
+		set("retry_policy", string(b))
+	}
+
+	if j.Data != nil {
+		// the has_data field tells an empty data apart from no data, since an empty hash does not exist
+		set("has_data", 1)
+	}
+
+	for k, v := range j.Data {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to encode the job data, %v", err)
+		}
+		data = append(data, k, string(b))
+	}
+
+	return
+}
+
+// decodeRedisJob decodes the job from the fields of the job hash, and of the job data hash
+func decodeRedisJob(id string, fields, data map[string]string) (*redisJob, error) {
+	var err error
+	toInt := func(field string) int {
+		v, convErr := strconv.Atoi(fields[field])
+		if convErr != nil && fields[field] != "" && err == nil {
+			err = fmt.Errorf("Failed to decode the field %s of job %s, %v", field, id, convErr)
+		}
+		return v
+	}
+	toInt64 := func(field string) int64 {
+		v, convErr := strconv.ParseInt(fields[field], 10, 64)
+		if convErr != nil && fields[field] != "" && err == nil {
+			err = fmt.Errorf("Failed to decode the field %s of job %s, %v", field, id, convErr)
+		}
+		return v
+	}
+	toTime := func(field string) *time.Time {
+		if fields[field] == "" {
+			return nil
+		}
+		t := time.UnixMilli(toInt64(field)).UTC()
+		return &t
+	}
+
+	rj := &redisJob{
+		version: fields["version"],
+		seq:     toInt64("seq"),
+		job: Job{
+			ID:                id,
+			Name:              fields["name"],
+			ScheduleType:      ScheduleType(fields["schedule_type"]),
+			Status:            ScheduleStatus(fields["status"]),
+			Owner:             fields["owner"],
+			LeaseExpiresAt:    toTime("lease_expires_at"),
+			OrphanCount:       toInt("orphan_count"),
+			LastRunAt:         toTime("last_run_at"),
+			ScheduleString:    fields["schedule_string"],
+			ScheduleLimitDate: toTime("schedule_limit_date"),
+			ScheduleAnchor:    toTime("schedule_anchor"),
+			Location:          fields["location"],
+			Timeout:           time.Duration(toInt64("timeout")),
+			Attempts:          toInt("attempts"),
+			LastError:         fields["last_error"],
+			FailedAt:          toTime("failed_at"),
+			FailureCount:      toInt("failure_count"),
+			UniqueKey:         fields["unique_key"],
+		},
+	}
+
+	if nra := toTime("next_run_at"); nra != nil {
+		rj.job.NextRunAt = *nra
+	}
+
+	if rp := fields["retry_policy"]; rp != "" {
+		rj.job.RetryPolicy = &RetryPolicy{}
+		jsonErr := json.Unmarshal([]byte(rp), rj.job.RetryPolicy)
+		if jsonErr != nil && err == nil {
+			err = fmt.Errorf("Failed to decode the retry policy of job %s, %v", id, jsonErr)
+		}
+	}
+
+	if fields["has_data"] != "" {
+		rj.job.Data = make(map[string]any, len(data))
+	}
+	for k, v := range data {
+		var value any
+		jsonErr := json.Unmarshal([]byte(v), &value)
+		if jsonErr != nil && err == nil {
+			err = fmt.Errorf("Failed to decode the data of job %s, %v", id, jsonErr)
+		}
+		rj.job.Data[k] = value
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rj, nil
+}
+
+// stringReply converts the reply into a string, that is empty if the reply is nil
+func (db *redisJobDB) stringReply(reply any, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+
+	switch r := reply.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return r, nil
+	case []byte:
+		return string(r), nil
+	case int64:
+		return strconv.FormatInt(r, 10), nil
+	default:
+		return "", fmt.Errorf("Unexpected redis reply type %T", reply)
+	}
+}
+
+// intReply converts the reply into an integer
+func (db *redisJobDB) intReply(reply any, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+
+	switch r := reply.(type) {
+	case int64:
+		return r, nil
+	case string, []byte:
+		s, _ := db.stringReply(r, nil)
+		return strconv.ParseInt(s, 10, 64)
+	default:
+		return 0, fmt.Errorf("Unexpected redis reply type %T", reply)
+	}
+}
+
+// stringsReply converts the array reply into strings
+func (db *redisJobDB) stringsReply(reply any, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	switch r := reply.(type) {
+	case nil:
+		return []string{}, nil
+	case []any:
+		values := make([]string, len(r))
+		for i, v := range r {
+			values[i], err = db.stringReply(v, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("Unexpected redis reply type %T", reply)
+	}
+}
+
+// hashReply converts the reply of a HGETALL command into the hash fields and values,
+// given either as an array of fields and values, or as a map
+func (db *redisJobDB) hashReply(reply any, err error) (map[string]string, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	hash := map[string]string{}
+	if m, ok := reply.(map[any]any); ok {
+		for k, v := range m {
+			field, err := db.stringReply(k, nil)
+			if err != nil {
+				return nil, err
+			}
+			hash[field], err = db.stringReply(v, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return hash, nil
+	}
+
+	values, err := db.stringsReply(reply, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i+1 < len(values); i += 2 {
+		hash[values[i]] = values[i+1]
+	}
+
+	return hash, nil
+}
@@ -0,0 +1,580 @@
+package scheduler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// redisFake its an in-process Redis server, that runs the subset of commands used by the redis job database.
+//
+// The compare and set script is ran by its Go equivalent, while holding the lock, so its as atomic as on Redis.
+type redisFake struct {
+	mu      sync.Mutex
+	strings map[string]string
+	hashes  map[string]map[string]string
+	sets    map[string]map[string]bool
+	zsets   map[string]map[string]float64
+}
+
+func newRedisFake() *redisFake {
+	return &redisFake{
+		strings: map[string]string{},
+		hashes:  map[string]map[string]string{},
+		sets:    map[string]map[string]bool{},
+		zsets:   map[string]map[string]float64{},
+	}
+}
+
+func (r *redisFake) Do(ctx context.Context, args ...any) (any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	strs := make([]string, len(args))
+	for i, a := range args {
+		strs[i] = fmt.Sprint(a)
+	}
+
+	return r.do(strs)
+}
+
+func (r *redisFake) do(args []string) (any, error) {
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "PONG", nil
+	case "GET":
+		v, ok := r.strings[args[1]]
+		if !ok {
+			return nil, nil
+		}
+		return v, nil
+	case "SET":
+		r.strings[args[1]] = args[2]
+		return "OK", nil
+	case "INCR":
+		v, _ := strconv.ParseInt(r.strings[args[1]], 10, 64)
+		v++
+		r.strings[args[1]] = strconv.FormatInt(v, 10)
+		return v, nil
+	case "DEL":
+		deleted := int64(0)
+		for _, k := range args[1:] {
+			_, s := r.strings[k]
+			_, h := r.hashes[k]
+			_, st := r.sets[k]
+			_, z := r.zsets[k]
+			if s || h || st || z {
+				deleted++
+			}
+			delete(r.strings, k)
+			delete(r.hashes, k)
+			delete(r.sets, k)
+			delete(r.zsets, k)
+		}
+		return deleted, nil
+	case "HGET":
+		v, ok := r.hashes[args[1]][args[2]]
+		if !ok {
+			return nil, nil
+		}
+		return v, nil
+	case "HGETALL":
+		reply := []any{}
+		for f, v := range r.hashes[args[1]] {
+			reply = append(reply, f, v)
+		}
+		return reply, nil
+	case "HSET":
+		if r.hashes[args[1]] == nil {
+			r.hashes[args[1]] = map[string]string{}
+		}
+		for i := 2; i+1 < len(args); i += 2 {
+			r.hashes[args[1]][args[i]] = args[i+1]
+		}
+		return int64(0), nil
+	case "HDEL":
+		for _, f := range args[2:] {
+			delete(r.hashes[args[1]], f)
+		}
+		if len(r.hashes[args[1]]) == 0 {
+			delete(r.hashes, args[1])
+		}
+		return int64(0), nil
+	case "SADD":
+		if r.sets[args[1]] == nil {
+			r.sets[args[1]] = map[string]bool{}
+		}
+		for _, m := range args[2:] {
+			r.sets[args[1]][m] = true
+		}
+		return int64(0), nil
+	case "SREM":
+		for _, m := range args[2:] {
+			delete(r.sets[args[1]], m)
+		}
+		if len(r.sets[args[1]]) == 0 {
+			delete(r.sets, args[1])
+		}
+		return int64(0), nil
+	case "SMEMBERS":
+		reply := []any{}
+		for m := range r.sets[args[1]] {
+			reply = append(reply, m)
+		}
+		return reply, nil
+	case "ZADD":
+		if r.zsets[args[1]] == nil {
+			r.zsets[args[1]] = map[string]float64{}
+		}
+		for i := 2; i+1 < len(args); i += 2 {
+			score, err := strconv.ParseFloat(args[i], 64)
+			if err != nil {
+				return nil, errors.New("ERR value is not a valid float")
+			}
+			r.zsets[args[1]][args[i+1]] = score
+		}
+		return int64(0), nil
+	case "ZREM":
+		for _, m := range args[2:] {
+			delete(r.zsets[args[1]], m)
+		}
+		if len(r.zsets[args[1]]) == 0 {
+			delete(r.zsets, args[1])
+		}
+		return int64(0), nil
+	case "ZRANGE":
+		return r.zrange(args[1], "-inf", "+inf", false), nil
+	case "ZRANGEBYSCORE":
+		return r.zrange(args[1], args[2], args[3], false), nil
+	case "ZREVRANGEBYSCORE":
+		return r.zrange(args[1], args[3], args[2], true), nil
+	case "EVAL":
+		return r.eval(args)
+	default:
+		return nil, fmt.Errorf("ERR unknown command '%s'", args[0])
+	}
+}
+
+// zrange returns the members of the sorted set within the scores, sorted by their score and then by the member
+func (r *redisFake) zrange(key, min, max string, reverse bool) []any {
+	inRange := func(score float64, bound string, isMin bool) bool {
+		exclusive := strings.HasPrefix(bound, "(")
+		bound = strings.TrimPrefix(bound, "(")
+		switch bound {
+		case "-inf":
+			return true
+		case "+inf":
+			return true
+		}
+		b, _ := strconv.ParseFloat(bound, 64)
+		if isMin {
+			return score > b || (!exclusive && score == b)
+		}
+		return score < b || (!exclusive && score == b)
+	}
+
+	z := r.zsets[key]
+	members := []string{}
+	for m, score := range z {
+		if inRange(score, min, true) && inRange(score, max, false) {
+			members = append(members, m)
+		}
+	}
+
+	sort.Slice(members, func(a, b int) bool {
+		if z[members[a]] != z[members[b]] {
+			return z[members[a]] < z[members[b]]
+		}
+		return members[a] < members[b]
+	})
+
+	reply := make([]any, len(members))
+	for i, m := range members {
+		if reverse {
+			i = len(members) - 1 - i
+		}
+		reply[i] = m
+	}
+
+	return reply
+}
+
+// eval runs the compare and set script, the same way as the lua code does
+func (r *redisFake) eval(args []string) (any, error) {
+	if args[1] != redisCompareAndSet {
+		return nil, errors.New("NOSCRIPT unknown script")
+	}
+
+	numKeys, _ := strconv.Atoi(args[2])
+	keys, argv := args[3:3+numKeys], args[3+numKeys:]
+	conds, _ := strconv.Atoi(argv[0])
+
+	for i, k := range keys[:conds] {
+		if r.hashes[k][argv[2*i+1]] != argv[2*i+2] {
+			return int64(0), nil
+		}
+	}
+
+	for a, k := 2*conds+1, conds; a < len(argv); k++ {
+		n, _ := strconv.Atoi(argv[a])
+		cmd := append([]string{argv[a+1], keys[k]}, argv[a+2:a+1+n]...)
+		_, err := r.do(cmd)
+		if err != nil {
+			return nil, err
+		}
+		a += n + 1
+	}
+
+	return int64(1), nil
+}
+
+// redisConn its a minimal RESP client, used to run the tests against a local redis-server when REDIS_ADDR is set
+type redisConn struct {
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func (c *redisConn) Do(ctx context.Context, args ...any) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	req := fmt.Sprintf("*%d\r\n", len(args))
+	for _, a := range args {
+		s := fmt.Sprint(a)
+		req += fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+	}
+
+	_, err := c.conn.Write([]byte(req))
+	if err != nil {
+		return nil, err
+	}
+
+	return c.read()
+}
+
+func (c *redisConn) read() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, errors.New(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, _ := strconv.Atoi(line[1:])
+		if n < 0 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		_, err = io.ReadFull(c.r, b)
+		return string(b[:n]), err
+	case '*':
+		n, _ := strconv.Atoi(line[1:])
+		if n < 0 {
+			return nil, nil
+		}
+		reply := make([]any, n)
+		for i := range reply {
+			reply[i], err = c.read()
+			if err != nil {
+				return nil, err
+			}
+		}
+		return reply, nil
+	default:
+		return nil, fmt.Errorf("Unexpected redis reply %s", line)
+	}
+}
+
+// redisClients returns the in-process fake, and the local redis-server if REDIS_ADDR is set
+func redisClients(t *testing.T) map[string]func() RedisClient {
+	clients := map[string]func() RedisClient{
+		"fake": func() RedisClient { return newRedisFake() },
+	}
+
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		return clients
+	}
+
+	clients["redis-server"] = func() RedisClient {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatalf("Failed to connect to redis at %s, %v", addr, err)
+		}
+		t.Cleanup(func() { conn.Close() })
+
+		return &redisConn{conn: conn, r: bufio.NewReader(conn)}
+	}
+
+	return clients
+}
+
+func TestRedisJobDB(t *testing.T) {
+	ctx := context.Background()
+
+	for name, newClient := range redisClients(t) {
+		// every test uses its own prefix, so that the tests do not see each other jobs on a shared redis-server
+		prefix := fmt.Sprintf("{scheduler-test-%d}", time.Now().UnixNano())
+		count := 0
+		newDB := func() JobDatabase {
+			count++
+			db := newRedis(newClient(), fmt.Sprintf("%s-%d", prefix, count))
+			assert.NoError(t, db.InitJobDB(ctx))
+			return db
+		}
+
+		t.Run(name, func(t *testing.T) {
+			t.Run("Should save and get the job with all of its fields", func(t *testing.T) {
+				db := newDB()
+				now := time.Now().UTC().Truncate(time.Millisecond)
+				j := Job{
+					Name:           "MYMOCKJOB!",
+					Data:           map[string]any{"key": "value", "tenant": map[string]any{"id": float64(42)}},
+					ScheduleType:   RECURRENT,
+					Status:         PENDING,
+					NextRunAt:      now,
+					LastRunAt:      &now,
+					ScheduleString: "@daily",
+					Location:       "America/Sao_Paulo",
+					RetryPolicy:    &RetryPolicy{MaxAttempts: 3, Backoff: EXPONENTIAL, Delay: time.Minute},
+					Timeout:        time.Hour,
+					LastError:      "MOCK ERROR!",
+					FailedAt:       &now,
+					FailureCount:   2,
+				}
+
+				id, err := db.SaveJob(ctx, j)
+				assert.NoError(t, err)
+				assert.NotEmpty(t, id)
+
+				saved, err := db.GetJob(ctx, id)
+				assert.NoError(t, err)
+
+				j.ID = id
+				assert.Equal(t, j, *saved)
+			})
+			t.Run("Should return ErrJobNotFound if the job does not exist", func(t *testing.T) {
+				_, err := newDB().GetJob(ctx, "42")
+				assert.ErrorIs(t, err, ErrJobNotFound)
+			})
+			t.Run("Should list only the due pending jobs as expired schedules", func(t *testing.T) {
+				db := newDB()
+				dueID, _ := db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: PENDING, NextRunAt: time.Now().Add(-time.Minute)})
+				db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: PENDING, NextRunAt: time.Now().Add(time.Hour)})
+				db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: DONE, NextRunAt: time.Now().Add(-time.Minute)})
+
+				js, err := db.ListExpiredSchedules(ctx)
+				assert.NoError(t, err)
+				assert.Len(t, js, 1)
+				assert.Equal(t, dueID, js[0].ID)
+			})
+			t.Run("Should claim a job only once", func(t *testing.T) {
+				db := newDB()
+				nextRunAt := time.Now()
+				id, _ := db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: PENDING, NextRunAt: nextRunAt})
+				lease := time.Now().Add(time.Minute)
+
+				var wg sync.WaitGroup
+				claims := make(chan bool, 10)
+				for i := 0; i < 10; i++ {
+					wg.Add(1)
+					go func(i int) {
+						defer wg.Done()
+						claimed, err := db.ClaimJob(ctx, Job{ID: id, Owner: fmt.Sprintf("worker-%d", i), NextRunAt: nextRunAt, LeaseExpiresAt: &lease})
+						assert.NoError(t, err)
+						claims <- claimed
+					}(i)
+				}
+				wg.Wait()
+				close(claims)
+
+				claimed := 0
+				for c := range claims {
+					if c {
+						claimed++
+					}
+				}
+				assert.Equal(t, 1, claimed)
+
+				js, _ := db.ListExpiredSchedules(ctx)
+				assert.Empty(t, js)
+				j, _ := db.GetJob(ctx, id)
+				assert.True(t, j.IsRunning())
+			})
+			t.Run("Should renew the lease only for the job owner and recover the expired leases", func(t *testing.T) {
+				db := newDB()
+				nextRunAt := time.Now()
+				id, _ := db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: PENDING, NextRunAt: nextRunAt})
+				expired := time.Now().Add(-time.Minute)
+				db.ClaimJob(ctx, Job{ID: id, Owner: "my-worker", NextRunAt: nextRunAt, LeaseExpiresAt: &expired})
+
+				renewed, err := db.RenewLease(ctx, Job{ID: id, Owner: "another-worker", LeaseExpiresAt: &expired})
+				assert.NoError(t, err)
+				assert.False(t, renewed)
+
+				js, _ := db.ListExpiredLeases(ctx)
+				assert.Len(t, js, 1)
+
+				recovered, err := db.RecoverJob(ctx, Job{ID: id, Name: "MYMOCKJOB!", Status: PENDING, NextRunAt: time.Now(), OrphanCount: 1})
+				assert.NoError(t, err)
+				assert.True(t, recovered)
+
+				js, _ = db.ListExpiredLeases(ctx)
+				assert.Empty(t, js)
+				js, _ = db.ListExpiredSchedules(ctx)
+				assert.Len(t, js, 1)
+				assert.Equal(t, 1, js[0].OrphanCount)
+			})
+			t.Run("Should not update a running job", func(t *testing.T) {
+				db := newDB()
+				id, _ := db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: RUNNING})
+
+				updated, err := db.UpdateJob(ctx, Job{ID: id, Name: "MYMOCKJOB!", Status: CANCELED}, Job{ID: id, Status: RUNNING})
+				assert.NoError(t, err)
+				assert.False(t, updated)
+			})
+			t.Run("Should keep or replace the job holding the unique key", func(t *testing.T) {
+				db := newDB()
+				id, saved, err := db.SaveUniqueJob(ctx, Job{Name: "MYMOCKJOB!", Status: PENDING, UniqueKey: "MYKEY"}, false)
+				assert.NoError(t, err)
+				assert.True(t, saved)
+
+				keptID, saved, err := db.SaveUniqueJob(ctx, Job{Name: "ANOTHERJOB!", Status: PENDING, UniqueKey: "MYKEY"}, false)
+				assert.NoError(t, err)
+				assert.False(t, saved)
+				assert.Equal(t, id, keptID)
+
+				replacedID, saved, err := db.SaveUniqueJob(ctx, Job{Name: "ANOTHERJOB!", Status: PENDING, UniqueKey: "MYKEY"}, true)
+				assert.NoError(t, err)
+				assert.True(t, saved)
+				assert.Equal(t, id, replacedID)
+
+				// the name index is changed along with the job
+				count, _ := db.Count(ctx, Finder{Name: "MYMOCKJOB!"})
+				assert.Equal(t, 0, count)
+				count, _ = db.Count(ctx, Finder{Name: "ANOTHERJOB!"})
+				assert.Equal(t, 1, count)
+
+				db.ClaimJob(ctx, Job{ID: id, Owner: "my-worker"})
+				_, _, err = db.SaveUniqueJob(ctx, Job{Name: "MYMOCKJOB!", UniqueKey: "MYKEY"}, true)
+				assert.ErrorIs(t, err, ErrJobRunning)
+
+				// the unique key is released when the job is deleted
+				db.DeleteJob(ctx, Job{ID: id})
+				newID, saved, _ := db.SaveUniqueJob(ctx, Job{Name: "MYMOCKJOB!", UniqueKey: "MYKEY"}, false)
+				assert.True(t, saved)
+				assert.NotEqual(t, id, newID)
+			})
+			t.Run("Should find, sort and paginate the jobs", func(t *testing.T) {
+				db := newDB()
+				now := time.Now().UTC()
+				firstID, _ := db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: PENDING, NextRunAt: now.Add(time.Hour), Data: map[string]any{"tenant": 42}})
+				secondID, _ := db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: FAILED, NextRunAt: now})
+				db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: DONE, NextRunAt: now})
+				db.SaveJob(ctx, Job{Name: "ANOTHERJOB!", Status: PENDING, NextRunAt: now})
+
+				js, err := db.List(ctx, Finder{Name: "MYMOCKJOB!", Statuses: []ScheduleStatus{PENDING, FAILED}, SortBy: SORT_BY_NEXT_RUN_AT})
+				assert.NoError(t, err)
+				assert.Len(t, js, 2)
+				assert.Equal(t, secondID, js[0].ID)
+				assert.Equal(t, firstID, js[1].ID)
+
+				js, _ = db.List(ctx, Finder{Data: map[string]any{"tenant": 42}})
+				assert.Len(t, js, 1)
+				assert.Equal(t, firstID, js[0].ID)
+
+				js, _ = db.List(ctx, Finder{Limit: 2, Offset: 1})
+				assert.Len(t, js, 2)
+				assert.Equal(t, secondID, js[0].ID)
+
+				count, _ := db.Count(ctx, Finder{Status: "PENDING"})
+				assert.Equal(t, 2, count)
+			})
+			t.Run("Should change many jobs, skipping the running ones", func(t *testing.T) {
+				db := newDB()
+				now := time.Now().UTC().Truncate(time.Millisecond)
+				id, _ := db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: PENDING, NextRunAt: now})
+				db.SaveJob(ctx, Job{Name: "MYMOCKJOB!", Status: RUNNING, NextRunAt: now})
+				db.SaveJob(ctx, Job{Name: "ANOTHERJOB!", Status: PENDING, NextRunAt: now})
+
+				rescheduled, err := db.RescheduleMany(ctx, Finder{Name: "MYMOCKJOB!"}, time.Hour)
+				assert.NoError(t, err)
+				assert.Equal(t, 1, rescheduled)
+				j, _ := db.GetJob(ctx, id)
+				assert.Equal(t, now.Add(time.Hour), j.NextRunAt)
+
+				canceled, err := db.CancelMany(ctx, Finder{Name: "MYMOCKJOB!"})
+				assert.NoError(t, err)
+				assert.Equal(t, 1, canceled)
+
+				deleted, err := db.DeleteMany(ctx, Finder{})
+				assert.NoError(t, err)
+				assert.Equal(t, 2, deleted)
+
+				count, _ := db.Count(ctx, Finder{})
+				assert.Equal(t, 1, count)
+			})
+			t.Run("Should save, list and delete the job runs", func(t *testing.T) {
+				db := newDB()
+				now := time.Now().UTC()
+				db.SaveRun(ctx, JobRun{JobID: "1", Outcome: RUN_FAILED, StartedAt: now.Add(-time.Hour)})
+				db.SaveRun(ctx, JobRun{JobID: "1", Outcome: RUN_SUCCEEDED, StartedAt: now})
+				db.SaveRun(ctx, JobRun{JobID: "2", Outcome: RUN_SUCCEEDED, StartedAt: now})
+
+				rs, err := db.ListRuns(ctx, RunFinder{JobID: "1"})
+				assert.NoError(t, err)
+				assert.Len(t, rs, 2)
+				assert.Equal(t, RUN_SUCCEEDED, rs[0].Outcome)
+
+				deleted, err := db.DeleteRunsBefore(ctx, now.Add(-time.Minute))
+				assert.NoError(t, err)
+				assert.Equal(t, 1, deleted)
+
+				rs, _ = db.ListRuns(ctx, RunFinder{})
+				assert.Len(t, rs, 2)
+			})
+		})
+	}
+
+	t.Run("Should be used by the library when a redis client is configured", func(t *testing.T) {
+		s, err := New(Config{Redis: &RedisJobDBConfig{Client: newRedisFake()}, ProcessingRate: time.Hour})
+		assert.NoError(t, err)
+		defer s.Stop()
+
+		assert.Equal(t, "{scheduler}", s.db.(*redisJobDB).prefix)
+	})
+}
+
+func TestDecodeRedisJob(t *testing.T) {
+	t.Run("Should fail if a field is not a number", func(t *testing.T) {
+		_, err := decodeRedisJob("42", map[string]string{"attempts": "a lot"}, nil)
+		assert.ErrorContains(t, err, "Failed to decode the field attempts of job 42")
+	})
+	t.Run("Should read the job hash given as a map reply", func(t *testing.T) {
+		db := &redisJobDB{}
+		hash, err := db.hashReply(map[any]any{"name": "MYMOCKJOB!", "attempts": int64(2)}, nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"name": "MYMOCKJOB!", "attempts": "2"}, hash)
+	})
+}
@@ -0,0 +1,139 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	// defaultScheduler its the scheduler instance used by the package level functions
+	defaultScheduler = newScheduler()
+)
+
+// Init inits the default scheduler instance, used by the package level functions.
+func Init(c Config) (err error) {
+	return defaultScheduler.init(c)
+}
+
+// Define inserts a new job definition on the default scheduler instance.
+//
+// See Scheduler.Define for more.
+func Define(jobName string, fn JobFunc, options ...DefinitionOptions) {
+	defaultScheduler.Define(jobName, fn, options...)
+}
+
+// In creates a new definition of a SIMPLE and PENDING job to be run once in the provided duration, on the default scheduler instance.
+//
+// See Scheduler.In for more.
+func In(d time.Duration) *simpleScheduleDefinition {
+	return defaultScheduler.In(d)
+}
+
+// On creates a new definition of a SIMPLE and PENDING job to be run once in the provided date time, on the default scheduler instance.
+//
+// See Scheduler.On for more.
+func On(t time.Time) *simpleScheduleDefinition {
+	return defaultScheduler.On(t)
+}
+
+// Every schedules a RECURRENT job to run repeatedly given the schedule string, on the default scheduler instance.
+//
+// See Scheduler.Every for more.
+func Every(schedule string) *recurrentScheduleDefinition {
+	return defaultScheduler.Every(schedule)
+}
+
+// List lists jobs on the default scheduler instance database given the finder.
+func List(f Finder) ([]*Job, error) {
+	return defaultScheduler.List(f)
+}
+
+// Count counts the jobs on the default scheduler instance database given the finder.
+//
+// See Scheduler.Count for more.
+func Count(f Finder) (int, error) {
+	return defaultScheduler.Count(f)
+}
+
+// Get returns the job with the given ID, on the default scheduler instance database.
+//
+// See Scheduler.Get for more.
+func Get(id string) (*Job, error) {
+	return defaultScheduler.Get(id)
+}
+
+// Reschedule sets the job with the given ID to run on the provided date time, on the default scheduler instance database.
+//
+// See Scheduler.Reschedule for more.
+func Reschedule(id string, t time.Time) (*Job, error) {
+	return defaultScheduler.Reschedule(id, t)
+}
+
+// UpdateData replaces the extra data of the job with the given ID, on the default scheduler instance database.
+//
+// See Scheduler.UpdateData for more.
+func UpdateData(id string, data map[string]any) (*Job, error) {
+	return defaultScheduler.UpdateData(id, data)
+}
+
+// ChangeSchedule changes the schedule string of the RECURRENT job with the given ID, on the default scheduler instance database.
+//
+// See Scheduler.ChangeSchedule for more.
+func ChangeSchedule(id string, schedule string) (*Job, error) {
+	return defaultScheduler.ChangeSchedule(id, schedule)
+}
+
+// RunNow sets the job with the given ID to run as soon as possible, on the default scheduler instance database.
+//
+// See Scheduler.RunNow for more.
+func RunNow(id string) (*Job, error) {
+	return defaultScheduler.RunNow(id)
+}
+
+// CancelMany cancels every job found given the finder, on the default scheduler instance database.
+//
+// See Scheduler.CancelMany for more.
+func CancelMany(f Finder) (int, error) {
+	return defaultScheduler.CancelMany(f)
+}
+
+// DeleteMany deletes every job found given the finder, on the default scheduler instance database.
+//
+// See Scheduler.DeleteMany for more.
+func DeleteMany(f Finder) (int, error) {
+	return defaultScheduler.DeleteMany(f)
+}
+
+// RescheduleMany moves the next run date of every job found given the finder by the delta duration,
+// on the default scheduler instance database.
+//
+// See Scheduler.RescheduleMany for more.
+func RescheduleMany(f Finder, delta time.Duration) (int, error) {
+	return defaultScheduler.RescheduleMany(f, delta)
+}
+
+// ProcessCycle runs a processing cycle right away on the default scheduler instance, and waits for the running jobs to finish.
+//
+// See Scheduler.ProcessCycle for more.
+func ProcessCycle(ctx context.Context) error {
+	return defaultScheduler.ProcessCycle(ctx)
+}
+
+// ListRuns lists the job runs recorded on the default scheduler instance database given the run finder.
+//
+// See Scheduler.ListRuns for more.
+func ListRuns(f RunFinder) ([]*JobRun, error) {
+	return defaultScheduler.ListRuns(f)
+}
+
+// Shutdown gracefully shuts down the default scheduler instance.
+//
+// See Scheduler.Shutdown for more.
+func Shutdown(ctx context.Context) error {
+	return defaultScheduler.Shutdown(ctx)
+}
+
+// Stop stops the default scheduler instance, waiting for the running jobs to finish with no time limit.
+func Stop() {
+	defaultScheduler.Stop()
+}
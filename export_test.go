@@ -0,0 +1,22 @@
+package scheduler
+
+import (
+	"database/sql"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// NewRedisFakeJobDB creates a redis job database on an in-process fake, so that the external tests can use it
+func NewRedisFakeJobDB() JobDatabase {
+	return newRedis(newRedisFake(), "{scheduler}")
+}
+
+// NewPostgresJobDB creates a postgres job database on the given tables, so that the external tests can use it
+func NewPostgresJobDB(conn *sql.DB, tableName, runsTableName string) (JobDatabase, error) {
+	return newPostgres(conn, tableName, runsTableName)
+}
+
+// NewMongoJobDB creates a mongo job database on the given collections, so that the external tests can use it
+func NewMongoJobDB(conn *mongo.Client, dbName, collName, runsCollName string) JobDatabase {
+	return newMongo(conn, dbName, collName, runsCollName)
+}
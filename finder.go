@@ -1,10 +1,270 @@
 package scheduler
 
-// Finder its a helper struct used to pass parameters to the database List action.
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Finder its a helper struct used to pass parameters to the database List and Count actions.
 //
-// Currently, only the status, name or data values can be used.
+// Every value that is set narrows the jobs found, and the zero values are ignored.
 type Finder struct {
+	// Status finds the jobs with the given status
 	Status string
-	Name   string
-	Data   map[string]any
+
+	// Statuses finds the jobs with any of the given statuses.
+	//
+	// If Status is also set, it is considered as one more status of the list.
+	Statuses []ScheduleStatus
+
+	// Name finds the jobs with the given name
+	Name string
+
+	// ScheduleType finds the jobs with the given schedule type (SIMPLE or RECURRENT)
+	ScheduleType ScheduleType
+
+	// Data finds the jobs which extra data has the given values on each one of the given keys
+	Data map[string]any
+
+	// NextRunAfter finds the jobs which NextRunAt is after or equal to the given time
+	NextRunAfter time.Time
+
+	// NextRunBefore finds the jobs which NextRunAt is before the given time
+	NextRunBefore time.Time
+
+	// LastRunAfter finds the jobs which LastRunAt is after or equal to the given time
+	LastRunAfter time.Time
+
+	// LastRunBefore finds the jobs which LastRunAt is before the given time
+	LastRunBefore time.Time
+
+	// SortBy defines the field that the listed jobs are sorted by.
+	//
+	// If not set, the jobs are listed in the order they were created.
+	SortBy SortField
+
+	// SortDescending sorts the listed jobs in descending order
+	SortDescending bool
+
+	// Limit defines the maximum number of jobs listed. If not set, the listed jobs are not limited.
+	Limit int
+
+	// Offset defines how many of the jobs found are skipped before listing, so that the jobs can be listed in pages
+	Offset int
+
+	// Fields defines the job fields (Ex.: "Name", "Status", "NextRunAt") that the listed jobs are filled with, besides their ID.
+	// If not set, the jobs are listed with every field.
+	//
+	// The jobs listed with a projection are read-only: they can't be saved back on the database (Ex.: with Done or Cancel),
+	// since the fields left out would be lost, and ErrPartialJob is returned instead.
+	Fields []string
+}
+
+// SortField represents a job field that the listed jobs can be sorted by
+type SortField string
+
+const (
+	SORT_BY_NEXT_RUN_AT = SortField("NEXT_RUN_AT")
+	SORT_BY_LAST_RUN_AT = SortField("LAST_RUN_AT")
+	SORT_BY_NAME        = SortField("NAME")
+	SORT_BY_STATUS      = SortField("STATUS")
+)
+
+// String returns the sort field in string notation
+func (sf SortField) String() string {
+	return string(sf)
+}
+
+// statuses returns every status the finder finds jobs by, given by both the Status and the Statuses values
+func (f Finder) statuses() (ss []ScheduleStatus) {
+	ss = append(ss, f.Statuses...)
+	if f.Status != "" {
+		ss = append(ss, ScheduleStatus(f.Status))
+	}
+
+	return
+}
+
+// matches returns true if the job is found given the finder,
+// so that databases that can not translate the finder into a query can filter the jobs themselves
+func (f Finder) matches(j Job) bool {
+	if f.Name != "" && j.Name != f.Name {
+		return false
+	}
+
+	if statuses := f.statuses(); len(statuses) > 0 && !containsStatus(statuses, j.Status) {
+		return false
+	}
+
+	if f.ScheduleType != "" && j.ScheduleType != f.ScheduleType {
+		return false
+	}
+
+	for field, value := range f.Data {
+		v, ok := dataValue(j.Data, field)
+		if !ok || !equalValues(v, value) {
+			return false
+		}
+	}
+
+	if !inTimeRange(&j.NextRunAt, f.NextRunAfter, f.NextRunBefore) ||
+		!inTimeRange(j.LastRunAt, f.LastRunAfter, f.LastRunBefore) {
+		return false
+	}
+
+	return true
+}
+
+// containsStatus returns true if the status is one of the statuses
+func containsStatus(statuses []ScheduleStatus, status ScheduleStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inTimeRange returns true if t is after or equal to after, and before before.
+//
+// A zero time leaves its side of the range open, and a nil t is only in the range if the range is fully open.
+func inTimeRange(t *time.Time, after, before time.Time) bool {
+	if after.IsZero() && before.IsZero() {
+		return true
+	}
+
+	if t == nil {
+		return false
+	}
+
+	return (after.IsZero() || !t.Before(after)) &&
+		(before.IsZero() || t.Before(before))
+}
+
+// dataValue returns the value of the data field, that can be a path to a nested field (Ex.: "address.city")
+func dataValue(data map[string]any, field string) (any, bool) {
+	var value any = data
+	for _, key := range strings.Split(field, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		value, ok = m[key]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// equalValues returns true if the values are equal, comparing numbers regardless of their type
+func equalValues(a, b any) bool {
+	af, aIsNumber := toFloat(a)
+	bf, bIsNumber := toFloat(b)
+	if aIsNumber && bIsNumber {
+		return af == bf
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// toFloat converts the value to a float, returning false if the value is not a number
+func toFloat(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// sortAndPaginate sorts the jobs according to the finder, and returns the page of jobs defined by the finder limit and offset.
+//
+// The jobs are expected in their creation order, that is kept between jobs with equal sort fields.
+func (f Finder) sortAndPaginate(js []*Job) []*Job {
+	compare := func(a, b *Job) int { return 0 }
+	switch f.SortBy {
+	case SORT_BY_NEXT_RUN_AT:
+		compare = func(a, b *Job) int { return a.NextRunAt.Compare(b.NextRunAt) }
+	case SORT_BY_LAST_RUN_AT:
+		compare = func(a, b *Job) int { return compareTimes(a.LastRunAt, b.LastRunAt) }
+	case SORT_BY_NAME:
+		compare = func(a, b *Job) int { return strings.Compare(a.Name, b.Name) }
+	case SORT_BY_STATUS:
+		compare = func(a, b *Job) int { return strings.Compare(a.Status.String(), b.Status.String()) }
+	}
+
+	sort.SliceStable(js, func(a, b int) bool {
+		return compare(js[a], js[b]) < 0
+	})
+	if f.SortDescending {
+		// reversing the stable sort keeps the jobs with equal sort fields in descending creation order,
+		// the same way the jobs are sorted by their IDs when listed from the mongo database
+		for a, b := 0, len(js)-1; a < b; a, b = a+1, b-1 {
+			js[a], js[b] = js[b], js[a]
+		}
+	}
+
+	if f.Offset > 0 {
+		if f.Offset > len(js) {
+			return []*Job{}
+		}
+		js = js[f.Offset:]
+	}
+
+	if f.Limit > 0 && f.Limit < len(js) {
+		js = js[:f.Limit]
+	}
+
+	return js
+}
+
+// compareTimes compares the times, a nil time being lesser than any other time
+func compareTimes(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	default:
+		return a.Compare(*b)
+	}
+}
+
+// checkFields returns an error if any of the finder fields is not an exported job field
+func (f Finder) checkFields() error {
+	t := reflect.TypeOf(Job{})
+	for _, field := range f.Fields {
+		sf, ok := t.FieldByName(field)
+		if !ok || !sf.IsExported() {
+			return fmt.Errorf("Failed to list jobs, '%s' is not a job field", field)
+		}
+	}
+
+	return nil
+}
+
+// project returns a partial copy of the job, filled only with its ID and the finder fields
+func (f Finder) project(j *Job) *Job {
+	p := &Job{ID: j.ID, partial: true}
+
+	src, dst := reflect.ValueOf(j).Elem(), reflect.ValueOf(p).Elem()
+	for _, field := range f.Fields {
+		dst.FieldByName(field).Set(src.FieldByName(field))
+	}
+
+	return p
 }
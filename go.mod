@@ -3,6 +3,7 @@ module github.com/delivery-much/go-scheduler
 go 1.20
 
 require (
+	github.com/lib/pq v1.10.9
 	github.com/stretchr/testify v1.8.4
 	go.mongodb.org/mongo-driver v1.12.0
 )
@@ -1,12 +1,13 @@
 package scheduler
 
-import "time"
-
-var (
-	deleteOnDone   = false
-	deleteOnCancel = false
+import (
+	"errors"
+	"time"
 )
 
+// ErrPartialJob is returned when trying to save a job that was listed with a projection (See Finder.Fields)
+var ErrPartialJob = errors.New("Job was listed with a projection, and can't be saved")
+
 // Job represents a schedule job.
 type Job struct {
 	// ID its the job ID in the database
@@ -15,9 +16,22 @@ type Job struct {
 	// ScheduleType represents the job schedule type, if its a job that runs only once (SIMPLE) or if its a job that runs recurrently (RECURRENT)
 	ScheduleType
 
-	// Status represents the job schedule current status, if its pending, failed, done, or canceled
+	// Status represents the job schedule current status, if its pending, running, failed, timed out, done, or canceled
 	Status ScheduleStatus
 
+	// Owner represents the worker ID of the scheduler instance that claimed the job,
+	// while the job is RUNNING
+	Owner string
+
+	// LeaseExpiresAt defines until when the Owner holds the job, while the job is RUNNING.
+	//
+	// The lease is renewed periodically while the job runs, if it expires,
+	// the job is considered orphaned (Ex.: the instance running it crashed) and is recovered.
+	LeaseExpiresAt *time.Time
+
+	// OrphanCount represents how many times the job was orphaned while RUNNING
+	OrphanCount int
+
 	// NextRunAt defines when the job should run
 	NextRunAt time.Time
 
@@ -34,45 +48,187 @@ type Job struct {
 	// If no limit date is set, the job will run forever until its manually canceled on deleted.
 	ScheduleLimitDate *time.Time
 
+	// ScheduleAnchor defines the date that the time intervals of the RECURRENT job schedule string (Ex.: "1 month") are counted from,
+	// that is when the schedule was configured.
+	//
+	// It keeps the runs from drifting: a job scheduled every "1 month" on January 31st runs on February 29th (or 28th),
+	// and then back on March 31st.
+	//
+	// The jobs saved with no anchor are anchored on their first run that is not a retry.
+	ScheduleAnchor *time.Time
+
+	// Location represents the timezone (Ex.: "Europe/Lisbon") on which the RECURRENT job schedule string is evaluated.
+	//
+	// If no location is set, the location configured in the library instantiation is used.
+	Location string
+
+	// RetryPolicy defines how the job should be retried when its job function fails.
+	//
+	// If no retry policy is set, the retry policy configured in the library instantiation is used.
+	RetryPolicy *RetryPolicy
+
+	// Timeout defines how long the job function can run before its context is cancelled and the run is considered TIMED_OUT.
+	//
+	// If no timeout is set, the timeout of the job definition, or the one configured in the library instantiation is used.
+	Timeout time.Duration
+
+	// LastError is the error message of the last failure of the job
+	LastError string
+
+	// FailedAt defines when the job last failed
+	FailedAt *time.Time
+
+	// FailureCount represents how many runs of the job failed in total,
+	// including the failures that were retried (unlike Attempts, it is not reset when the job succeeds)
+	FailureCount int
+
+	// UniqueKey identifies the job among the other jobs on the database, if set.
+	//
+	// Only one job can hold a unique key, so that scheduling the same job many times (Ex.: on every application start)
+	// does not create duplicates.
+	UniqueKey string
+
+	// Attempts represents how many times the job function failed in a row for the current run
+	Attempts int
+
 	// Name represents the job definition name
 	Name string
 
 	// Data represents the extra data that the user can provide when defining a job
 	Data map[string]any
+
+	// scheduler its the scheduler instance that manages the job
+	scheduler *Scheduler
+
+	// partial defines if the job was listed with a projection (See Finder.Fields), so that it can't be saved back on the database
+	partial bool
 }
 
 // Done sets the job schedule status as DONE and saves it on the database
+//
+// Returns ErrPartialJob if the job was listed with a projection.
 func (j *Job) Done() error {
+	if j.partial {
+		return ErrPartialJob
+	}
+
 	j.Status = DONE
+	j.release()
+
+	s := j.getScheduler()
+	ctx, cancel := s.dbContext()
+	defer cancel()
 
-	if deleteOnDone {
-		return db.DeleteJob(*j)
+	if s.deleteOnDone {
+		return s.db.DeleteJob(ctx, *j)
 	}
 
-	return db.SaveJob(*j)
+	_, err := s.db.SaveJob(ctx, *j)
+	return err
 }
 
 // Fail sets the job schedule status as FAILED and saves it on the database
+//
+// Returns ErrPartialJob if the job was listed with a projection.
 func (j *Job) Fail() error {
+	if j.partial {
+		return ErrPartialJob
+	}
+
 	j.Status = FAILED
+	j.release()
+
+	s := j.getScheduler()
+	ctx, cancel := s.dbContext()
+	defer cancel()
 
-	return db.SaveJob(*j)
+	_, err := s.db.SaveJob(ctx, *j)
+	return err
 }
 
 // Cancel sets the job schedule status as CANCELED and saves it on the database
+//
+// Returns ErrPartialJob if the job was listed with a projection.
 func (j *Job) Cancel() error {
+	if j.partial {
+		return ErrPartialJob
+	}
+
 	j.Status = CANCELED
+	j.release()
 
-	if deleteOnCancel {
-		return db.DeleteJob(*j)
+	s := j.getScheduler()
+	ctx, cancel := s.dbContext()
+	defer cancel()
+
+	if s.deleteOnCancel {
+		return s.db.DeleteJob(ctx, *j)
 	}
 
-	return db.SaveJob(*j)
+	_, err := s.db.SaveJob(ctx, *j)
+	return err
 }
 
 // Delete deletes the job from the database
 func (j *Job) Delete() error {
-	return db.DeleteJob(*j)
+	s := j.getScheduler()
+	ctx, cancel := s.dbContext()
+	defer cancel()
+
+	return s.db.DeleteJob(ctx, *j)
+}
+
+// getScheduler returns the scheduler instance that manages the job,
+// or the default scheduler instance if the job was not attached to any
+func (j *Job) getScheduler() *Scheduler {
+	if j.scheduler == nil {
+		return defaultScheduler
+	}
+
+	return j.scheduler
+}
+
+// release clears the job ownership and lease, since it's not RUNNING anymore
+func (j *Job) release() {
+	j.Owner = ""
+	j.LeaseExpiresAt = nil
+}
+
+// copyData deep copies the job data, so that the copy shares no maps or slices with the original data
+func copyData(data map[string]any) map[string]any {
+	if data == nil {
+		return nil
+	}
+
+	c := make(map[string]any, len(data))
+	for k, v := range data {
+		c[k] = copyDataValue(v)
+	}
+
+	return c
+}
+
+// copyDataValue deep copies a job data value
+func copyDataValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return copyData(v)
+	case []any:
+		c := make([]any, len(v))
+		for i, e := range v {
+			c[i] = copyDataValue(e)
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// setFailure records the failure of the job run, that happened at the given time
+func (j *Job) setFailure(err error, at time.Time) {
+	j.LastError = err.Error()
+	j.FailedAt = &at
+	j.FailureCount++
 }
 
 // IsDone returns true if the job status is DONE, and false otherwise
@@ -85,6 +241,11 @@ func (j *Job) HasFailed() bool {
 	return j.Status == FAILED
 }
 
+// HasTimedOut returns true if the job status is TIMED_OUT, and false otherwise
+func (j *Job) HasTimedOut() bool {
+	return j.Status == TIMED_OUT
+}
+
 // IsCanceled returns true if the job status is CANCELED, and false otherwise
 func (j *Job) IsCanceled() bool {
 	return j.Status == CANCELED
@@ -95,6 +256,11 @@ func (j *Job) IsPending() bool {
 	return j.Status == PENDING
 }
 
+// IsRunning returns true if the job status is RUNNING, and false otherwise
+func (j *Job) IsRunning() bool {
+	return j.Status == RUNNING
+}
+
 // IsSimple return true if the job schedule type is SIMPLE, and false otherwise
 func (j *Job) IsSimple() bool {
 	return j.ScheduleType == SIMPLE
@@ -0,0 +1,72 @@
+package scheduler
+
+import "time"
+
+// DefinitionOptions represents the optional values that can be provided when defining a job.
+type DefinitionOptions struct {
+	// Concurrency limits how many jobs with this name can run at the same time on this scheduler instance.
+	//
+	// If no value is specified, the jobs are only limited by the library Concurrency.
+	Concurrency int
+
+	// Timeout defines how long the jobs with this name can run before their context is cancelled and the run is considered TIMED_OUT.
+	//
+	// A job function that ignores its cancelled context is abandoned after a grace period (See Config.JobTimeout).
+	//
+	// If no value is specified, the library JobTimeout is used.
+	Timeout time.Duration
+}
+
+// jobDefinition represents a job definition, with the function to be called when the job is triggered
+type jobDefinition struct {
+	fn JobFunc
+
+	// timeout its the timeout of the jobs with the definition name
+	timeout time.Duration
+
+	// slots limits how many jobs with the definition name can run at the same time, if the definition has a concurrency limit
+	slots chan struct{}
+}
+
+// newJobDefinition creates a new job definition given the job function and the definition options
+func newJobDefinition(fn JobFunc, options ...DefinitionOptions) *jobDefinition {
+	jd := &jobDefinition{
+		fn: fn,
+	}
+
+	if len(options) == 0 {
+		return jd
+	}
+
+	if options[0].Concurrency > 0 {
+		jd.slots = make(chan struct{}, options[0].Concurrency)
+	}
+	jd.timeout = options[0].Timeout
+
+	return jd
+}
+
+// acquire tries to acquire a slot to run a job with the definition name.
+//
+// Returns false if the definition concurrency limit was reached.
+func (jd *jobDefinition) acquire() bool {
+	if jd.slots == nil {
+		return true
+	}
+
+	select {
+	case jd.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// release releases a slot previously acquired to run a job with the definition name
+func (jd *jobDefinition) release() {
+	if jd.slots == nil {
+		return
+	}
+
+	<-jd.slots
+}
@@ -8,15 +8,36 @@ import (
 
 // jobDocument represents a job document on the mongo database
 type jobDocument struct {
-	ID                *primitive.ObjectID `bson:"_id,omitempty"`
-	Name              string              `bson:"name"`
-	Data              map[string]any      `bson:"data"`
-	ScheduleType      string              `bson:"schedule_type"`
-	Status            string              `bson:"status"`
-	NextRunAt         time.Time           `bson:"next_run_at"`
-	LastRunAt         *time.Time          `bson:"last_run_at,omitempty"`
-	ScheduleString    string              `bson:"schedule_string,omitempty"`
-	ScheduleLimitDate *time.Time          `bson:"schedule_limit_date,omitempty"`
+	ID                *primitive.ObjectID  `bson:"_id,omitempty"`
+	Name              string               `bson:"name"`
+	Data              map[string]any       `bson:"data"`
+	ScheduleType      string               `bson:"schedule_type"`
+	Status            string               `bson:"status"`
+	Owner             string               `bson:"owner"`
+	LeaseExpiresAt    *time.Time           `bson:"lease_expires_at"`
+	OrphanCount       int                  `bson:"orphan_count,omitempty"`
+	NextRunAt         time.Time            `bson:"next_run_at"`
+	LastRunAt         *time.Time           `bson:"last_run_at,omitempty"`
+	ScheduleString    string               `bson:"schedule_string,omitempty"`
+	ScheduleLimitDate *time.Time           `bson:"schedule_limit_date,omitempty"`
+	ScheduleAnchor    *time.Time           `bson:"schedule_anchor,omitempty"`
+	Location          string               `bson:"location,omitempty"`
+	RetryPolicy       *retryPolicyDocument `bson:"retry_policy,omitempty"`
+	Timeout           time.Duration        `bson:"timeout,omitempty"`
+	Attempts          int                  `bson:"attempts"`
+	LastError         string               `bson:"last_error,omitempty"`
+	FailedAt          *time.Time           `bson:"failed_at,omitempty"`
+	FailureCount      int                  `bson:"failure_count,omitempty"`
+	UniqueKey         string               `bson:"unique_key,omitempty"`
+}
+
+// retryPolicyDocument represents a job retry policy on the mongo database
+type retryPolicyDocument struct {
+	MaxAttempts int           `bson:"max_attempts"`
+	Backoff     string        `bson:"backoff,omitempty"`
+	Delay       time.Duration `bson:"delay,omitempty"`
+	MaxDelay    time.Duration `bson:"max_delay,omitempty"`
+	Jitter      bool          `bson:"jitter,omitempty"`
 }
 
 // marshalJob marshals a job struct into a job document
@@ -35,10 +56,22 @@ func marshalJob(j Job) jobDocument {
 		Data:              j.Data,
 		ScheduleType:      j.ScheduleType.String(),
 		Status:            j.Status.String(),
+		Owner:             j.Owner,
+		LeaseExpiresAt:    j.LeaseExpiresAt,
+		OrphanCount:       j.OrphanCount,
 		NextRunAt:         j.NextRunAt,
 		LastRunAt:         j.LastRunAt,
 		ScheduleString:    j.ScheduleString,
 		ScheduleLimitDate: j.ScheduleLimitDate,
+		ScheduleAnchor:    j.ScheduleAnchor,
+		Location:          j.Location,
+		RetryPolicy:       marshalRetryPolicy(j.RetryPolicy),
+		Timeout:           j.Timeout,
+		Attempts:          j.Attempts,
+		LastError:         j.LastError,
+		FailedAt:          j.FailedAt,
+		FailureCount:      j.FailureCount,
+		UniqueKey:         j.UniqueKey,
 	}
 }
 
@@ -50,9 +83,51 @@ func unmarshalJob(j jobDocument) Job {
 		Data:              j.Data,
 		ScheduleType:      ScheduleType(j.ScheduleType),
 		Status:            ScheduleStatus(j.Status),
+		Owner:             j.Owner,
+		LeaseExpiresAt:    j.LeaseExpiresAt,
+		OrphanCount:       j.OrphanCount,
 		NextRunAt:         j.NextRunAt,
 		LastRunAt:         j.LastRunAt,
 		ScheduleString:    j.ScheduleString,
 		ScheduleLimitDate: j.ScheduleLimitDate,
+		ScheduleAnchor:    j.ScheduleAnchor,
+		Location:          j.Location,
+		RetryPolicy:       unmarshalRetryPolicy(j.RetryPolicy),
+		Timeout:           j.Timeout,
+		Attempts:          j.Attempts,
+		LastError:         j.LastError,
+		FailedAt:          j.FailedAt,
+		FailureCount:      j.FailureCount,
+		UniqueKey:         j.UniqueKey,
+	}
+}
+
+// marshalRetryPolicy marshals a retry policy struct into a retry policy document
+func marshalRetryPolicy(rp *RetryPolicy) *retryPolicyDocument {
+	if rp == nil {
+		return nil
+	}
+
+	return &retryPolicyDocument{
+		MaxAttempts: rp.MaxAttempts,
+		Backoff:     rp.Backoff.String(),
+		Delay:       rp.Delay,
+		MaxDelay:    rp.MaxDelay,
+		Jitter:      rp.Jitter,
+	}
+}
+
+// unmarshalRetryPolicy marshals a retry policy document into a retry policy struct
+func unmarshalRetryPolicy(rp *retryPolicyDocument) *RetryPolicy {
+	if rp == nil {
+		return nil
+	}
+
+	return &RetryPolicy{
+		MaxAttempts: rp.MaxAttempts,
+		Backoff:     BackoffStrategy(rp.Backoff),
+		Delay:       rp.Delay,
+		MaxDelay:    rp.MaxDelay,
+		Jitter:      rp.Jitter,
 	}
 }
@@ -0,0 +1,134 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrJobNotFound is returned when no job with the given ID was found on the job database
+	ErrJobNotFound = errors.New("Job not found")
+
+	// ErrJobRunning is returned when trying to change a job that is RUNNING
+	ErrJobRunning = errors.New("Job is running")
+
+	// ErrJobChanged is returned when a job keeps being changed on the job database (Ex.: it keeps running) while it is updated
+	ErrJobChanged = errors.New("Job was changed while it was updated")
+)
+
+// updateJobAttempts its how many times a job is read and changed again,
+// when it is changed on the job database while it is updated
+const updateJobAttempts = 3
+
+// Get returns the job with the given ID.
+//
+// Returns ErrJobNotFound if no job has the given ID.
+func (s *Scheduler) Get(id string) (*Job, error) {
+	ctx, cancel := s.dbContext()
+	defer cancel()
+
+	j, err := s.db.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	j.scheduler = s
+	return j, nil
+}
+
+// Reschedule sets the job with the given ID to run on the provided date time.
+//
+// If the job is not PENDING (Ex.: it has FAILED), it is set as PENDING again, with its attempts reset.
+//
+// Returns ErrJobRunning if the job is RUNNING.
+func (s *Scheduler) Reschedule(id string, t time.Time) (*Job, error) {
+	return s.updateJob(id, func(j *Job) error {
+		j.Status = PENDING
+		j.NextRunAt = t
+		j.Attempts = 0
+		return nil
+	})
+}
+
+// UpdateData replaces the extra data of the job with the given ID.
+//
+// Returns ErrJobRunning if the job is RUNNING.
+func (s *Scheduler) UpdateData(id string, data map[string]any) (*Job, error) {
+	return s.updateJob(id, func(j *Job) error {
+		j.Data = data
+		return nil
+	})
+}
+
+// ChangeSchedule changes the schedule string of the RECURRENT job with the given ID,
+// and re-schedules it to the next date of the new schedule string.
+//
+// The schedule string expects the same formats as the Every function.
+//
+// Returns ErrJobRunning if the job is RUNNING.
+func (s *Scheduler) ChangeSchedule(id string, schedule string) (*Job, error) {
+	return s.updateJob(id, func(j *Job) error {
+		if !j.IsRecurrent() {
+			return fmt.Errorf("Failed to change the schedule of job %s, the job is not RECURRENT", j.ID)
+		}
+
+		anchor := s.now()
+		t, err := s.nextScheduleDate(schedule, j.Location, anchor)
+		if err != nil {
+			return err
+		}
+
+		j.ScheduleString = schedule
+		j.ScheduleAnchor = &anchor
+		j.NextRunAt = t
+		return nil
+	})
+}
+
+// RunNow sets the job with the given ID to run as soon as possible, on the next processing cycle.
+//
+// If the job is not PENDING (Ex.: it has FAILED), it is set as PENDING again, with its attempts reset.
+//
+// Returns ErrJobRunning if the job is RUNNING.
+func (s *Scheduler) RunNow(id string) (*Job, error) {
+	return s.Reschedule(id, s.now())
+}
+
+// updateJob gets the job with the given ID, changes it with the update function, and saves it,
+// as long as the job is not RUNNING.
+//
+// The job is only saved if it was not changed on the job database since it was read (Ex.: it ran and was re-scheduled),
+// otherwise it is read and changed again, so that the values written by the run are not overwritten.
+func (s *Scheduler) updateJob(id string, update func(j *Job) error) (*Job, error) {
+	for i := 0; i < updateJobAttempts; i++ {
+		j, err := s.Get(id)
+		if err != nil {
+			return nil, err
+		}
+
+		if j.IsRunning() {
+			return nil, ErrJobRunning
+		}
+
+		old := *j
+		err = update(j)
+		if err != nil {
+			return nil, err
+		}
+
+		ctx, cancel := s.dbContext()
+		updated, err := s.db.UpdateJob(ctx, *j, old)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+
+		if updated {
+			return j, nil
+		}
+		// the job was claimed, changed or deleted after it was read, so it is read again
+	}
+
+	return nil, ErrJobChanged
+}
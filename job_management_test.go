@@ -0,0 +1,191 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	t.Run("Should return the job attached to the scheduler", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		dbMock.SetMethodResponse("GetJob", &Job{ID: "MYMOCKID"}, nil)
+
+		j, err := s.Get("MYMOCKID")
+		assert.NoError(t, err)
+		assert.Equal(t, "MYMOCKID", j.ID)
+		assert.Equal(t, s, j.scheduler)
+		assert.True(t, dbMock.Method("GetJob").CalledWith("MYMOCKID"))
+	})
+	t.Run("Should return the database error", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		dbMock.SetMethodResponse("GetJob", nil, ErrJobNotFound)
+
+		j, err := s.Get("MYMOCKID")
+		assert.ErrorIs(t, err, ErrJobNotFound)
+		assert.Nil(t, j)
+	})
+}
+
+func TestUpdateJob(t *testing.T) {
+	t.Run("Should reschedule the job as PENDING and reset its attempts", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		dbMock.SetMethodResponse("GetJob", &Job{ID: "MYMOCKID", Status: FAILED, Attempts: 3}, nil)
+		dbMock.SetMethodResponse("UpdateJob", true, nil)
+
+		nextWeek := time.Now().Add(7 * 24 * time.Hour)
+		j, err := s.Reschedule("MYMOCKID", nextWeek)
+		assert.NoError(t, err)
+		assert.True(t, j.IsPending())
+		assert.Equal(t, nextWeek, j.NextRunAt)
+		assert.Equal(t, 0, j.Attempts)
+		assert.True(t, dbMock.Method("UpdateJob").CalledWith(*j))
+	})
+	t.Run("Should set the job to run now", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		dbMock.SetMethodResponse("GetJob", &Job{ID: "MYMOCKID", Status: PENDING, NextRunAt: time.Now().Add(time.Hour)}, nil)
+		dbMock.SetMethodResponse("UpdateJob", true, nil)
+
+		j, err := s.RunNow("MYMOCKID")
+		assert.NoError(t, err)
+		assert.True(t, j.IsPending())
+		assert.WithinDuration(t, time.Now(), j.NextRunAt, time.Second)
+	})
+	t.Run("Should replace the job data", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		dbMock.SetMethodResponse("GetJob", &Job{ID: "MYMOCKID", Status: DONE, Data: map[string]any{"old": true}}, nil)
+		dbMock.SetMethodResponse("UpdateJob", true, nil)
+
+		j, err := s.UpdateData("MYMOCKID", map[string]any{"new": true})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"new": true}, j.Data)
+		assert.True(t, j.IsDone())
+	})
+	t.Run("Should change the schedule of a RECURRENT job", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		dbMock.SetMethodResponse("GetJob", &Job{ID: "MYMOCKID", Status: PENDING, ScheduleType: RECURRENT, ScheduleString: "hour"}, nil)
+		dbMock.SetMethodResponse("UpdateJob", true, nil)
+
+		j, err := s.ChangeSchedule("MYMOCKID", "@daily")
+		assert.NoError(t, err)
+		assert.Equal(t, "@daily", j.ScheduleString)
+
+		tomorrow := s.now().AddDate(0, 0, 1)
+		assert.Equal(t, time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 0, 0, 0, 0, time.UTC), j.NextRunAt)
+	})
+	t.Run("Should fail to change the schedule of a SIMPLE job", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		dbMock.SetMethodResponse("GetJob", &Job{ID: "MYMOCKID", Status: PENDING, ScheduleType: SIMPLE}, nil)
+
+		_, err := s.ChangeSchedule("MYMOCKID", "@daily")
+		assert.EqualError(t, err, "Failed to change the schedule of job MYMOCKID, the job is not RECURRENT")
+		assert.False(t, dbMock.Method("UpdateJob").Called())
+	})
+	t.Run("Should fail to change the schedule if the schedule string is invalid", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		dbMock.SetMethodResponse("GetJob", &Job{ID: "MYMOCKID", Status: PENDING, ScheduleType: RECURRENT, ScheduleString: "hour"}, nil)
+
+		_, err := s.ChangeSchedule("MYMOCKID", "5 bananas")
+		assert.Error(t, err)
+		assert.False(t, dbMock.Method("UpdateJob").Called())
+	})
+	t.Run("Should not update a RUNNING job", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		dbMock.SetMethodResponse("GetJob", &Job{ID: "MYMOCKID", Status: RUNNING}, nil)
+
+		_, err := s.Reschedule("MYMOCKID", time.Now())
+		assert.ErrorIs(t, err, ErrJobRunning)
+		assert.False(t, dbMock.Method("UpdateJob").Called())
+	})
+	t.Run("Should read and update the job again if it was ran before it was updated", func(t *testing.T) {
+		s, _, _ := mockScheduler()
+		db := &changingJobDB{MemoryJobDB: NewMemoryJobDB()}
+		s.db = db
+
+		nextRun := time.Now().Add(time.Hour)
+		id, _ := db.SaveJob(context.Background(), Job{Name: "MYMOCKJOB!", ScheduleType: RECURRENT, Status: PENDING, NextRunAt: nextRun})
+
+		// the job runs and is re-scheduled while it is updated
+		lastRun := time.Now()
+		db.change = func(stored *Job) {
+			stored.LastRunAt = &lastRun
+			stored.NextRunAt = nextRun.Add(time.Hour)
+			stored.FailureCount = 1
+		}
+
+		j, err := s.UpdateData(id, map[string]any{"new": true})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"new": true}, j.Data)
+
+		stored, _ := db.GetJob(context.Background(), id)
+		assert.Equal(t, map[string]any{"new": true}, stored.Data)
+		assert.Equal(t, nextRun.Add(time.Hour), stored.NextRunAt)
+		assert.Equal(t, lastRun, *stored.LastRunAt)
+		assert.Equal(t, 1, stored.FailureCount)
+	})
+	t.Run("Should return ErrJobRunning if the job was claimed before it was updated", func(t *testing.T) {
+		s, _, _ := mockScheduler()
+		db := &changingJobDB{MemoryJobDB: NewMemoryJobDB()}
+		s.db = db
+
+		id, _ := db.SaveJob(context.Background(), Job{Name: "MYMOCKJOB!", Status: PENDING, NextRunAt: time.Now()})
+
+		db.change = func(stored *Job) {
+			stored.Status = RUNNING
+		}
+
+		_, err := s.RunNow(id)
+		assert.ErrorIs(t, err, ErrJobRunning)
+	})
+	t.Run("Should return ErrJobChanged if the job keeps being changed while it is updated", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		dbMock.SetMethodResponse("GetJob", &Job{ID: "MYMOCKID", Status: PENDING}, nil)
+		dbMock.SetMethodResponse("UpdateJob", false, nil)
+
+		_, err := s.RunNow("MYMOCKID")
+		assert.ErrorIs(t, err, ErrJobChanged)
+		assert.True(t, dbMock.Method("UpdateJob").CalledTimes(updateJobAttempts))
+	})
+	t.Run("Should return the database error if the job fails to be updated", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		dbMock.SetMethodResponse("GetJob", &Job{ID: "MYMOCKID", Status: PENDING}, nil)
+		dbMock.SetMethodResponse("UpdateJob", false, errors.New("MOCK ERROR!"))
+
+		_, err := s.RunNow("MYMOCKID")
+		assert.EqualError(t, err, "MOCK ERROR!")
+	})
+}
+
+// changingJobDB its a memory job database that changes the stored job right before the first job update,
+// as if the job was changed by a concurrent call after it was read
+type changingJobDB struct {
+	*MemoryJobDB
+
+	change func(stored *Job)
+}
+
+func (db *changingJobDB) UpdateJob(ctx context.Context, j Job, old Job) (bool, error) {
+	if db.change != nil {
+		db.update(j.ID, func(stored *Job) bool {
+			db.change(stored)
+			return true
+		})
+		db.change = nil
+	}
+
+	return db.MemoryJobDB.UpdateJob(ctx, j, old)
+}
@@ -0,0 +1,121 @@
+package scheduler
+
+import (
+	"errors"
+	"time"
+)
+
+// JobRun represents a record of a single run of a job
+type JobRun struct {
+	// ID its the run ID in the database
+	ID string
+
+	// JobID its the ID of the job that ran
+	JobID string
+
+	// JobName its the job definition name of the job that ran
+	JobName string
+
+	// Attempt represents which attempt of the job run this was, starting at 1
+	Attempt int
+
+	// WorkerID identifies the scheduler instance that ran the job
+	WorkerID string
+
+	// StartedAt defines when the job function was called
+	StartedAt time.Time
+
+	// FinishedAt defines when the job function returned, or was abandoned
+	FinishedAt time.Time
+
+	// Outcome represents how the run ended
+	Outcome RunOutcome
+
+	// Error its the error message of the run, when it did not succeed
+	Error string
+}
+
+// Duration returns how long the run took
+func (r *JobRun) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// RunFinder its a helper struct used to pass parameters to the database ListRuns action.
+//
+// Empty values are not used to filter the runs.
+type RunFinder struct {
+	JobID   string
+	JobName string
+	Outcome RunOutcome
+
+	// StartedAfter and StartedBefore filter the runs by when they started
+	StartedAfter  time.Time
+	StartedBefore time.Time
+
+	// Limit limits how many runs are listed, the most recent runs are listed first
+	Limit int
+}
+
+// ListRuns lists the job runs recorded on the database given the run finder, the most recent runs first.
+//
+// Runs are only recorded when the run history is enabled on the library configuration.
+func (s *Scheduler) ListRuns(f RunFinder) ([]*JobRun, error) {
+	ctx, cancel := s.dbContext()
+	defer cancel()
+
+	return s.db.ListRuns(ctx, f)
+}
+
+// recordRun records the run of the job on the database, given the error returned by the job function,
+// if the run history is enabled
+func (s *Scheduler) recordRun(j *Job, attempt int, startedAt time.Time, err error) {
+	if !s.runHistory {
+		return
+	}
+
+	r := JobRun{
+		JobID:      j.ID,
+		JobName:    j.Name,
+		Attempt:    attempt,
+		WorkerID:   s.workerID,
+		StartedAt:  startedAt,
+		FinishedAt: s.now(),
+		Outcome:    RUN_SUCCEEDED,
+	}
+
+	switch {
+	case errors.Is(err, errJobTimedOut):
+		r.Outcome = RUN_TIMED_OUT
+	case errors.Is(err, errJobCanceled):
+		r.Outcome = RUN_CANCELED
+	case err != nil:
+		r.Outcome = RUN_FAILED
+	}
+
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	ctx, cancel := s.dbContext()
+	defer cancel()
+
+	err = s.db.SaveRun(ctx, r)
+	if err != nil {
+		s.logger.Errorf("Failed to record the run of job %s, %v", j.ID, err)
+	}
+}
+
+// purgeRuns deletes the recorded runs that are older than the run retention, if any retention is configured
+func (s *Scheduler) purgeRuns() {
+	if !s.runHistory || s.runRetention <= 0 {
+		return
+	}
+
+	ctx, cancel := s.dbContext()
+	defer cancel()
+
+	_, err := s.db.DeleteRunsBefore(ctx, s.now().Add(-s.runRetention))
+	if err != nil {
+		s.logger.Errorf("Failed to delete the runs older than %v, %v", s.runRetention, err)
+	}
+}
@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunHistory(t *testing.T) {
+	// runJobWithHistory processes a single job with the run history enabled, and returns the recorded run
+	runJobWithHistory := func(t *testing.T, s *Scheduler, dbMock *databaseMock, j *Job) JobRun {
+		s.runHistory = true
+		s.workerID = "my-worker"
+
+		dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{j}, nil)
+		dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+		s.process()
+		<-s.runningJobsDone()
+
+		calls := dbMock.Method("SaveRun").GetCalls()
+		assert.Len(t, calls, 1)
+		return calls[0].Args[0].(JobRun)
+	}
+
+	t.Run("Should record a successful run", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+		s.Define("MYMOCKJOB!", func(ctx context.Context, j *Job) error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		})
+
+		r := runJobWithHistory(t, s, dbMock, &Job{ID: "MYMOCKID", Name: "MYMOCKJOB!", ScheduleType: SIMPLE})
+
+		assert.Equal(t, "MYMOCKID", r.JobID)
+		assert.Equal(t, "MYMOCKJOB!", r.JobName)
+		assert.Equal(t, 1, r.Attempt)
+		assert.Equal(t, "my-worker", r.WorkerID)
+		assert.Equal(t, RUN_SUCCEEDED, r.Outcome)
+		assert.Empty(t, r.Error)
+		assert.GreaterOrEqual(t, r.Duration(), 10*time.Millisecond)
+	})
+	t.Run("Should record a failed run with its error and attempt", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+		s.Define("MYMOCKJOB!", func(ctx context.Context, j *Job) error {
+			return errors.New("MOCK ERROR!")
+		})
+
+		r := runJobWithHistory(t, s, dbMock, &Job{ID: "MYMOCKID", Name: "MYMOCKJOB!", Attempts: 2})
+
+		assert.Equal(t, 3, r.Attempt)
+		assert.Equal(t, RUN_FAILED, r.Outcome)
+		assert.Equal(t, "MOCK ERROR!", r.Error)
+	})
+	t.Run("Should record a timed out run", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+		s.Define("MYMOCKJOB!", func(ctx context.Context, j *Job) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, DefinitionOptions{Timeout: 10 * time.Millisecond})
+
+		r := runJobWithHistory(t, s, dbMock, &Job{ID: "MYMOCKID", Name: "MYMOCKJOB!"})
+
+		assert.Equal(t, RUN_TIMED_OUT, r.Outcome)
+		assert.Equal(t, errJobTimedOut.Error(), r.Error)
+	})
+	t.Run("Should record a failed run if the job has no definition", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		r := runJobWithHistory(t, s, dbMock, &Job{ID: "MYMOCKID", Name: "MYMOCKJOB!"})
+
+		assert.Equal(t, RUN_FAILED, r.Outcome)
+		assert.Equal(t, "No job definition with the name MYMOCKJOB! was found", r.Error)
+	})
+	t.Run("Should log an error if the run fails to be recorded", func(t *testing.T) {
+		s, dbMock, loggerMock := mockScheduler()
+		s.Define("MYMOCKJOB!", func(ctx context.Context, j *Job) error { return nil })
+
+		dbMock.SetMethodResponse("SaveRun", errors.New("MOCK ERROR!"))
+		runJobWithHistory(t, s, dbMock, &Job{ID: "MYMOCKID", Name: "MYMOCKJOB!", ScheduleType: SIMPLE})
+
+		assert.True(t, loggerMock.Method("Errorf").CalledWith("Failed to record the run of job %s, %v"))
+	})
+	t.Run("Should not record runs if the run history is disabled", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+		s.Define("MYMOCKJOB!", func(ctx context.Context, j *Job) error { return nil })
+
+		dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{{Name: "MYMOCKJOB!", ScheduleType: SIMPLE}}, nil)
+		dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+		s.process()
+		<-s.runningJobsDone()
+
+		assert.False(t, dbMock.Method("SaveRun").Called())
+	})
+}
+
+func TestPurgeRuns(t *testing.T) {
+	t.Run("Should delete the runs older than the retention", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+		s.runHistory = true
+		s.runRetention = 24 * time.Hour
+
+		s.purgeRuns()
+
+		calls := dbMock.Method("DeleteRunsBefore").GetCalls()
+		assert.Len(t, calls, 1)
+		assert.WithinDuration(t, time.Now().Add(-24*time.Hour), calls[0].Args[0].(time.Time), time.Second)
+	})
+	t.Run("Should not delete runs if there is no retention", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+		s.runHistory = true
+
+		s.purgeRuns()
+
+		assert.False(t, dbMock.Method("DeleteRunsBefore").Called())
+	})
+}
+
+func TestListRuns(t *testing.T) {
+	t.Run("Should list the runs given the run finder", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		runs := []*JobRun{{ID: "MYMOCKRUN", JobID: "MYMOCKID"}}
+		dbMock.SetMethodResponse("ListRuns", runs, nil)
+
+		f := RunFinder{JobID: "MYMOCKID", Outcome: RUN_FAILED, Limit: 10}
+		result, err := s.ListRuns(f)
+
+		assert.NoError(t, err)
+		assert.Equal(t, runs, result)
+		assert.True(t, dbMock.Method("ListRuns").CalledWith(f))
+	})
+}
@@ -1,4 +1,15 @@
 package scheduler
 
-// JobFunc represents a function that can handle jobs
-type JobFunc func(*Job) (err error)
+import "context"
+
+// JobFunc represents a function that can handle jobs.
+//
+// The provided context is cancelled when the job should stop running (Ex.: when the scheduler shuts down).
+type JobFunc func(ctx context.Context, j *Job) (err error)
+
+// WithoutContext adapts a job function that does not receive a context into a JobFunc.
+func WithoutContext(fn func(*Job) error) JobFunc {
+	return func(ctx context.Context, j *Job) error {
+		return fn(j)
+	}
+}
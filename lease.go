@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+)
+
+// startHeartbeat periodically renews the lease of the claimed job while it runs.
+//
+// If the lease is lost, the lost function is called, so that the job can be cancelled.
+//
+// Returns a function that stops the heartbeat, and that should be called as soon as the job finishes running.
+func (s *Scheduler) startHeartbeat(j Job, lost func()) (stop func()) {
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		ticker := s.clock.NewTicker(s.leaseDuration / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C():
+				leaseExpiresAt := s.now().Add(s.leaseDuration)
+				j.LeaseExpiresAt = &leaseExpiresAt
+
+				renewed, err := s.renewLease(j)
+				if err != nil {
+					s.logger.Errorf("Failed to renew the lease of job %s, %v", j.ID, err)
+					continue
+				}
+				if !renewed {
+					s.logger.Errorf("Lost the lease of job %s, it might be ran by another instance", j.ID)
+					lost()
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// renewLease renews the job lease on the database
+func (s *Scheduler) renewLease(j Job) (bool, error) {
+	ctx, cancel := s.dbContext()
+	defer cancel()
+
+	return s.db.RenewLease(ctx, j)
+}
+
+// recoverOrphanedJobs recovers the RUNNING jobs which lease has expired,
+// setting them back as PENDING so they can run again, or as FAILED if they were orphaned too many times.
+func (s *Scheduler) recoverOrphanedJobs() {
+	ctx, cancel := s.dbContext()
+	jobs, err := s.db.ListExpiredLeases(ctx)
+	cancel()
+	if err != nil {
+		s.logger.Errorf("Failed to list jobs with expired leases, %v", err)
+		return
+	}
+	s.attach(jobs)
+
+	for _, j := range jobs {
+		s.logger.Errorf("Job %s was orphaned by worker %s while running", j.ID, j.Owner)
+
+		j.OrphanCount++
+		j.setFailure(fmt.Errorf("Job was orphaned by worker %s while running", j.Owner), s.now())
+		j.release()
+		j.Status = PENDING
+		if s.maxOrphanings >= 0 && j.OrphanCount >= s.maxOrphanings {
+			j.Status = FAILED
+		}
+
+		ctx, cancel := s.dbContext()
+		_, err = s.db.RecoverJob(ctx, *j)
+		cancel()
+		if err != nil {
+			s.logger.Errorf("Failed to recover orphaned job %s, %v", j.ID, err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverOrphanedJobs(t *testing.T) {
+	t.Run("Should log an error if the database fails to list expired leases", func(t *testing.T) {
+		s, dbMock, loggerMock := mockScheduler()
+
+		mockErr := errors.New("mock!!")
+		dbMock.SetMethodResponse("ListExpiredLeases", []*Job{}, mockErr)
+
+		s.recoverOrphanedJobs()
+
+		assert.True(t, dbMock.CalledOnce())
+		assert.True(t, loggerMock.Method("Errorf").CalledWith("Failed to list jobs with expired leases, %v"))
+	})
+	t.Run("Should set the orphaned job back as PENDING", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		lea := time.Now().Add(-time.Minute)
+		mockJob := Job{
+			Status:         RUNNING,
+			Owner:          "a dead worker",
+			LeaseExpiresAt: &lea,
+		}
+
+		dbMock.SetMethodResponse("ListExpiredLeases", []*Job{&mockJob}, nil)
+		dbMock.SetMethodResponse("RecoverJob", true, nil)
+
+		s.recoverOrphanedJobs()
+
+		assert.True(t, dbMock.Method("RecoverJob").CalledOnce())
+		assert.True(t, dbMock.Method("RecoverJob").CalledWith(mockJob))
+		assert.True(t, mockJob.IsPending())
+		assert.Equal(t, 1, mockJob.OrphanCount)
+		assert.Empty(t, mockJob.Owner)
+		assert.Nil(t, mockJob.LeaseExpiresAt)
+	})
+	t.Run("Should fail the orphaned job if it was orphaned too many times", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		lea := time.Now().Add(-time.Minute)
+		mockJob := Job{
+			Status:         RUNNING,
+			Owner:          "a dead worker",
+			LeaseExpiresAt: &lea,
+			OrphanCount:    s.maxOrphanings - 1,
+		}
+
+		dbMock.SetMethodResponse("ListExpiredLeases", []*Job{&mockJob}, nil)
+		dbMock.SetMethodResponse("RecoverJob", true, nil)
+
+		s.recoverOrphanedJobs()
+
+		assert.True(t, dbMock.Method("RecoverJob").CalledWith(mockJob))
+		assert.True(t, mockJob.HasFailed())
+		assert.Equal(t, s.maxOrphanings, mockJob.OrphanCount)
+		assert.Equal(t, "Job was orphaned by worker a dead worker while running", mockJob.LastError)
+		assert.NotNil(t, mockJob.FailedAt)
+	})
+	t.Run("Should log an error if the database fails to recover the job", func(t *testing.T) {
+		s, dbMock, loggerMock := mockScheduler()
+
+		mockJob := Job{
+			Status: RUNNING,
+		}
+
+		mockErr := errors.New("mock!!")
+		dbMock.SetMethodResponse("ListExpiredLeases", []*Job{&mockJob}, nil)
+		dbMock.SetMethodResponse("RecoverJob", false, mockErr)
+
+		s.recoverOrphanedJobs()
+
+		assert.True(t, loggerMock.Method("Errorf").CalledWith("Failed to recover orphaned job %s, %v"))
+	})
+}
+
+func TestStartHeartbeat(t *testing.T) {
+	t.Run("Should renew the job lease while the job runs", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		s.leaseDuration = 30 * time.Millisecond
+
+		dbMock.SetMethodResponse("RenewLease", true, nil)
+
+		stop := s.startHeartbeat(Job{ID: "mock id", Status: RUNNING, Owner: s.workerID}, func() {})
+		time.Sleep(50 * time.Millisecond)
+		stop()
+
+		assert.True(t, dbMock.Method("RenewLease").Called())
+	})
+	t.Run("Should stop renewing the lease and cancel the job if the job is not owned by the instance anymore", func(t *testing.T) {
+		s, dbMock, loggerMock := mockScheduler()
+
+		s.leaseDuration = 15 * time.Millisecond
+
+		dbMock.SetMethodResponse("RenewLease", false, nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		stop := s.startHeartbeat(Job{ID: "mock id", Status: RUNNING, Owner: s.workerID}, cancel)
+		time.Sleep(50 * time.Millisecond)
+		stop()
+
+		assert.True(t, dbMock.Method("RenewLease").CalledOnce())
+		assert.True(t, loggerMock.Method("Errorf").CalledWith("Lost the lease of job %s, it might be ran by another instance"))
+		assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	})
+}
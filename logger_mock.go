@@ -1,21 +1,34 @@
 package scheduler
 
-import "github.com/delivery-much/mock-helper/mock"
+import (
+	"sync"
+
+	"github.com/delivery-much/mock-helper/mock"
+)
 
 type loggerMock struct {
 	mock.Mock
+
+	// mu guards the mock, since it can be called by concurrently running jobs
+	mu sync.Mutex
 }
 
 func newLoggerMock() *loggerMock {
 	return &loggerMock{
-		mock.NewMock(),
+		Mock: mock.NewMock(),
 	}
 }
 
 func (lm *loggerMock) Error(message string) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
 	lm.RegisterMethodCall("Error", message)
 }
 
 func (lm *loggerMock) Errorf(format string, a ...any) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
 	lm.RegisterMethodCall("Errorf", format, a)
 }
@@ -1,85 +1,450 @@
 package scheduler
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"time"
 )
 
-func processJobs(rate time.Duration) {
-	defer func() {
-		if r := recover(); r != nil {
-			logger.Errorf("A panic occurred while processing jobs: %v", r)
+var (
+	// errJobTimedOut is returned when the job function does not return before the job timeout
+	errJobTimedOut = errors.New("job timed out")
+
+	// errJobCanceled is returned when the job is canceled before the job function returns
+	errJobCanceled = errors.New("job canceled")
+)
+
+// processJobs processes jobs on the provided rate, until the stop channel is closed
+func (s *Scheduler) processJobs(rate time.Duration, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := s.clock.NewTicker(rate)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C():
+			s.processCycle()
 		}
+	}
+}
 
-		processJobs(rate)
-	}()
+// ProcessCycle runs a processing cycle right away, and waits for the jobs that are running on the scheduler to finish,
+// or until the context is done.
+//
+// The jobs that were left for a next cycle because of their job definition concurrency limit
+// are run on new cycles, once the running jobs finish, so that every job that was due has run when it returns.
+//
+// It returns an error, without processing any job, if the scheduler was already shut down.
+//
+// Along with a fake clock (See Config.Clock), it allows the schedules to be tested deterministically,
+// without waiting for the processing rate. Ex.:
+//
+//	clock.Advance(24 * time.Hour)
+//	err := s.ProcessCycle(ctx)
+//	// every job that was due in the next 24 hours has run
+func (s *Scheduler) ProcessCycle(ctx context.Context) error {
+	if s.isStopping() {
+		return errors.New("Failed to process jobs, the scheduler was shut down")
+	}
 
 	for {
-		time.Sleep(rate)
+		skipped := s.processCycle()
+
+		select {
+		case <-s.runningJobsDone():
+		case <-ctx.Done():
+			return fmt.Errorf("Failed to wait for the running jobs, %v", ctx.Err())
+		}
 
-		process()
+		if !skipped || s.isStopping() {
+			return nil
+		}
 	}
 }
 
-func process() {
-	jobs, err := db.ListExpiredSchedules()
+// processCycle recovers orphaned jobs and processes the expired schedules,
+// recovering from any panic that occurs while doing so.
+//
+// Returns true if any expired schedule was left for a next cycle.
+func (s *Scheduler) processCycle() (skipped bool) {
+	s.cycleMu.Lock()
+	defer s.cycleMu.Unlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Errorf("A panic occurred while processing jobs: %v", r)
+		}
+	}()
+
+	s.recoverOrphanedJobs()
+	s.purgeRuns()
+	return s.process()
+}
+
+// process lists the expired schedules and dispatches them to run on the worker pool.
+//
+// When every worker is busy, it waits for a free worker to dispatch the next job,
+// but it does not wait for the dispatched jobs to finish running.
+//
+// Returns true if any expired schedule was left for a next cycle, because of its job definition concurrency limit.
+func (s *Scheduler) process() (skipped bool) {
+	ctx, cancel := s.dbContext()
+	jobs, err := s.db.ListExpiredSchedules(ctx)
+	cancel()
 	if err != nil {
-		logger.Errorf("Failed to list expired schedules, %v", err)
-		return
+		s.logger.Errorf("Failed to list expired schedules, %v", err)
+		return false
 	}
+	s.attach(jobs)
+
+	s.lifecycleMu.Lock()
+	stopping := s.stopping
+	s.lifecycleMu.Unlock()
 
 	for _, j := range jobs {
-		jobFunc := jobDefinitions[j.Name]
-		if jobFunc == nil {
-			logger.Errorf("Job %s was scheduled but no job definition with the name %s was found", j.ID, j.Name)
-			failJob(j)
-			continue
+		if s.isStopping() {
+			// the scheduler is shutting down, no new jobs should be picked
+			return false
 		}
 
-		err := (*jobFunc)(j)
-		if err != nil {
-			logger.Errorf("Job %s failed, %v", j.ID, err)
-			failJob(j)
+		jd := s.getJobDefinition(j.Name)
+		if jd != nil && !jd.acquire() {
+			// the job definition concurrency limit was reached, the job will be picked on the next cycle
+			skipped = true
 			continue
 		}
 
-		now := now()
-		j.LastRunAt = &now
-		if j.IsSimple() ||
-			(j.ScheduleLimitDate != nil && j.ScheduleLimitDate.Before(now)) {
-			err = j.Done()
-			if err != nil {
-				logger.Errorf("Failed to save job %s after it was done processing, %v", j.ID, err)
+		select {
+		case s.workers <- struct{}{}:
+		case <-stopping:
+			// the scheduler is shutting down while every worker is busy, no new jobs should be picked
+			if jd != nil {
+				jd.release()
 			}
-			continue
+			return false
 		}
 
-		if j.ScheduleString == "" {
-			logger.Errorf("Tried to re-schedule recurrent job %s, but it had no ScheduleString", j.ID)
-			failJob(j)
-			continue
-		}
+		claimed, err := s.claimJob(j)
+		if err != nil || !claimed {
+			if err != nil {
+				s.logger.Errorf("Failed to claim job %s, %v", j.ID, err)
+			}
+			// if not claimed, another scheduler instance is already running this job
 
-		nra, err := getNextScheduleDate(j.ScheduleString)
-		if err != nil {
-			logger.Errorf("Failed to get next schedule date for job %s, %v", j.ID, err)
-			failJob(j)
+			<-s.workers
+			if jd != nil {
+				jd.release()
+			}
 			continue
 		}
 
-		j.Status = PENDING
-		j.NextRunAt = nra
-		err = db.SaveJob(*j)
-		if err != nil {
-			logger.Errorf("Failed to save job %s on the database to be re-scheduled, %v", j.ID, err)
-			failJob(j)
-			continue
-		}
+		s.trackRunningJob(j)
+		go func(j *Job, jd *jobDefinition) {
+			defer func() {
+				<-s.workers
+				if jd != nil {
+					jd.release()
+				}
+				s.untrackRunningJob(j)
+			}()
+
+			s.runJob(j, jd)
+		}(j, jd)
+	}
+
+	return skipped
+}
+
+// runJob runs a claimed job, and saves it according to the job function result
+func (s *Scheduler) runJob(j *Job, jd *jobDefinition) {
+	if jd == nil {
+		s.logger.Errorf("Job %s was scheduled but no job definition with the name %s was found", j.ID, j.Name)
+		err := fmt.Errorf("No job definition with the name %s was found", j.Name)
+		s.recordRun(j, j.Attempts+1, s.now(), err)
+		s.failJob(j, err)
+		return
+	}
+
+	if j.IsRecurrent() && j.ScheduleAnchor == nil && j.Attempts == 0 && !j.NextRunAt.IsZero() {
+		// the jobs saved before the schedule anchor existed are anchored on the first run that is claimed on schedule (and not on a retry),
+		// so that the anchor is saved along with the run result
+		anchor := j.NextRunAt
+		j.ScheduleAnchor = &anchor
+	}
+
+	ctx, cancel := context.WithCancel(s.jobsContext())
+	defer cancel()
+
+	timeout := s.jobTimeout(j, jd)
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	attempt := j.Attempts + 1
+	startedAt := s.now()
+	stopHeartbeat := s.startHeartbeat(*j, cancel)
+	returned, err := callJobFunc(ctx, jd.fn, j, s.jobGracePeriod)
+	stopHeartbeat()
+
+	select {
+	case <-returned:
+	default:
+		// the job function ignores its context, so it is abandoned: its worker and job definition slots are freed,
+		// so that it does not stop the processing, and it is counted apart from the running jobs until it returns
+		abandoned := s.abandonJobFunc(returned)
+		s.logger.Errorf("Job %s function did not return after its context was done and was abandoned, %d abandoned job functions are still running", j.ID, abandoned)
+	}
+
+	s.recordRun(j, attempt, startedAt, err)
+	switch {
+	case errors.Is(err, errJobTimedOut):
+		s.logger.Errorf("Job %s timed out after %v", j.ID, timeout)
+		s.retryOrTimeOutJob(j, err)
+		return
+
+	case errors.Is(err, errJobCanceled):
+		// the job was released on shutdown, or its lease was lost to another instance,
+		// so it should not be saved by this instance
+		s.logger.Errorf("Job %s was canceled while running", j.ID)
+		return
+
+	case err != nil:
+		s.logger.Errorf("Job %s failed, %v", j.ID, err)
+		s.retryOrFailJob(j, err)
+		return
+	}
+
+	now := s.now()
+	j.LastRunAt = &now
+	j.Attempts = 0
+	if j.IsSimple() ||
+		(j.ScheduleLimitDate != nil && j.ScheduleLimitDate.Before(now)) {
+		s.doneJob(j)
+		return
+	}
+
+	if j.ScheduleString == "" {
+		s.logger.Errorf("Tried to re-schedule recurrent job %s, but it had no ScheduleString", j.ID)
+		s.failJob(j, errors.New("Tried to re-schedule the recurrent job, but it had no ScheduleString"))
+		return
+	}
+
+	if j.ScheduleAnchor == nil {
+		// a job saved before the schedule anchor existed, that was first claimed on a retry, is anchored on this run,
+		// since its NextRunAt was already moved by the retry delay
+		anchor := now
+		j.ScheduleAnchor = &anchor
+	}
+
+	nra, err := s.nextScheduleDate(j.ScheduleString, j.Location, *j.ScheduleAnchor)
+	if err != nil {
+		s.logger.Errorf("Failed to get next schedule date for job %s, %v", j.ID, err)
+		s.failJob(j, fmt.Errorf("Failed to get next schedule date, %v", err))
+		return
+	}
+
+	j.Status = PENDING
+	j.release()
+	j.NextRunAt = nra
+	_, err = s.finishJob(j)
+	if err != nil {
+		s.logger.Errorf("Failed to save job %s on the database to be re-scheduled, %v", j.ID, err)
+		s.failJob(j, fmt.Errorf("Failed to save job on the database to be re-scheduled, %v", err))
+	}
+}
+
+// callJobFunc calls the job function, recovering from any panic that occurs inside it as an error.
+//
+// The job function runs on a copy of the job, that is only applied to the job if the function returns before the context is done.
+// If the context is done first, the job function is waited for during the grace period,
+// and then errJobTimedOut or errJobCanceled is returned according to the context error.
+//
+// The returned channel is closed when the job function actually returns, even if that happens after the grace period.
+func callJobFunc(ctx context.Context, fn JobFunc, j *Job, grace time.Duration) (returned <-chan struct{}, err error) {
+	jc := *j
+	jc.Data = copyData(j.Data)
+	result := make(chan error, 1)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		defer func() {
+			if r := recover(); r != nil {
+				result <- fmt.Errorf("A panic occurred while running the job: %v", r)
+			}
+		}()
+
+		result <- fn(ctx, &jc)
+	}()
+
+	select {
+	case err = <-result:
+		<-done
+		*j = jc
+		return done, err
+	case <-ctx.Done():
 	}
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return done, errJobTimedOut
+	}
+
+	return done, errJobCanceled
 }
 
-func failJob(j *Job) {
-	err := j.Fail()
+// jobTimeout returns the timeout of the job run,
+// given by the job itself, its definition, or the scheduler configuration, in that order
+func (s *Scheduler) jobTimeout(j *Job, jd *jobDefinition) time.Duration {
+	switch {
+	case j.Timeout > 0:
+		return j.Timeout
+	case jd.timeout > 0:
+		return jd.timeout
+	default:
+		return s.defaultJobTimeout
+	}
+}
+
+// claimJob tries to claim the job to be ran by this scheduler instance.
+//
+// Returns true if the job was claimed, or false if it was already claimed by another instance.
+func (s *Scheduler) claimJob(j *Job) (bool, error) {
+	leaseExpiresAt := s.now().Add(s.leaseDuration)
+
+	j.Status = RUNNING
+	j.Owner = s.workerID
+	j.LeaseExpiresAt = &leaseExpiresAt
+
+	ctx, cancel := s.dbContext()
+	defer cancel()
+
+	return s.db.ClaimJob(ctx, *j)
+}
+
+// retryOrFailJob records the failure on the job, and re-schedules it to be retried according to its retry policy,
+// or fails it if the retries are exhausted.
+func (s *Scheduler) retryOrFailJob(j *Job, err error) {
+	j.setFailure(err, s.now())
+	if s.retryJob(j) {
+		return
+	}
+
+	s.saveFailedJob(j)
+}
+
+// retryOrTimeOutJob records the failure on the job, and re-schedules it to be retried according to its retry policy,
+// or sets it as TIMED_OUT if the retries are exhausted.
+func (s *Scheduler) retryOrTimeOutJob(j *Job, err error) {
+	j.setFailure(err, s.now())
+	if s.retryJob(j) {
+		return
+	}
+
+	s.timeOutJob(j)
+}
+
+// retryJob re-schedules the job to be retried according to its retry policy.
+//
+// Returns false if the job could not be re-scheduled.
+func (s *Scheduler) retryJob(j *Job) bool {
+	j.Attempts++
+
+	rp := j.RetryPolicy
+	if rp == nil {
+		rp = s.retryPolicy
+	}
+
+	if !rp.shouldRetry(j.Attempts) {
+		return false
+	}
+
+	j.Status = PENDING
+	j.release()
+	j.NextRunAt = s.now().Add(rp.nextDelay(j.Attempts))
+	_, err := s.finishJob(j)
+	if err != nil {
+		s.logger.Errorf("Failed to save job %s on the database to be retried, %v", j.ID, err)
+		return false
+	}
+
+	return true
+}
+
+func (s *Scheduler) timeOutJob(j *Job) {
+	j.Status = TIMED_OUT
+	j.release()
+
+	_, err := s.finishJob(j)
+	if err != nil {
+		s.logger.Errorf("Failed to save job %s after it timed out, %v", j.ID, err)
+	}
+}
+
+// failJob records the failure on the job, and sets it as FAILED
+func (s *Scheduler) failJob(j *Job, err error) {
+	j.setFailure(err, s.now())
+	s.saveFailedJob(j)
+}
+
+func (s *Scheduler) saveFailedJob(j *Job) {
+	j.Status = FAILED
+	j.release()
+
+	_, err := s.finishJob(j)
 	if err != nil {
-		logger.Errorf("Failed to save job %s after it failed, %v", j.ID, err)
+		s.logger.Errorf("Failed to save job %s after it failed, %v", j.ID, err)
 	}
 }
+
+// doneJob sets the job as DONE, deleting it instead if the scheduler is configured to delete the done jobs
+func (s *Scheduler) doneJob(j *Job) {
+	j.Status = DONE
+	j.release()
+
+	finished, err := s.finishJob(j)
+	if err != nil {
+		s.logger.Errorf("Failed to save job %s after it was done processing, %v", j.ID, err)
+		return
+	}
+	if !finished || !s.deleteOnDone {
+		return
+	}
+
+	ctx, cancel := s.dbContext()
+	defer cancel()
+
+	err = s.db.DeleteJob(ctx, *j)
+	if err != nil {
+		s.logger.Errorf("Failed to delete job %s after it was done processing, %v", j.ID, err)
+	}
+}
+
+// finishJob saves the job when its run is over, but only if the job is still owned by this scheduler instance,
+// so that a run that lost the job (Ex.: its lease expired and another instance recovered it)
+// does not overwrite the state saved by the new owner.
+//
+// Returns false, dropping the write, if the job is not owned by this scheduler instance anymore.
+func (s *Scheduler) finishJob(j *Job) (bool, error) {
+	ctx, cancel := s.dbContext()
+	defer cancel()
+
+	finished, err := s.db.FinishJob(ctx, *j, s.workerID)
+	if err == nil && !finished {
+		s.logger.Errorf("Job %s is not owned by this scheduler instance anymore, the result of its run was dropped", j.ID)
+	}
+
+	return finished, err
+}
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -8,16 +9,18 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-// mockDependencies mocks the database and logger library dependencies and returns a pointer to the mocks
-func mockDependencies() (
+// mockScheduler creates a scheduler with mocked database and logger dependencies and returns a pointer to the scheduler and the mocks
+func mockScheduler() (
+	s *Scheduler,
 	dbMock *databaseMock,
 	logMock *loggerMock,
 ) {
 	dbMock = newDatabaseMock()
 	logMock = newLoggerMock()
 
-	db = dbMock
-	logger = logMock
+	s = newScheduler()
+	s.db = dbMock
+	s.logger = logMock
 
 	return
 }
@@ -25,51 +28,146 @@ func mockDependencies() (
 func TestProcessJobs(t *testing.T) {
 	t.Run("When the database fails", func(t *testing.T) {
 		t.Run("Should log an error if the database fails to list jobs", func(t *testing.T) {
-			dbMock, loggerMock := mockDependencies()
+			s, dbMock, loggerMock := mockScheduler()
 
 			mockErr := errors.New("mock!!")
 			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{}, mockErr)
 
-			process()
+			s.process()
+			<-s.runningJobsDone()
 
 			assert.True(t, dbMock.CalledOnce())
 			assert.True(t, loggerMock.Method("Errorf").CalledWith("Failed to list expired schedules, %v"))
 		})
 		t.Run("Should do nothing if there are no expired jobs", func(t *testing.T) {
-			dbMock, loggerMock := mockDependencies()
+			s, dbMock, loggerMock := mockScheduler()
 
 			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{}, nil)
 
-			process()
+			s.process()
+			<-s.runningJobsDone()
 
 			assert.True(t, dbMock.CalledOnce())
 			assert.False(t, loggerMock.Called())
 		})
 	})
+	t.Run("When claiming the job", func(t *testing.T) {
+		t.Run("Should log an error and not run the job if the database fails to claim it", func(t *testing.T) {
+			s, dbMock, loggerMock := mockScheduler()
+
+			mockJobName := "MYMOCKJOB!"
+			ran := false
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				ran = true
+				return nil
+			}
+
+			mockJob := Job{
+				Name:   mockJobName,
+				Status: PENDING,
+			}
+
+			s.Define(mockJobName, mockJobFunc)
+
+			mockErr := errors.New("mock!!")
+			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", false, mockErr)
+
+			s.process()
+			<-s.runningJobsDone()
+
+			assert.True(t, dbMock.Method("ClaimJob").CalledOnce())
+			assert.False(t, dbMock.Method("FinishJob").Called())
+			assert.False(t, ran)
+			assert.True(t, loggerMock.Method("Errorf").CalledWith("Failed to claim job %s, %v"))
+		})
+		t.Run("Should skip the job if it was claimed by another instance", func(t *testing.T) {
+			s, dbMock, loggerMock := mockScheduler()
+
+			mockJobName := "MYMOCKJOB!"
+			ran := false
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				ran = true
+				return nil
+			}
+
+			mockJob := Job{
+				Name:   mockJobName,
+				Status: PENDING,
+			}
+
+			s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", false, nil)
+
+			s.process()
+			<-s.runningJobsDone()
+
+			assert.True(t, dbMock.Method("ClaimJob").CalledOnce())
+			assert.False(t, dbMock.Method("FinishJob").Called())
+			assert.False(t, ran)
+			assert.False(t, loggerMock.Called())
+		})
+		t.Run("Should claim the job as RUNNING with the worker ID before running it", func(t *testing.T) {
+			s, dbMock, _ := mockScheduler()
+
+			mockJobName := "MYMOCKJOB!"
+			var runningJob Job
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				runningJob = *j
+				return nil
+			}
+
+			mockJob := Job{
+				Name:         mockJobName,
+				Status:       PENDING,
+				ScheduleType: SIMPLE,
+			}
+
+			s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+			s.process()
+			<-s.runningJobsDone()
+
+			assert.True(t, dbMock.Method("ClaimJob").CalledWith(runningJob))
+			assert.True(t, runningJob.IsRunning())
+			assert.Equal(t, s.workerID, runningJob.Owner)
+			assert.True(t, mockJob.IsDone())
+			assert.Empty(t, mockJob.Owner)
+		})
+	})
 	t.Run("When the job fails", func(t *testing.T) {
 		t.Run("Should log an error and fail the job if the job has no definition", func(t *testing.T) {
-			dbMock, loggerMock := mockDependencies()
+			s, dbMock, loggerMock := mockScheduler()
 
 			mockJob := Job{
 				Name: "a name that was not defined",
 			}
 
 			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
 
-			process()
+			s.process()
+			<-s.runningJobsDone()
 
 			assert.True(t, dbMock.Method("ListExpiredSchedules").CalledOnce())
-			assert.True(t, dbMock.Method("SaveJob").CalledOnce())
-			assert.True(t, dbMock.Method("SaveJob").CalledWith(mockJob))
+			assert.True(t, dbMock.Method("FinishJob").CalledOnce())
+			assert.True(t, dbMock.Method("FinishJob").CalledWith(mockJob))
 			assert.True(t, mockJob.HasFailed())
+			assert.Equal(t, "No job definition with the name a name that was not defined was found", mockJob.LastError)
+			assert.Equal(t, 1, mockJob.FailureCount)
 			assert.True(t, loggerMock.Method("Errorf").CalledWith("Job %s was scheduled but no job definition with the name %s was found"))
 		})
 		t.Run("Should log an error and fail the job if the job function returns an error", func(t *testing.T) {
-			dbMock, loggerMock := mockDependencies()
+			s, dbMock, loggerMock := mockScheduler()
 
 			mockJobName := "MYMOCKJOB!"
 			mockErr := errors.New("MOCK ERROR")
-			mockJobFunc := func(j *Job) error {
+			mockJobFunc := func(ctx context.Context, j *Job) error {
 				return mockErr
 			}
 
@@ -77,25 +175,325 @@ func TestProcessJobs(t *testing.T) {
 				Name: mockJobName,
 			}
 
-			Define(mockJobName, mockJobFunc)
+			s.Define(mockJobName, mockJobFunc)
 
 			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
 
-			process()
+			s.process()
+			<-s.runningJobsDone()
 
 			assert.True(t, dbMock.Method("ListExpiredSchedules").CalledOnce())
-			assert.True(t, dbMock.Method("SaveJob").CalledOnce())
-			assert.True(t, dbMock.Method("SaveJob").CalledWith(mockJob))
+			assert.True(t, dbMock.Method("FinishJob").CalledOnce())
+			assert.True(t, dbMock.Method("FinishJob").CalledWith(mockJob))
 			assert.True(t, mockJob.HasFailed())
+			assert.Equal(t, "MOCK ERROR", mockJob.LastError)
+			assert.WithinDuration(t, time.Now(), *mockJob.FailedAt, time.Second)
+			assert.Equal(t, 1, mockJob.FailureCount)
+			assert.True(t, loggerMock.Method("Errorf").CalledWith("Job %s failed, %v"))
+		})
+	})
+	t.Run("When the job fails and has a retry policy", func(t *testing.T) {
+		t.Run("Should re-schedule the job as PENDING if there are attempts left", func(t *testing.T) {
+			s, dbMock, loggerMock := mockScheduler()
+
+			mockJobName := "MYMOCKJOB!"
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				return errors.New("MOCK ERROR")
+			}
+
+			mockJob := Job{
+				Name:         mockJobName,
+				ScheduleType: SIMPLE,
+				RetryPolicy:  &RetryPolicy{MaxAttempts: 3, Delay: time.Hour},
+			}
+
+			s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+			s.process()
+			<-s.runningJobsDone()
+
+			assert.True(t, dbMock.Method("FinishJob").CalledOnce())
+			assert.True(t, dbMock.Method("FinishJob").CalledWith(mockJob))
+			assert.True(t, mockJob.IsPending())
+			assert.Equal(t, 1, mockJob.Attempts)
+			assert.Equal(t, 1, mockJob.FailureCount)
+			assert.Equal(t, "MOCK ERROR", mockJob.LastError)
+			assert.Equal(t, s.now().Add(time.Hour).Round(time.Second), mockJob.NextRunAt.Round(time.Second))
 			assert.True(t, loggerMock.Method("Errorf").CalledWith("Job %s failed, %v"))
 		})
+		t.Run("Should not fail the job if it is not owned by this scheduler instance anymore when it is re-scheduled", func(t *testing.T) {
+			s, dbMock, loggerMock := mockScheduler()
+
+			mockJobName := "MYMOCKJOB!"
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				return errors.New("MOCK ERROR")
+			}
+
+			mockJob := Job{
+				Name:         mockJobName,
+				ScheduleType: SIMPLE,
+				RetryPolicy:  &RetryPolicy{MaxAttempts: 3, Delay: time.Hour},
+			}
+
+			s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+			dbMock.SetMethodResponse("FinishJob", false, nil)
+
+			s.process()
+			<-s.runningJobsDone()
+
+			assert.True(t, dbMock.Method("FinishJob").CalledOnce())
+			assert.True(t, mockJob.IsPending())
+			assert.True(t, loggerMock.Method("Errorf").CalledWith("Job %s is not owned by this scheduler instance anymore, the result of its run was dropped"))
+		})
+		t.Run("Should fail the job if the attempts are exhausted", func(t *testing.T) {
+			s, dbMock, _ := mockScheduler()
+
+			mockJobName := "MYMOCKJOB!"
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				return errors.New("MOCK ERROR")
+			}
+
+			mockJob := Job{
+				Name:         mockJobName,
+				ScheduleType: SIMPLE,
+				RetryPolicy:  &RetryPolicy{MaxAttempts: 3},
+				Attempts:     2,
+				FailureCount: 2,
+			}
+
+			s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+			s.process()
+			<-s.runningJobsDone()
+
+			assert.True(t, dbMock.Method("FinishJob").CalledOnce())
+			assert.True(t, dbMock.Method("FinishJob").CalledWith(mockJob))
+			assert.True(t, mockJob.HasFailed())
+			assert.Equal(t, 3, mockJob.Attempts)
+			assert.Equal(t, 3, mockJob.FailureCount)
+		})
+		t.Run("Should use the library retry policy if the job has no retry policy", func(t *testing.T) {
+			s, dbMock, _ := mockScheduler()
+
+			s.retryPolicy = &RetryPolicy{MaxAttempts: 2}
+
+			mockJobName := "MYMOCKJOB!"
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				return errors.New("MOCK ERROR")
+			}
+
+			mockJob := Job{
+				Name:         mockJobName,
+				ScheduleType: SIMPLE,
+			}
+
+			s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+			s.process()
+			<-s.runningJobsDone()
+
+			assert.True(t, mockJob.IsPending())
+			assert.Equal(t, 1, mockJob.Attempts)
+		})
+		t.Run("Should reset the attempts when the job succeeds", func(t *testing.T) {
+			s, dbMock, _ := mockScheduler()
+
+			mockJobName := "MYMOCKJOB!"
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				return nil
+			}
+
+			mockJob := Job{
+				Name:         mockJobName,
+				ScheduleType: SIMPLE,
+				RetryPolicy:  &RetryPolicy{MaxAttempts: 3},
+				Attempts:     2,
+				LastError:    "MOCK ERROR",
+				FailureCount: 2,
+			}
+
+			s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+			s.process()
+			<-s.runningJobsDone()
+
+			assert.True(t, mockJob.IsDone())
+			assert.Equal(t, 0, mockJob.Attempts)
+			assert.Equal(t, "MOCK ERROR", mockJob.LastError)
+			assert.Equal(t, 2, mockJob.FailureCount)
+		})
+	})
+	t.Run("When the job times out", func(t *testing.T) {
+		t.Run("Should set the job as TIMED_OUT if the job function does not return before the timeout", func(t *testing.T) {
+			s, dbMock, loggerMock := mockScheduler()
+			s.defaultJobTimeout = 10 * time.Millisecond
+
+			mockJobName := "MYMOCKJOB!"
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				<-ctx.Done()
+				return nil
+			}
+
+			mockJob := Job{
+				Name:         mockJobName,
+				ScheduleType: SIMPLE,
+			}
+
+			s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+			s.process()
+			<-s.runningJobsDone()
+
+			assert.True(t, dbMock.Method("FinishJob").CalledOnce())
+			assert.True(t, dbMock.Method("FinishJob").CalledWith(mockJob))
+			assert.True(t, mockJob.HasTimedOut())
+			assert.Equal(t, errJobTimedOut.Error(), mockJob.LastError)
+			assert.Equal(t, 1, mockJob.FailureCount)
+			assert.True(t, loggerMock.Method("Errorf").CalledWith("Job %s timed out after %v"))
+		})
+		t.Run("Should free the worker, abandoning the timed out job function that does not return after the grace period", func(t *testing.T) {
+			s, dbMock, loggerMock := mockScheduler()
+			s.defaultJobTimeout = 10 * time.Millisecond
+			s.jobGracePeriod = 10 * time.Millisecond
+
+			mockJobName := "MYMOCKJOB!"
+			proceed := make(chan struct{})
+			returned := make(chan struct{})
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				// ignores the context on purpose
+				defer close(returned)
+				<-proceed
+				return nil
+			}
+
+			mockJob := Job{
+				Name:         mockJobName,
+				ScheduleType: SIMPLE,
+			}
+
+			s.Define(mockJobName, mockJobFunc, DefinitionOptions{Concurrency: 1})
+			defer s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+			s.process()
+
+			// the run is recorded once the grace period is over, and the worker and the definition slot are freed...
+			<-s.runningJobsDone()
+			assert.True(t, dbMock.Method("FinishJob").CalledOnce())
+			assert.True(t, mockJob.HasTimedOut())
+			assert.True(t, loggerMock.Method("Errorf").CalledWith("Job %s function did not return after its context was done and was abandoned, %d abandoned job functions are still running"))
+			assert.Len(t, s.workers, 0)
+			assert.True(t, s.getJobDefinition(mockJobName).acquire())
+			s.getJobDefinition(mockJobName).release()
+
+			// ... while the abandoned function is counted until it returns
+			s.claimedJobsMu.Lock()
+			assert.Equal(t, 1, s.abandonedJobFuncs)
+			s.claimedJobsMu.Unlock()
+
+			close(proceed)
+			<-returned
+			assert.Eventually(t, func() bool {
+				s.claimedJobsMu.Lock()
+				defer s.claimedJobsMu.Unlock()
+
+				return s.abandonedJobFuncs == 0
+			}, time.Second, time.Millisecond)
+		})
+		t.Run("Should not share the job data with the timed out job function", func(t *testing.T) {
+			s, dbMock, _ := mockScheduler()
+			s.defaultJobTimeout = 10 * time.Millisecond
+
+			mockJobName := "MYMOCKJOB!"
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				<-ctx.Done()
+				j.Data["key"] = "changed"
+				j.Data["nested"].(map[string]any)["key"] = "changed"
+				return nil
+			}
+
+			mockJob := Job{
+				Name:         mockJobName,
+				ScheduleType: SIMPLE,
+				Data:         map[string]any{"key": "value", "nested": map[string]any{"key": "value"}},
+			}
+
+			s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+			s.process()
+			<-s.runningJobsDone()
+
+			assert.Equal(t, "value", mockJob.Data["key"])
+			assert.Equal(t, "value", mockJob.Data["nested"].(map[string]any)["key"])
+		})
+		t.Run("Should retry the timed out job if it has a retry policy", func(t *testing.T) {
+			s, dbMock, _ := mockScheduler()
+
+			mockJobName := "MYMOCKJOB!"
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+
+			mockJob := Job{
+				Name:         mockJobName,
+				ScheduleType: SIMPLE,
+				Timeout:      10 * time.Millisecond,
+				RetryPolicy:  &RetryPolicy{MaxAttempts: 2},
+			}
+
+			s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+			s.process()
+			<-s.runningJobsDone()
+
+			assert.True(t, mockJob.IsPending())
+			assert.Equal(t, 1, mockJob.Attempts)
+		})
+		t.Run("Should prefer the job timeout over the definition timeout, and the definition timeout over the library timeout", func(t *testing.T) {
+			s, _, _ := mockScheduler()
+			s.defaultJobTimeout = time.Hour
+
+			jd := newJobDefinition(nil, DefinitionOptions{Timeout: time.Minute})
+
+			assert.Equal(t, time.Second, s.jobTimeout(&Job{Timeout: time.Second}, jd))
+			assert.Equal(t, time.Minute, s.jobTimeout(&Job{}, jd))
+			assert.Equal(t, time.Hour, s.jobTimeout(&Job{}, newJobDefinition(nil)))
+		})
 	})
 	t.Run("When the job succeeds", func(t *testing.T) {
 		t.Run("Should set the job as done if the job schedule is SIMPLE", func(t *testing.T) {
-			dbMock, loggerMock := mockDependencies()
+			s, dbMock, loggerMock := mockScheduler()
 
 			mockJobName := "MYMOCKJOB!"
-			mockJobFunc := func(j *Job) error {
+			mockJobFunc := func(ctx context.Context, j *Job) error {
 				return nil
 			}
 
@@ -104,23 +502,81 @@ func TestProcessJobs(t *testing.T) {
 				ScheduleType: SIMPLE,
 			}
 
-			Define(mockJobName, mockJobFunc)
+			s.Define(mockJobName, mockJobFunc)
 
 			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
 
-			process()
+			s.process()
+			<-s.runningJobsDone()
 
 			assert.True(t, dbMock.Method("ListExpiredSchedules").CalledOnce())
-			assert.True(t, dbMock.Method("SaveJob").CalledOnce())
-			assert.True(t, dbMock.Method("SaveJob").CalledWith(mockJob))
+			assert.True(t, dbMock.Method("FinishJob").CalledOnce())
+			assert.True(t, dbMock.Method("FinishJob").CalledWith(mockJob))
 			assert.True(t, mockJob.IsDone())
 			assert.False(t, loggerMock.Called())
 		})
+		t.Run("Should delete the job after it is done if the scheduler deletes the done jobs", func(t *testing.T) {
+			s, dbMock, loggerMock := mockScheduler()
+			s.deleteOnDone = true
+
+			mockJobName := "MYMOCKJOB!"
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				return nil
+			}
+
+			mockJob := Job{
+				ID:           "MYJOBID!",
+				Name:         mockJobName,
+				ScheduleType: SIMPLE,
+			}
+
+			s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+			s.process()
+			<-s.runningJobsDone()
+
+			assert.True(t, dbMock.Method("FinishJob").CalledOnce())
+			assert.True(t, dbMock.Method("DeleteJob").CalledOnce())
+			assert.False(t, loggerMock.Called())
+		})
+		t.Run("Should drop the job run result, logging an error, if the job is not owned by this scheduler instance anymore", func(t *testing.T) {
+			s, dbMock, loggerMock := mockScheduler()
+			s.deleteOnDone = true
+
+			mockJobName := "MYMOCKJOB!"
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				return nil
+			}
+
+			mockJob := Job{
+				ID:           "MYJOBID!",
+				Name:         mockJobName,
+				ScheduleType: SIMPLE,
+			}
+
+			s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+			dbMock.SetMethodResponse("FinishJob", false, nil)
+
+			s.process()
+			<-s.runningJobsDone()
+
+			assert.True(t, dbMock.Method("FinishJob").CalledOnce())
+			assert.True(t, dbMock.Method("FinishJob").CalledWith(mockJob, s.workerID))
+			assert.False(t, dbMock.Method("DeleteJob").Called())
+			assert.True(t, loggerMock.Method("Errorf").CalledOnce())
+		})
 		t.Run("Should set the job as done if the job schedule is RECURRENT, but the limit date is lesser than now", func(t *testing.T) {
-			dbMock, loggerMock := mockDependencies()
+			s, dbMock, loggerMock := mockScheduler()
 
 			mockJobName := "MYMOCKJOB!"
-			mockJobFunc := func(j *Job) error {
+			mockJobFunc := func(ctx context.Context, j *Job) error {
 				return nil
 			}
 
@@ -131,23 +587,25 @@ func TestProcessJobs(t *testing.T) {
 				ScheduleLimitDate: &ld,
 			}
 
-			Define(mockJobName, mockJobFunc)
+			s.Define(mockJobName, mockJobFunc)
 
 			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
 
-			process()
+			s.process()
+			<-s.runningJobsDone()
 
 			assert.True(t, dbMock.Method("ListExpiredSchedules").CalledOnce())
-			assert.True(t, dbMock.Method("SaveJob").CalledOnce())
-			assert.True(t, dbMock.Method("SaveJob").CalledWith(mockJob))
+			assert.True(t, dbMock.Method("FinishJob").CalledOnce())
+			assert.True(t, dbMock.Method("FinishJob").CalledWith(mockJob))
 			assert.True(t, mockJob.IsDone())
 			assert.False(t, loggerMock.Called())
 		})
 		t.Run("Should log an error and fail the job if the job schedule is RECURRENT, but the job has no scheduleString", func(t *testing.T) {
-			dbMock, loggerMock := mockDependencies()
+			s, dbMock, loggerMock := mockScheduler()
 
 			mockJobName := "MYMOCKJOB!"
-			mockJobFunc := func(j *Job) error {
+			mockJobFunc := func(ctx context.Context, j *Job) error {
 				return nil
 			}
 
@@ -157,23 +615,26 @@ func TestProcessJobs(t *testing.T) {
 				ScheduleString: "",
 			}
 
-			Define(mockJobName, mockJobFunc)
+			s.Define(mockJobName, mockJobFunc)
 
 			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
 
-			process()
+			s.process()
+			<-s.runningJobsDone()
 
 			assert.True(t, dbMock.Method("ListExpiredSchedules").CalledOnce())
-			assert.True(t, dbMock.Method("SaveJob").CalledOnce())
-			assert.True(t, dbMock.Method("SaveJob").CalledWith(mockJob))
+			assert.True(t, dbMock.Method("FinishJob").CalledOnce())
+			assert.True(t, dbMock.Method("FinishJob").CalledWith(mockJob))
 			assert.True(t, mockJob.HasFailed())
+			assert.Equal(t, "Tried to re-schedule the recurrent job, but it had no ScheduleString", mockJob.LastError)
 			assert.True(t, loggerMock.CalledWith("Tried to re-schedule recurrent job %s, but it had no ScheduleString"))
 		})
 		t.Run("Should log an error and fail the job if the job schedule is RECURRENT, but the job scheduleString is invalid", func(t *testing.T) {
-			dbMock, loggerMock := mockDependencies()
+			s, dbMock, loggerMock := mockScheduler()
 
 			mockJobName := "MYMOCKJOB!"
-			mockJobFunc := func(j *Job) error {
+			mockJobFunc := func(ctx context.Context, j *Job) error {
 				return nil
 			}
 
@@ -183,23 +644,26 @@ func TestProcessJobs(t *testing.T) {
 				ScheduleString: "an invalid schedule string",
 			}
 
-			Define(mockJobName, mockJobFunc)
+			s.Define(mockJobName, mockJobFunc)
 
 			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
 
-			process()
+			s.process()
+			<-s.runningJobsDone()
 
 			assert.True(t, dbMock.Method("ListExpiredSchedules").CalledOnce())
-			assert.True(t, dbMock.Method("SaveJob").CalledOnce())
-			assert.True(t, dbMock.Method("SaveJob").CalledWith(mockJob))
+			assert.True(t, dbMock.Method("FinishJob").CalledOnce())
+			assert.True(t, dbMock.Method("FinishJob").CalledWith(mockJob))
 			assert.True(t, mockJob.HasFailed())
+			assert.Contains(t, mockJob.LastError, "Failed to get next schedule date")
 			assert.True(t, loggerMock.CalledWith("Failed to get next schedule date for job %s, %v"))
 		})
 		t.Run("Should re-schedule job if the job is RECURRENT and its schedule string is valid", func(t *testing.T) {
-			dbMock, loggerMock := mockDependencies()
+			s, dbMock, loggerMock := mockScheduler()
 
 			mockJobName := "MYMOCKJOB!"
-			mockJobFunc := func(j *Job) error {
+			mockJobFunc := func(ctx context.Context, j *Job) error {
 				return nil
 			}
 
@@ -209,17 +673,271 @@ func TestProcessJobs(t *testing.T) {
 				ScheduleString: "monday at 12:45",
 			}
 
-			Define(mockJobName, mockJobFunc)
+			s.Define(mockJobName, mockJobFunc)
 
 			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
 
-			process()
+			s.process()
+			<-s.runningJobsDone()
 
 			assert.True(t, dbMock.Method("ListExpiredSchedules").CalledOnce())
-			assert.True(t, dbMock.Method("SaveJob").CalledOnce())
-			assert.True(t, dbMock.Method("SaveJob").CalledWith(mockJob))
+			assert.True(t, dbMock.Method("FinishJob").CalledOnce())
+			assert.True(t, dbMock.Method("FinishJob").CalledWith(mockJob))
 			assert.True(t, mockJob.IsPending())
 			assert.False(t, loggerMock.Called())
 		})
+		t.Run("Should re-schedule the RECURRENT job on the job location", func(t *testing.T) {
+			s, dbMock, loggerMock := mockScheduler()
+
+			mockJobName := "MYMOCKJOB!"
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				return nil
+			}
+
+			mockJob := Job{
+				Name:           mockJobName,
+				ScheduleType:   RECURRENT,
+				ScheduleString: "09:00",
+				Location:       "Europe/Lisbon",
+			}
+
+			s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+			s.process()
+			<-s.runningJobsDone()
+
+			assert.True(t, dbMock.Method("FinishJob").CalledOnce())
+			assert.True(t, mockJob.IsPending())
+			assert.Equal(t, "Europe/Lisbon", mockJob.NextRunAt.Location().String())
+			assert.Equal(t, 9, mockJob.NextRunAt.Hour())
+			assert.Equal(t, 0, mockJob.NextRunAt.Minute())
+			assert.False(t, loggerMock.Called())
+		})
+		t.Run("Should log an error and fail the job if the job location is invalid", func(t *testing.T) {
+			s, dbMock, loggerMock := mockScheduler()
+
+			mockJobName := "MYMOCKJOB!"
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				return nil
+			}
+
+			mockJob := Job{
+				Name:           mockJobName,
+				ScheduleType:   RECURRENT,
+				ScheduleString: "09:00",
+				Location:       "Nowhere/Somewhere",
+			}
+
+			s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+			s.process()
+			<-s.runningJobsDone()
+
+			assert.True(t, loggerMock.Method("Errorf").CalledOnce())
+			assert.True(t, mockJob.HasFailed())
+		})
+	})
+	t.Run("When running jobs concurrently", func(t *testing.T) {
+		t.Run("Should run the due jobs in parallel, limited by the library concurrency", func(t *testing.T) {
+			s, dbMock, _ := mockScheduler()
+
+			s.workers = make(chan struct{}, 2)
+
+			mockJobName := "MYMOCKJOB!"
+			running := make(chan struct{}, 3)
+			proceed := make(chan struct{})
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				running <- struct{}{}
+				<-proceed
+				return nil
+			}
+
+			mockJobs := []*Job{
+				{Name: mockJobName, ScheduleType: SIMPLE},
+				{Name: mockJobName, ScheduleType: SIMPLE},
+				{Name: mockJobName, ScheduleType: SIMPLE},
+			}
+
+			s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", mockJobs, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+			processed := make(chan struct{})
+			go func() {
+				s.process()
+				close(processed)
+			}()
+
+			// two jobs run at the same time, while the third waits for a free worker
+			<-running
+			<-running
+			assert.Len(t, running, 0)
+
+			close(proceed)
+			<-processed
+			<-s.runningJobsDone()
+
+			for _, j := range mockJobs {
+				assert.True(t, j.IsDone())
+			}
+		})
+		t.Run("Should run every due job on the same cycle with the default concurrency", func(t *testing.T) {
+			s, dbMock, loggerMock := mockScheduler()
+
+			mockJobName := "MYMOCKJOB!"
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			}
+
+			mockJobs := []*Job{}
+			for i := 0; i < 5; i++ {
+				mockJobs = append(mockJobs, &Job{Name: mockJobName, ScheduleType: SIMPLE})
+			}
+
+			s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", mockJobs, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+			s.process()
+			<-s.runningJobsDone()
+
+			assert.Equal(t, 1, cap(s.workers))
+			assert.True(t, dbMock.Method("ClaimJob").CalledTimes(5))
+			for _, j := range mockJobs {
+				assert.True(t, j.IsDone())
+			}
+			assert.False(t, loggerMock.Called())
+		})
+		t.Run("Should stop waiting for a free worker if the scheduler is shutting down", func(t *testing.T) {
+			s, dbMock, _ := mockScheduler()
+
+			mockJobName := "MYMOCKJOB!"
+			running := make(chan struct{}, 2)
+			proceed := make(chan struct{})
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				running <- struct{}{}
+				<-proceed
+				return nil
+			}
+
+			mockJobs := []*Job{
+				{Name: mockJobName, ScheduleType: SIMPLE, Status: PENDING},
+				{Name: mockJobName, ScheduleType: SIMPLE, Status: PENDING},
+			}
+
+			s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", mockJobs, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+			processed := make(chan struct{})
+			go func() {
+				s.process()
+				close(processed)
+			}()
+
+			// the first job holds the only worker, while the second waits for it
+			<-running
+			close(s.stopping)
+			<-processed
+
+			close(proceed)
+			<-s.runningJobsDone()
+
+			assert.True(t, dbMock.Method("ClaimJob").CalledOnce())
+			assert.True(t, mockJobs[0].IsDone())
+			assert.True(t, mockJobs[1].IsPending())
+		})
+		t.Run("Should skip the job if its definition concurrency limit was reached", func(t *testing.T) {
+			s, dbMock, _ := mockScheduler()
+
+			s.workers = make(chan struct{}, 2)
+
+			mockJobName := "MYMOCKJOB!"
+			proceed := make(chan struct{})
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				<-proceed
+				return nil
+			}
+
+			mockJobs := []*Job{
+				{Name: mockJobName, ScheduleType: SIMPLE, Status: PENDING},
+				{Name: mockJobName, ScheduleType: SIMPLE, Status: PENDING},
+			}
+
+			s.Define(mockJobName, mockJobFunc, DefinitionOptions{Concurrency: 1})
+			defer s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", mockJobs, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+			s.process()
+			close(proceed)
+			<-s.runningJobsDone()
+
+			assert.True(t, dbMock.Method("ClaimJob").CalledOnce())
+			assert.True(t, mockJobs[0].IsDone())
+			assert.True(t, mockJobs[1].IsPending())
+		})
+		t.Run("Should run job functions that do not receive a context", func(t *testing.T) {
+			s, dbMock, _ := mockScheduler()
+
+			mockJobName := "MYMOCKJOB!"
+			ran := false
+			mockJobFunc := func(j *Job) error {
+				ran = true
+				return nil
+			}
+
+			mockJob := Job{
+				Name:         mockJobName,
+				ScheduleType: SIMPLE,
+			}
+
+			s.Define(mockJobName, WithoutContext(mockJobFunc))
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+			s.process()
+			<-s.runningJobsDone()
+
+			assert.True(t, ran)
+			assert.True(t, mockJob.IsDone())
+		})
+		t.Run("Should fail the job if the job function panics", func(t *testing.T) {
+			s, dbMock, loggerMock := mockScheduler()
+
+			mockJobName := "MYMOCKJOB!"
+			mockJobFunc := func(ctx context.Context, j *Job) error {
+				panic("mock panic!!")
+			}
+
+			mockJob := Job{
+				Name:         mockJobName,
+				ScheduleType: SIMPLE,
+			}
+
+			s.Define(mockJobName, mockJobFunc)
+
+			dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+			dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+			s.process()
+			<-s.runningJobsDone()
+
+			assert.True(t, mockJob.HasFailed())
+			assert.True(t, loggerMock.Method("Errorf").CalledWith("Job %s failed, %v"))
+		})
 	})
 }
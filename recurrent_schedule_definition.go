@@ -6,21 +6,31 @@ import (
 )
 
 type recurrentScheduleDefinition struct {
-	schedule  string
-	limitDate *time.Time
+	scheduler   *Scheduler
+	schedule    string
+	limitDate   *time.Time
+	location    string
+	retryPolicy *RetryPolicy
+	timeout     time.Duration
+
+	unique         bool
+	uniqueKey      string
+	conflictPolicy ConflictPolicy
 }
 
 // Do effectivelly schedules the job on the database to run in the configured time, given the job name.
 //
 // You can also provide extra data that will be saved with the job.
-func (rsd *recurrentScheduleDefinition) Do(jobName string, data ...map[string]any) (err error) {
-	jobFunc := jobDefinitions[jobName]
-	if jobFunc == nil {
+//
+// Returns the saved job, with the ID assigned by the database.
+func (rsd *recurrentScheduleDefinition) Do(jobName string, data ...map[string]any) (j *Job, err error) {
+	if rsd.scheduler.getJobDefinition(jobName) == nil {
 		err = fmt.Errorf("No job definition with the name %s was found", jobName)
 		return
 	}
 
-	t, err := getNextScheduleDate(rsd.schedule)
+	anchor := rsd.scheduler.now()
+	t, err := rsd.scheduler.nextScheduleDate(rsd.schedule, rsd.location, anchor)
 	if err != nil {
 		return
 	}
@@ -36,11 +46,22 @@ func (rsd *recurrentScheduleDefinition) Do(jobName string, data ...map[string]an
 		NextRunAt:         t,
 		ScheduleString:    rsd.schedule,
 		ScheduleLimitDate: rsd.limitDate,
+		ScheduleAnchor:    &anchor,
+		Location:          rsd.location,
+		RetryPolicy:       rsd.retryPolicy,
+		Timeout:           rsd.timeout,
 		Name:              jobName,
 		Data:              d,
 	}
 
-	return db.SaveJob(job)
+	if rsd.unique {
+		job.UniqueKey, err = jobUniqueKey(rsd.uniqueKey, jobName, d)
+		if err != nil {
+			return
+		}
+	}
+
+	return rsd.scheduler.createJob(job, rsd.conflictPolicy)
 }
 
 // Until sets a limit date for the RECURRENT job to run.
@@ -53,3 +74,53 @@ func (rsd *recurrentScheduleDefinition) Until(t time.Time) *recurrentScheduleDef
 	rsd.limitDate = &t
 	return rsd
 }
+
+// In sets the timezone (Ex.: "Europe/Lisbon") on which the schedule string is evaluated,
+// overriding the location configured in the library instantiation.
+//
+// The location is saved with the job, so that every re-schedule of the job is computed on it.
+// If the location is invalid or unavailable, the Do function will return an error.
+func (rsd *recurrentScheduleDefinition) In(location string) *recurrentScheduleDefinition {
+	rsd.location = location
+	return rsd
+}
+
+// Retry sets the retry policy that should be used when the job function fails,
+// overriding the retry policy configured in the library instantiation.
+func (rsd *recurrentScheduleDefinition) Retry(rp RetryPolicy) *recurrentScheduleDefinition {
+	rsd.retryPolicy = &rp
+	return rsd
+}
+
+// Timeout sets how long the job function can run before its context is cancelled and the run is considered TIMED_OUT,
+// overriding the timeout of the job definition and the one configured in the library instantiation.
+//
+// A job function that ignores its cancelled context is abandoned after a grace period (See Config.JobTimeout).
+func (rsd *recurrentScheduleDefinition) Timeout(d time.Duration) *recurrentScheduleDefinition {
+	rsd.timeout = d
+	return rsd
+}
+
+// Unique makes the job unique given the key, so that scheduling it again does not create a duplicate job
+// (Ex.: when the job is scheduled on every application start).
+//
+// If the key is empty, the key is derived from the job name and data.
+//
+// When another job already holds the key, the existing job is kept by default, see OnConflict to change that.
+func (rsd *recurrentScheduleDefinition) Unique(key string) *recurrentScheduleDefinition {
+	rsd.unique = true
+	rsd.uniqueKey = key
+	return rsd
+}
+
+// OnConflict sets what happens when the Unique job key is already used by another job:
+//
+// - KEEP: the existing job is kept and returned by Do (default), even if it is already DONE or CANCELED
+//
+// - REPLACE: the existing job is replaced by the new job, keeping its ID (a RUNNING job can't be replaced)
+//
+// - REJECT: the existing job is kept, and Do returns ErrDuplicateJob
+func (rsd *recurrentScheduleDefinition) OnConflict(cp ConflictPolicy) *recurrentScheduleDefinition {
+	rsd.conflictPolicy = cp
+	return rsd
+}
@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy defines how a job should be retried when its job function returns an error.
+type RetryPolicy struct {
+	// MaxAttempts represents the maximum number of times that the job function will be called for a single run,
+	// including the first call.
+	//
+	// When the attempts are exhausted, the job is set as FAILED.
+	MaxAttempts int
+
+	// Backoff defines how the delay between attempts grows, if its FIXED or EXPONENTIAL.
+	//
+	// Default: FIXED
+	Backoff BackoffStrategy
+
+	// Delay represents the delay before the first retry.
+	//
+	// When the Backoff is EXPONENTIAL, the delay doubles for each subsequent retry.
+	//
+	// Default: 1 minute
+	Delay time.Duration
+
+	// MaxDelay represents the maximum delay between attempts.
+	//
+	// If no value is specified, the delay is not limited.
+	MaxDelay time.Duration
+
+	// Jitter defines if the delay between attempts should be randomized,
+	// so that jobs that failed together are not retried all at once.
+	//
+	// When set, the delay is randomly picked between half of the delay and the full delay.
+	Jitter bool
+}
+
+// shouldRetry returns true if the job can be retried after the provided number of attempts
+func (rp *RetryPolicy) shouldRetry(attempts int) bool {
+	return rp != nil && attempts < rp.MaxAttempts
+}
+
+// nextDelay returns the delay before the retry that follows the provided number of attempts
+func (rp *RetryPolicy) nextDelay(attempts int) time.Duration {
+	delay := rp.Delay
+	if delay <= 0 {
+		delay = time.Minute
+	}
+
+	if rp.Backoff == EXPONENTIAL {
+		for i := 1; i < attempts; i++ {
+			if delay > math.MaxInt64/2 ||
+				(rp.MaxDelay > 0 && delay >= rp.MaxDelay) {
+				break
+			}
+
+			delay *= 2
+		}
+	}
+
+	if rp.MaxDelay > 0 && delay > rp.MaxDelay {
+		delay = rp.MaxDelay
+	}
+
+	if rp.Jitter {
+		half := delay / 2
+		delay = half + time.Duration(rand.Int63n(int64(delay-half)+1))
+	}
+
+	return delay
+}
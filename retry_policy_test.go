@@ -0,0 +1,55 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	t.Run("Should not retry if there is no retry policy", func(t *testing.T) {
+		var rp *RetryPolicy
+		assert.False(t, rp.shouldRetry(1))
+	})
+	t.Run("Should retry only while the attempts are lesser than the max attempts", func(t *testing.T) {
+		rp := &RetryPolicy{MaxAttempts: 3}
+		assert.True(t, rp.shouldRetry(1))
+		assert.True(t, rp.shouldRetry(2))
+		assert.False(t, rp.shouldRetry(3))
+	})
+}
+
+func TestRetryPolicyNextDelay(t *testing.T) {
+	t.Run("Should use one minute as the default delay", func(t *testing.T) {
+		rp := &RetryPolicy{MaxAttempts: 3}
+		assert.Equal(t, time.Minute, rp.nextDelay(1))
+		assert.Equal(t, time.Minute, rp.nextDelay(2))
+	})
+	t.Run("Should keep the delay when the backoff is FIXED", func(t *testing.T) {
+		rp := &RetryPolicy{MaxAttempts: 3, Backoff: FIXED, Delay: 10 * time.Second}
+		assert.Equal(t, 10*time.Second, rp.nextDelay(1))
+		assert.Equal(t, 10*time.Second, rp.nextDelay(5))
+	})
+	t.Run("Should double the delay for each attempt when the backoff is EXPONENTIAL", func(t *testing.T) {
+		rp := &RetryPolicy{MaxAttempts: 5, Backoff: EXPONENTIAL, Delay: 10 * time.Second}
+		assert.Equal(t, 10*time.Second, rp.nextDelay(1))
+		assert.Equal(t, 20*time.Second, rp.nextDelay(2))
+		assert.Equal(t, 40*time.Second, rp.nextDelay(3))
+		assert.Equal(t, 80*time.Second, rp.nextDelay(4))
+	})
+	t.Run("Should limit the delay to the max delay", func(t *testing.T) {
+		rp := &RetryPolicy{MaxAttempts: 100, Backoff: EXPONENTIAL, Delay: 10 * time.Second, MaxDelay: 30 * time.Second}
+		assert.Equal(t, 20*time.Second, rp.nextDelay(2))
+		assert.Equal(t, 30*time.Second, rp.nextDelay(3))
+		assert.Equal(t, 30*time.Second, rp.nextDelay(100))
+	})
+	t.Run("Should randomize the delay between half of the delay and the full delay when using jitter", func(t *testing.T) {
+		rp := &RetryPolicy{MaxAttempts: 3, Delay: 10 * time.Second, Jitter: true}
+		for i := 0; i < 100; i++ {
+			d := rp.nextDelay(1)
+			assert.GreaterOrEqual(t, d, 5*time.Second)
+			assert.LessOrEqual(t, d, 10*time.Second)
+		}
+	})
+}
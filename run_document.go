@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// runDocument represents a job run document on the mongo database
+type runDocument struct {
+	ID         *primitive.ObjectID `bson:"_id,omitempty"`
+	JobID      string              `bson:"job_id"`
+	JobName    string              `bson:"job_name"`
+	Attempt    int                 `bson:"attempt"`
+	WorkerID   string              `bson:"worker_id"`
+	StartedAt  time.Time           `bson:"started_at"`
+	FinishedAt time.Time           `bson:"finished_at"`
+	Outcome    string              `bson:"outcome"`
+	Error      string              `bson:"error,omitempty"`
+}
+
+// marshalRun marshals a job run struct into a run document
+func marshalRun(r JobRun) runDocument {
+	var id *primitive.ObjectID
+	if r.ID != "" {
+		parsedID, err := primitive.ObjectIDFromHex(r.ID)
+		if err == nil {
+			id = &parsedID
+		}
+	}
+
+	return runDocument{
+		ID:         id,
+		JobID:      r.JobID,
+		JobName:    r.JobName,
+		Attempt:    r.Attempt,
+		WorkerID:   r.WorkerID,
+		StartedAt:  r.StartedAt,
+		FinishedAt: r.FinishedAt,
+		Outcome:    r.Outcome.String(),
+		Error:      r.Error,
+	}
+}
+
+// unmarshalRun marshals a run document into a job run struct
+func unmarshalRun(r runDocument) JobRun {
+	return JobRun{
+		ID:         r.ID.Hex(),
+		JobID:      r.JobID,
+		JobName:    r.JobName,
+		Attempt:    r.Attempt,
+		WorkerID:   r.WorkerID,
+		StartedAt:  r.StartedAt,
+		FinishedAt: r.FinishedAt,
+		Outcome:    RunOutcome(r.Outcome),
+		Error:      r.Error,
+	}
+}
@@ -0,0 +1,16 @@
+package scheduler
+
+// RunOutcome represents how a job run ended
+type RunOutcome string
+
+const (
+	RUN_SUCCEEDED = RunOutcome("SUCCEEDED")
+	RUN_FAILED    = RunOutcome("FAILED")
+	RUN_TIMED_OUT = RunOutcome("TIMED_OUT")
+	RUN_CANCELED  = RunOutcome("CANCELED")
+)
+
+// String returns the run outcome in string notation
+func (o RunOutcome) String() string {
+	return string(o)
+}
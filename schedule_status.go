@@ -3,10 +3,12 @@ package scheduler
 type ScheduleStatus string
 
 const (
-	DONE     = ScheduleStatus("DONE")
-	FAILED   = ScheduleStatus("FAILED")
-	PENDING  = ScheduleStatus("PENDING")
-	CANCELED = ScheduleStatus("CANCELED")
+	DONE      = ScheduleStatus("DONE")
+	FAILED    = ScheduleStatus("FAILED")
+	PENDING   = ScheduleStatus("PENDING")
+	RUNNING   = ScheduleStatus("RUNNING")
+	CANCELED  = ScheduleStatus("CANCELED")
+	TIMED_OUT = ScheduleStatus("TIMED_OUT")
 )
 
 // String returns the schedule status in string notation
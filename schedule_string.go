@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -30,19 +31,54 @@ var (
 		"minute":  time.Minute,
 		"hours":   time.Hour,
 		"hour":    time.Hour,
-		"days":    24 * time.Hour,
-		"day":     24 * time.Hour,
-		"months":  30 * 24 * time.Hour,
-		"month":   30 * 24 * time.Hour,
-		"years":   365 * 24 * time.Hour,
-		"year":    365 * 24 * time.Hour,
 	}
+
+	// calendarUnits is a mapping of the calendar time units to their respective amount of years, months and days,
+	// these units do not have a fixed duration (Ex.: a day can have 23 or 25 hours on a DST transition),
+	// so they are added using calendar arithmetic
+	calendarUnits = map[string]struct{ years, months, days int }{
+		"days":   {0, 0, 1},
+		"day":    {0, 0, 1},
+		"months": {0, 1, 0},
+		"month":  {0, 1, 0},
+		"years":  {1, 0, 0},
+		"year":   {1, 0, 0},
+	}
+
+	// monthlyScheduleRegex matches the schedule strings anchored to a day of the month
+	// (Ex.: "every month on the 1st at 09:00", "month on the 15th", "last day of month at 18:00")
+	monthlyScheduleRegex = regexp.MustCompile(`^(?:every )?(?:month on the (?:(\d{1,2})(?:st|nd|rd|th)|(last) day)|(last) day of (?:the )?month)(?: at (\S+))?$`)
 )
 
-// getNextScheduleDate parses a time schedule string into the date of the next execution
-func getNextScheduleDate(schedule string) (time.Time, error) {
-	// Get the current time
-	now := now()
+// getNextScheduleDate parses a time schedule string into the date of the next execution after now.
+//
+// The date is computed on the location of now. When the wall clock time of the schedule
+// is skipped by a DST transition, the job runs after the transition (Ex.: 02:30 becomes 03:30),
+// and when it is repeated by a DST transition, the job runs on its first occurrence.
+func getNextScheduleDate(schedule string, now time.Time) (time.Time, error) {
+	return getNextAnchoredScheduleDate(schedule, now, now)
+}
+
+// getNextAnchoredScheduleDate parses a time schedule string into the date of the next execution after now,
+// as getNextScheduleDate does, but the time intervals (Ex.: "1 month") are counted from the anchor,
+// so that the next date is the first one after now of the anchor plus a whole number of intervals.
+//
+// The anchor is ignored by the other schedule formats.
+func getNextAnchoredScheduleDate(schedule string, anchor, now time.Time) (time.Time, error) {
+	// Check if the input is anchored to a day of the month (Ex.: "every month on the 1st at 09:00")
+	if m := monthlyScheduleRegex.FindStringSubmatch(strings.Join(strings.Fields(schedule), " ")); m != nil {
+		return getNextMonthlyDate(schedule, m, now)
+	}
+
+	// Check if the input is a cron expression (Ex.: "*/5 * * * *", "@daily")
+	if isCronExpression(schedule) {
+		cs, err := parseCronExpression(schedule)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return cs.next(now)
+	}
 
 	// Split the input schedule string into words
 	words := strings.Fields(schedule)
@@ -54,13 +90,16 @@ func getNextScheduleDate(schedule string) (time.Time, error) {
 			return time.Time{}, fmt.Errorf("Failed to parse schedule format '%s', invalid duration: %s, Error: %v", schedule, words[0], err)
 		}
 
+		if num < 1 {
+			return time.Time{}, fmt.Errorf("Failed to parse schedule format '%s', invalid duration: %s, the duration should be positive", schedule, words[0])
+		}
+
 		unit := words[1]
-		duration, found := unitToDuration[unit]
+		nextTime, found := nextIntervalDate(anchor, now, num, unit)
 		if !found {
 			return time.Time{}, fmt.Errorf("Failed to parse schedule format '%s', invalid time unit: %s", schedule, unit)
 		}
 
-		nextTime := now.Add(time.Duration(num) * duration)
 		return nextTime, nil
 	}
 
@@ -68,32 +107,23 @@ func getNextScheduleDate(schedule string) (time.Time, error) {
 		// Check if the input is a weekday
 		weekday, found := weekdays[words[0]]
 		if found {
-			daysUntilNextWeekday := int(weekday-now.Weekday()+7) % 7
-			nextTime := now.Add(time.Duration(daysUntilNextWeekday) * 24 * time.Hour)
-			nextTime = time.Date(nextTime.Year(), nextTime.Month(), nextTime.Day(), 0, 1, 0, 0, time.UTC)
-			// If the specified time has already passed for today, set it for the next week
-			if nextTime.Before(now) {
-				nextTime = nextTime.Add(time.Hour * 24 * 7)
-			}
-
-			return nextTime, nil
+			return nextWeekdayDate(now, weekday, 0, 1), nil
 		}
 
 		// Check if the input is a duration
-		duration, found := unitToDuration[words[0]]
+		nextTime, found := nextIntervalDate(anchor, now, 1, words[0])
 		if found {
-			nextTime := now.Add(duration)
 			return nextTime, nil
 		}
 
 		// Check if the input is a time in HH:MM format
 		t, err := time.Parse(hourMinuteFormat, words[0])
 		if err == nil {
-			nextTime := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+			nextTime := dateIn(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, now.Location())
 
-			// If the specified time has already passed for today, set it for the next day
-			if now.After(nextTime) {
-				nextTime = nextTime.Add(24 * time.Hour)
+			// If the specified time has already passed for today (or is right now), set it for the next day
+			if !nextTime.After(now) {
+				nextTime = dateIn(now.Year(), now.Month(), now.Day()+1, t.Hour(), t.Minute(), 0, now.Location())
 			}
 
 			return nextTime, nil
@@ -113,16 +143,125 @@ func getNextScheduleDate(schedule string) (time.Time, error) {
 				return time.Time{}, fmt.Errorf("Failed to parse schedule format '%s', invalid duration: %s, Error: %v", schedule, words[0], err)
 			}
 
-			daysUntilNextWeekday := int(weekday-now.Weekday()+7) % 7
-			nextTime := now.Add(time.Duration(daysUntilNextWeekday) * 24 * time.Hour)
-			nextTime = time.Date(nextTime.Year(), nextTime.Month(), nextTime.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
-			// If the specified time has already passed for today, set it for the next week
-			if nextTime.Before(now) {
-				nextTime = nextTime.Add(time.Hour * 24 * 7)
+			return nextWeekdayDate(now, weekday, t.Hour(), t.Minute()), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("Invalid schedule format: %s", schedule)
+}
+
+// nextIntervalDate returns the first date after now that is num time units apart, a whole number of times, from the anchor.
+//
+// Calendar units (days, months and years) are added using calendar arithmetic,
+// so that "1 month" after January 15th is February 15th, regardless of the number of days in between,
+// and "1 day" keeps the wall clock time across DST transitions.
+// When the anchor day does not exist on a month, the last day of that month is used instead,
+// so that "1 month" after January 31st is February 29th (or 28th), and then March 31st.
+//
+// Returns false if the time unit is invalid.
+func nextIntervalDate(anchor, now time.Time, num int, unit string) (time.Time, bool) {
+	if c, found := calendarUnits[unit]; found {
+		months, days := num*(12*c.years+c.months), num*c.days
+
+		// skips the intervals that are surely before now, so that an old anchor is not stepped through one interval at a time
+		k := 0
+		if months > 0 {
+			elapsed := (now.Year()-anchor.Year())*12 + int(now.Month()-anchor.Month())
+			k = elapsed/months - 1
+		} else {
+			elapsed := int(now.Sub(anchor) / (24 * time.Hour))
+			k = elapsed/days - 1
+		}
+		if k < 1 {
+			k = 1
+		}
+
+		for ; ; k++ {
+			t := addCalendarDate(anchor, k*months, k*days)
+			if t.After(now) {
+				return t, true
 			}
+		}
+	}
+
+	if d, found := unitToDuration[unit]; found {
+		interval := time.Duration(num) * d
+
+		k := time.Duration(1)
+		if now.After(anchor) {
+			k = now.Sub(anchor)/interval + 1
+		}
+
+		return anchor.Add(k * interval), true
+	}
+
+	return time.Time{}, false
+}
+
+// addCalendarDate adds the months and days to t, as time.AddDate does,
+// but when the day of t does not exist on the resulting month (Ex.: February 31st),
+// the last day of that month is used instead of overflowing to the next month
+func addCalendarDate(t time.Time, months, days int) time.Time {
+	month := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+
+	day := t.Day()
+	if d := daysIn(month.Month(), month.Year()); day > d {
+		day = d
+	}
+
+	return dateIn(month.Year(), month.Month(), day+days, t.Hour(), t.Minute(), t.Second(), t.Location()).
+		Add(time.Duration(t.Nanosecond()))
+}
+
+// getNextMonthlyDate returns the next date after now of a schedule anchored to a day of the month,
+// given the monthlyScheduleRegex submatches of the schedule string.
+//
+// If the day does not exist on a month (Ex.: the 31st on April), the last day of that month is used instead.
+func getNextMonthlyDate(schedule string, m []string, now time.Time) (time.Time, error) {
+	// day 0 represents the last day of the month
+	day := 0
+	if m[1] != "" {
+		day, _ = strconv.Atoi(m[1])
+		if day < 1 || day > 31 {
+			return time.Time{}, fmt.Errorf("Failed to parse schedule format '%s', invalid day of month: %s", schedule, m[1])
+		}
+	}
+
+	var hour, minute int
+	if m[4] != "" {
+		t, err := time.Parse(hourMinuteFormat, m[4])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("Failed to parse schedule format '%s', invalid time: %s, Error: %v", schedule, m[4], err)
+		}
+		hour, minute = t.Hour(), t.Minute()
+	}
+
+	for i := 0; ; i++ {
+		month := time.Date(now.Year(), now.Month()+time.Month(i), 1, 0, 0, 0, 0, time.UTC)
+
+		d := daysIn(month.Month(), month.Year())
+		if day > 0 && day < d {
+			d = day
+		}
+
+		nextTime := dateIn(month.Year(), month.Month(), d, hour, minute, 0, now.Location())
+		if nextTime.After(now) {
 			return nextTime, nil
 		}
 	}
+}
 
-	return time.Time{}, fmt.Errorf("Invalid schedule format: %s", schedule)
+// nextWeekdayDate returns the next date after now on the weekday, at the given hour and minute.
+//
+// If the weekday is today and the time has already passed, the date of the next week is returned.
+func nextWeekdayDate(now time.Time, weekday time.Weekday, hour, minute int) time.Time {
+	daysUntilNextWeekday := int(weekday-now.Weekday()+7) % 7
+	nextTime := dateIn(now.Year(), now.Month(), now.Day()+daysUntilNextWeekday, hour, minute, 0, now.Location())
+
+	// If the specified time has already passed for today (or is right now), set it for the next week
+	if !nextTime.After(now) {
+		nextTime = dateIn(now.Year(), now.Month(), now.Day()+daysUntilNextWeekday+7, hour, minute, 0, now.Location())
+	}
+
+	return nextTime
 }
@@ -12,86 +12,213 @@ import (
 func TestGetNextScheduleDate(t *testing.T) {
 	// TIME INTERVAL TESTS
 	t.Run("Should schedule a time interval in minutes correctly", func(t *testing.T) {
-		result, err := getNextScheduleDate("5 minutes")
+		now := time.Date(2024, time.January, 15, 9, 0, 0, 0, time.UTC)
+
+		result, err := getNextScheduleDate("5 minutes", now)
 		assert.NoError(t, err)
-		assert.Equal(t, now().Add(5*time.Minute).Round(time.Second), result.Round(time.Second))
+		assert.Equal(t, now.Add(5*time.Minute), result)
 
-		result, err = getNextScheduleDate("1 minute")
+		result, err = getNextScheduleDate("1 minute", now)
 		assert.NoError(t, err)
-		assert.Equal(t, now().Add(time.Minute).Round(time.Second), result.Round(time.Second))
+		assert.Equal(t, now.Add(time.Minute), result)
 
-		result, err = getNextScheduleDate("minute")
+		result, err = getNextScheduleDate("minute", now)
 		assert.NoError(t, err)
-		assert.Equal(t, now().Add(time.Minute).Round(time.Second), result.Round(time.Second))
+		assert.Equal(t, now.Add(time.Minute), result)
 	})
 	t.Run("Should schedule a time interval in hours correctly", func(t *testing.T) {
-		result, err := getNextScheduleDate("3 hours")
+		now := time.Date(2024, time.January, 15, 9, 0, 0, 0, time.UTC)
+
+		result, err := getNextScheduleDate("3 hours", now)
 		assert.NoError(t, err)
-		assert.Equal(t, now().Add(3*time.Hour).Round(time.Second), result.Round(time.Second))
+		assert.Equal(t, now.Add(3*time.Hour), result)
 
-		result, err = getNextScheduleDate("1 hour")
+		result, err = getNextScheduleDate("1 hour", now)
 		assert.NoError(t, err)
-		assert.Equal(t, now().Add(time.Hour).Round(time.Second), result.Round(time.Second))
+		assert.Equal(t, now.Add(time.Hour), result)
 
-		result, err = getNextScheduleDate("hour")
+		result, err = getNextScheduleDate("hour", now)
 		assert.NoError(t, err)
-		assert.Equal(t, now().Add(time.Hour).Round(time.Second), result.Round(time.Second))
+		assert.Equal(t, now.Add(time.Hour), result)
 	})
 	t.Run("Should schedule a time interval in days correctly", func(t *testing.T) {
-		result, err := getNextScheduleDate("2 days")
+		now := time.Date(2024, time.January, 15, 9, 0, 0, 0, time.UTC)
+
+		result, err := getNextScheduleDate("2 days", now)
 		assert.NoError(t, err)
-		assert.Equal(t, now().Add(2*24*time.Hour).Round(time.Second), result.Round(time.Second))
+		assert.Equal(t, now.Add(2*24*time.Hour), result)
 
-		result, err = getNextScheduleDate("1 day")
+		result, err = getNextScheduleDate("1 day", now)
 		assert.NoError(t, err)
-		assert.Equal(t, now().Add(24*time.Hour).Round(time.Second), result.Round(time.Second))
+		assert.Equal(t, now.Add(24*time.Hour), result)
 
-		result, err = getNextScheduleDate("day")
+		result, err = getNextScheduleDate("day", now)
 		assert.NoError(t, err)
-		assert.Equal(t, now().Add(24*time.Hour).Round(time.Second), result.Round(time.Second))
+		assert.Equal(t, now.Add(24*time.Hour), result)
 	})
 	t.Run("Should schedule a time interval in months correctly", func(t *testing.T) {
-		result, err := getNextScheduleDate("4 months")
+		now := time.Date(2024, time.January, 15, 9, 0, 0, 0, time.UTC)
+
+		result, err := getNextScheduleDate("4 months", now)
 		assert.NoError(t, err)
-		assert.Equal(t, now().Add(4*30*24*time.Hour).Round(time.Second), result.Round(time.Second))
+		assert.Equal(t, now.AddDate(0, 4, 0), result)
 
-		result, err = getNextScheduleDate("1 month")
+		result, err = getNextScheduleDate("1 month", now)
 		assert.NoError(t, err)
-		assert.Equal(t, now().Add(30*24*time.Hour).Round(time.Second), result.Round(time.Second))
+		assert.Equal(t, now.AddDate(0, 1, 0), result)
 
-		result, err = getNextScheduleDate("month")
+		result, err = getNextScheduleDate("month", now)
+		assert.NoError(t, err)
+		assert.Equal(t, now.AddDate(0, 1, 0), result)
+	})
+	t.Run("Should schedule a time interval in months using the calendar", func(t *testing.T) {
+		result, err := getNextScheduleDate("1 month", time.Date(2024, time.February, 15, 9, 0, 0, 0, time.UTC))
 		assert.NoError(t, err)
-		assert.Equal(t, now().Add(30*24*time.Hour).Round(time.Second), result.Round(time.Second))
+		assert.Equal(t, time.Date(2024, time.March, 15, 9, 0, 0, 0, time.UTC), result)
 	})
 	t.Run("Should schedule a time interval in years correctly", func(t *testing.T) {
-		result, err := getNextScheduleDate("5 years")
+		now := time.Date(2024, time.January, 15, 9, 0, 0, 0, time.UTC)
+
+		result, err := getNextScheduleDate("5 years", now)
+		assert.NoError(t, err)
+		assert.Equal(t, now.AddDate(5, 0, 0), result)
+
+		result, err = getNextScheduleDate("1 year", now)
+		assert.NoError(t, err)
+		assert.Equal(t, now.AddDate(1, 0, 0), result)
+
+		result, err = getNextScheduleDate("year", now)
+		assert.NoError(t, err)
+		assert.Equal(t, now.AddDate(1, 0, 0), result)
+	})
+	t.Run("Should schedule a time interval in years using the calendar", func(t *testing.T) {
+		result, err := getNextScheduleDate("1 year", time.Date(2023, time.June, 1, 9, 0, 0, 0, time.UTC))
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.June, 1, 9, 0, 0, 0, time.UTC), result)
+	})
+	t.Run("Should use the last day of the month when the anchor day does not exist, and keep the anchor day on the next months", func(t *testing.T) {
+		jan31 := time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC)
+
+		result, err := getNextScheduleDate("1 month", jan31)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC), result)
+
+		result, err = getNextAnchoredScheduleDate("1 month", jan31, result)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.March, 31, 9, 0, 0, 0, time.UTC), result)
+
+		result, err = getNextAnchoredScheduleDate("1 month", jan31, result)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.April, 30, 9, 0, 0, 0, time.UTC), result)
+
+		result, err = getNextAnchoredScheduleDate("1 month", jan31, result)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.May, 31, 9, 0, 0, 0, time.UTC), result)
+
+		// on a year without February 29th
+		result, err = getNextScheduleDate("1 month", time.Date(2023, time.January, 31, 9, 0, 0, 0, time.UTC))
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2023, time.February, 28, 9, 0, 0, 0, time.UTC), result)
+	})
+	t.Run("Should use February 28th for a year interval anchored on February 29th, and keep the anchor day on leap years", func(t *testing.T) {
+		feb29 := time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC)
+
+		result, err := getNextScheduleDate("1 year", feb29)
 		assert.NoError(t, err)
-		assert.Equal(t, now().Add(5*365*24*time.Hour).Round(time.Second), result.Round(time.Second))
+		assert.Equal(t, time.Date(2025, time.February, 28, 9, 0, 0, 0, time.UTC), result)
 
-		result, err = getNextScheduleDate("1 year")
+		result, err = getNextAnchoredScheduleDate("1 year", feb29, time.Date(2027, time.March, 1, 9, 0, 0, 0, time.UTC))
 		assert.NoError(t, err)
-		assert.Equal(t, now().Add(365*24*time.Hour).Round(time.Second), result.Round(time.Second))
+		assert.Equal(t, time.Date(2028, time.February, 29, 9, 0, 0, 0, time.UTC), result)
 
-		result, err = getNextScheduleDate("year")
+		result, err = getNextScheduleDate("1 month", feb29)
 		assert.NoError(t, err)
-		assert.Equal(t, now().Add(365*24*time.Hour).Round(time.Second), result.Round(time.Second))
+		assert.Equal(t, time.Date(2024, time.March, 29, 9, 0, 0, 0, time.UTC), result)
+	})
+	t.Run("Should count the time intervals from the anchor, and not from now", func(t *testing.T) {
+		anchor := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+		result, err := getNextAnchoredScheduleDate("1 hour", anchor, time.Date(2024, time.January, 1, 11, 20, 0, 0, time.UTC))
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC), result)
+
+		result, err = getNextAnchoredScheduleDate("2 days", anchor, time.Date(2024, time.January, 4, 10, 0, 0, 0, time.UTC))
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 5, 9, 0, 0, 0, time.UTC), result)
+
+		// the intervals that were missed are skipped
+		result, err = getNextAnchoredScheduleDate("3 months", anchor, time.Date(2025, time.February, 1, 9, 0, 0, 0, time.UTC))
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2025, time.April, 1, 9, 0, 0, 0, time.UTC), result)
+
+		// the anchor is ignored by the other schedule formats
+		result, err = getNextAnchoredScheduleDate("12:00", anchor, time.Date(2024, time.January, 3, 10, 0, 0, 0, time.UTC))
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 3, 12, 0, 0, 0, time.UTC), result)
+	})
+	t.Run("Should fail if the duration is not positive", func(t *testing.T) {
+		_, err := getNextScheduleDate("0 minutes", time.Now().UTC())
+		assert.ErrorContains(t, err, "Failed to parse schedule format '0 minutes', invalid duration: 0")
 	})
 	t.Run("Should fail if the duration unit is invalid", func(t *testing.T) {
-		_, err := getNextScheduleDate("5 bananas")
+		_, err := getNextScheduleDate("5 bananas", time.Now().UTC())
 		assert.Equal(t, "Failed to parse schedule format '5 bananas', invalid time unit: bananas", err.Error())
 	})
 	t.Run("Should fail if the time unit is invalid", func(t *testing.T) {
-		_, err := getNextScheduleDate("two minutes")
+		_, err := getNextScheduleDate("two minutes", time.Now().UTC())
 		assert.Contains(t, err.Error(), "Failed to parse schedule format 'two minutes', invalid duration: two")
 	})
 
+	// DAY OF MONTH TESTS
+	t.Run("Should schedule for a day of the month correctly", func(t *testing.T) {
+		now := time.Date(2024, time.January, 15, 10, 0, 0, 0, time.UTC)
+
+		result, err := getNextScheduleDate("every month on the 1st at 09:00", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.February, 1, 9, 0, 0, 0, time.UTC), result)
+
+		result, err = getNextScheduleDate("month on the 20th", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 20, 0, 0, 0, 0, time.UTC), result)
+
+		result, err = getNextScheduleDate("month on the 15th at 11:30", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 15, 11, 30, 0, 0, time.UTC), result)
+	})
+	t.Run("Should schedule for the last day of the month if the day does not exist on the month", func(t *testing.T) {
+		now := time.Date(2024, time.February, 10, 10, 0, 0, 0, time.UTC)
+
+		result, err := getNextScheduleDate("every month on the 31st at 09:00", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC), result)
+	})
+	t.Run("Should schedule for the last day of the month correctly", func(t *testing.T) {
+		now := time.Date(2023, time.February, 28, 20, 0, 0, 0, time.UTC)
+
+		result, err := getNextScheduleDate("last day of month at 18:00", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2023, time.March, 31, 18, 0, 0, 0, time.UTC), result)
+
+		result, err = getNextScheduleDate("every month on the last day", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2023, time.March, 31, 0, 0, 0, 0, time.UTC), result)
+	})
+	t.Run("Should fail if the day of the month is invalid", func(t *testing.T) {
+		_, err := getNextScheduleDate("every month on the 32nd", time.Now().UTC())
+		assert.Equal(t, "Failed to parse schedule format 'every month on the 32nd', invalid day of month: 32", err.Error())
+
+		_, err = getNextScheduleDate("every month on the 1st at 9h", time.Now().UTC())
+		assert.Contains(t, err.Error(), "Failed to parse schedule format 'every month on the 1st at 9h', invalid time: 9h")
+	})
+
 	// TIME FORMATS TEST
 	t.Run("Should set the time for today, if the specified hour did not pass already", func(t *testing.T) {
-		now := now()
+		// its a wednesday
+		now := time.Date(2024, time.January, 3, 12, 30, 0, 0, time.UTC)
 		scheduledTime := now.Add(2 * time.Hour)
 
 		scheduleString := fmt.Sprintf("%02d:%02d", scheduledTime.Hour(), scheduledTime.Minute())
-		result, err := getNextScheduleDate(scheduleString)
+		result, err := getNextScheduleDate(scheduleString, now)
 
 		assert.NoError(t, err)
 
@@ -100,11 +227,12 @@ func TestGetNextScheduleDate(t *testing.T) {
 		assert.Equal(t, scheduledTime.Minute(), result.Minute())
 	})
 	t.Run("Should set the time for tomorrow, if the specified hour already passed", func(t *testing.T) {
-		now := now()
+		// its a wednesday
+		now := time.Date(2024, time.January, 3, 12, 30, 0, 0, time.UTC)
 		scheduledTime := now.Add(-2 * time.Hour)
 
 		scheduleString := fmt.Sprintf("%02d:%02d", scheduledTime.Hour(), scheduledTime.Minute())
-		result, err := getNextScheduleDate(scheduleString)
+		result, err := getNextScheduleDate(scheduleString, now)
 
 		assert.NoError(t, err)
 
@@ -115,30 +243,33 @@ func TestGetNextScheduleDate(t *testing.T) {
 
 	// WEEKDAY TESTS
 	t.Run("Should schedule for the next weekday correctly when the specified weekday has not passed", func(t *testing.T) {
-		now := now()
+		// its a wednesday
+		now := time.Date(2024, time.January, 3, 12, 30, 0, 0, time.UTC)
 		scheduledWeekday := now.Add(time.Hour * 48).Weekday()
 		scheduledString := strings.ToLower(scheduledWeekday.String())
 
-		result, err := getNextScheduleDate(scheduledString)
+		result, err := getNextScheduleDate(scheduledString, now)
 
 		assert.NoError(t, err)
 		assert.Equal(t, 2, result.Day()-now.Day())
 	})
 	t.Run("Should schedule for the next weekday correctly when the specified weekday already passed", func(t *testing.T) {
-		now := now()
+		// its a wednesday
+		now := time.Date(2024, time.January, 3, 12, 30, 0, 0, time.UTC)
 		scheduledWeekday := now.Add(-time.Hour * 48).Weekday()
 		scheduledString := strings.ToLower(scheduledWeekday.String())
 
-		result, err := getNextScheduleDate(scheduledString)
+		result, err := getNextScheduleDate(scheduledString, now)
 
 		assert.NoError(t, err)
 		assert.Equal(t, 5, result.Day()-now.Day())
 	})
 	t.Run("Should schedule for the next week if the specified weekday is today", func(t *testing.T) {
-		now := now()
+		// its a wednesday
+		now := time.Date(2024, time.January, 3, 12, 30, 0, 0, time.UTC)
 		scheduledString := strings.ToLower(now.Weekday().String())
 
-		result, err := getNextScheduleDate(scheduledString)
+		result, err := getNextScheduleDate(scheduledString, now)
 
 		assert.NoError(t, err)
 		assert.Equal(t, 7, result.Day()-now.Day())
@@ -146,7 +277,8 @@ func TestGetNextScheduleDate(t *testing.T) {
 
 	// WEEKDAY AND TIME TESTS
 	t.Run("Should schedule for the next weekday with the correct time when the specified weekday has not passed", func(t *testing.T) {
-		now := now()
+		// its a wednesday
+		now := time.Date(2024, time.January, 3, 12, 30, 0, 0, time.UTC)
 		scheduledTime := now.Add(time.Hour * 48)
 
 		weekdayString := strings.ToLower(scheduledTime.Weekday().String())
@@ -154,7 +286,7 @@ func TestGetNextScheduleDate(t *testing.T) {
 
 		scheduledString := fmt.Sprintf("%s at %s", weekdayString, timeString)
 
-		result, err := getNextScheduleDate(scheduledString)
+		result, err := getNextScheduleDate(scheduledString, now)
 
 		assert.NoError(t, err)
 
@@ -163,7 +295,8 @@ func TestGetNextScheduleDate(t *testing.T) {
 		assert.Equal(t, scheduledTime.Minute(), result.Minute())
 	})
 	t.Run("Should schedule for the next weekday with the correct time when the specified weekday already passed", func(t *testing.T) {
-		now := now()
+		// its a wednesday
+		now := time.Date(2024, time.January, 3, 12, 30, 0, 0, time.UTC)
 		scheduledTime := now.Add(-time.Hour * 48)
 
 		weekdayString := strings.ToLower(scheduledTime.Weekday().String())
@@ -171,7 +304,7 @@ func TestGetNextScheduleDate(t *testing.T) {
 
 		scheduledString := fmt.Sprintf("%s at %s", weekdayString, timeString)
 
-		result, err := getNextScheduleDate(scheduledString)
+		result, err := getNextScheduleDate(scheduledString, now)
 
 		assert.NoError(t, err)
 
@@ -180,7 +313,8 @@ func TestGetNextScheduleDate(t *testing.T) {
 		assert.Equal(t, scheduledTime.Minute(), result.Minute())
 	})
 	t.Run("Should schedule for today if the weekday is today, but the time has not passed already", func(t *testing.T) {
-		now := now()
+		// its a wednesday
+		now := time.Date(2024, time.January, 3, 12, 30, 0, 0, time.UTC)
 		scheduledTime := now.Add(time.Hour * 2)
 
 		weekdayString := strings.ToLower(scheduledTime.Weekday().String())
@@ -188,7 +322,7 @@ func TestGetNextScheduleDate(t *testing.T) {
 
 		scheduledString := fmt.Sprintf("%s at %s", weekdayString, timeString)
 
-		result, err := getNextScheduleDate(scheduledString)
+		result, err := getNextScheduleDate(scheduledString, now)
 
 		assert.NoError(t, err)
 
@@ -197,7 +331,8 @@ func TestGetNextScheduleDate(t *testing.T) {
 		assert.Equal(t, scheduledTime.Minute(), result.Minute())
 	})
 	t.Run("Should schedule for next week if the weekday is today and the time has already passed", func(t *testing.T) {
-		now := now()
+		// its a wednesday
+		now := time.Date(2024, time.January, 3, 12, 30, 0, 0, time.UTC)
 		scheduledTime := now.Add(-time.Hour * 2)
 
 		weekdayString := strings.ToLower(scheduledTime.Weekday().String())
@@ -205,7 +340,7 @@ func TestGetNextScheduleDate(t *testing.T) {
 
 		scheduledString := fmt.Sprintf("%s at %s", weekdayString, timeString)
 
-		result, err := getNextScheduleDate(scheduledString)
+		result, err := getNextScheduleDate(scheduledString, now)
 
 		assert.NoError(t, err)
 
@@ -213,4 +348,93 @@ func TestGetNextScheduleDate(t *testing.T) {
 		assert.Equal(t, scheduledTime.Hour(), result.Hour())
 		assert.Equal(t, scheduledTime.Minute(), result.Minute())
 	})
+	t.Run("Should schedule for the next occurrence if now is exactly the scheduled time", func(t *testing.T) {
+		// its a monday
+		now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+		result, err := getNextScheduleDate("monday at 12:00", now)
+		assert.NoError(t, err)
+		assert.Equal(t, now.AddDate(0, 0, 7), result)
+
+		result, err = getNextScheduleDate("12:00", now)
+		assert.NoError(t, err)
+		assert.Equal(t, now.AddDate(0, 0, 1), result)
+	})
+}
+
+func TestGetNextScheduleDateLocation(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	t.Run("Should schedule on the location of now", func(t *testing.T) {
+		// saturday
+		now := time.Date(2024, time.January, 13, 10, 0, 0, 0, newYork)
+
+		result, err := getNextScheduleDate("monday at 09:00", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 15, 9, 0, 0, 0, newYork), result)
+
+		result, err = getNextScheduleDate("09:00", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 14, 9, 0, 0, 0, newYork), result)
+
+		result, err = getNextScheduleDate("sunday", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.January, 14, 0, 1, 0, 0, newYork), result)
+	})
+	t.Run("Should keep the wall clock time when a day interval crosses a DST transition", func(t *testing.T) {
+		// the DST starts on 2024-03-10 at 02:00
+		now := time.Date(2024, time.March, 9, 9, 0, 0, 0, newYork)
+
+		result, err := getNextScheduleDate("1 day", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.March, 10, 9, 0, 0, 0, newYork), result)
+		assert.Equal(t, 23*time.Hour, result.Sub(now))
+	})
+	t.Run("Should shift forward a time skipped by a DST transition", func(t *testing.T) {
+		now := time.Date(2024, time.March, 9, 9, 0, 0, 0, newYork)
+
+		result, err := getNextScheduleDate("02:30", now)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2024, time.March, 10, 3, 30, 0, 0, newYork), result)
+	})
+	t.Run("Should schedule a time repeated by a DST transition on its first occurrence", func(t *testing.T) {
+		// the DST ends on 2024-11-03 at 02:00
+		now := time.Date(2024, time.November, 2, 9, 0, 0, 0, newYork)
+
+		result, err := getNextScheduleDate("01:30", now)
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-11-03T01:30:00-04:00", result.Format(time.RFC3339))
+	})
+	t.Run("Should shift forward cron times skipped by a DST transition", func(t *testing.T) {
+		// the DST starts on 2025-03-09 at 02:00
+		now := time.Date(2025, time.March, 8, 2, 30, 0, 0, newYork)
+
+		result, err := getNextScheduleDate("0 30 2 * * *", now)
+		assert.NoError(t, err)
+		assert.Equal(t, "2025-03-09T03:30:00-04:00", result.Format(time.RFC3339))
+
+		// the day after the transition runs on its own wall clock again
+		result, err = getNextScheduleDate("0 30 2 * * *", result)
+		assert.NoError(t, err)
+		assert.Equal(t, "2025-03-10T02:30:00-04:00", result.Format(time.RFC3339))
+
+		result, err = getNextScheduleDate("0 * * * *", time.Date(2024, time.March, 10, 1, 30, 0, 0, newYork))
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-03-10T03:00:00-04:00", result.Format(time.RFC3339))
+
+		// the shifted run is not repeated by the wall clock it was shifted to
+		result, err = getNextScheduleDate("0 * * * *", result)
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-03-10T04:00:00-04:00", result.Format(time.RFC3339))
+	})
+	t.Run("Should not schedule cron times before now during a repeated hour", func(t *testing.T) {
+		// the second occurrence of 01:20 on 2024-11-03
+		now := time.Date(2024, time.November, 3, 1, 20, 0, 0, newYork).Add(time.Hour)
+		assert.Equal(t, "2024-11-03T01:20:00-05:00", now.Format(time.RFC3339))
+
+		result, err := getNextScheduleDate("*/15 * * * *", now)
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-11-03T01:30:00-05:00", result.Format(time.RFC3339))
+	})
 }
@@ -1,28 +1,142 @@
 package scheduler
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 )
 
-// start library base values
-var (
-	// db its the library designated database
-	db JobDatabase = &emptyDB{}
+// Scheduler represents a scheduler instance, that schedules, persists and processes jobs on its own job database.
+//
+// Many schedulers can run on the same application (Ex.: against different mongo collections),
+// each one with its own configuration and job definitions.
+type Scheduler struct {
+	// db its the scheduler designated database
+	db JobDatabase
 
-	// logger its the library designated logger
-	logger Logger = &emptyLogger{}
+	// dbTimeout its the maximum duration of each call to the job database
+	dbTimeout time.Duration
 
-	// jobDefinitions maps the job names to its designated functions
-	jobDefinitions map[string]*JobFunc = make(map[string]*JobFunc, 0)
-)
+	// logger its the scheduler designated logger
+	logger Logger
+
+	// jobDefinitions maps the job names to its designated definitions
+	jobDefinitions   map[string]*jobDefinition
+	jobDefinitionsMu sync.RWMutex
+
+	// location its the location used when generating time values
+	location *time.Location
+
+	// clock its the source of the current time and of the tickers
+	clock Clock
+
+	// deleteOnDone defines if, when a job is done, the job should be deleted from the database
+	deleteOnDone bool
+
+	// deleteOnCancel defines if, when a job is canceled, the job should be deleted from the database
+	deleteOnCancel bool
+
+	// defaultJobTimeout its the timeout used when neither the job or its definition have a timeout
+	defaultJobTimeout time.Duration
+
+	// jobGracePeriod its for how long a job function is waited for after its context is done,
+	// before the run is recorded as timed out or canceled
+	jobGracePeriod time.Duration
+
+	// retryPolicy its the default retry policy used when a job has no retry policy of its own
+	retryPolicy *RetryPolicy
+
+	// leaseDuration its the duration of the lease that the scheduler holds on a RUNNING job
+	leaseDuration time.Duration
+
+	// maxOrphanings its the number of times a job can be orphaned before it is set as FAILED
+	maxOrphanings int
+
+	// runHistory defines if every job run should be recorded on the database
+	runHistory bool
+
+	// runRetention its for how long the recorded job runs are kept on the database
+	runRetention time.Duration
+
+	// workerID identifies the scheduler instance when claiming jobs
+	workerID string
+
+	// workers limits how many jobs the scheduler can run at the same time
+	workers chan struct{}
+
+	// claimedJobs maps the jobs that are currently running on the scheduler
+	// to the state they had when they were claimed
+	claimedJobs   map[*Job]Job
+	claimedJobsMu sync.Mutex
+
+	// idle is closed when there are no jobs running on the scheduler
+	idle chan struct{}
+
+	// abandonedJobFuncs counts the job functions that did not return after their context was done and were abandoned,
+	// that are still running on the background
+	abandonedJobFuncs int
+
+	// stopping is closed when the scheduler is shutting down, so that no new jobs are picked
+	stopping chan struct{}
+
+	// jobsCtx its the context from which the running jobs contexts derive,
+	// its cancelled when the scheduler fails to drain the running jobs while shutting down
+	jobsCtx    context.Context
+	cancelJobs context.CancelFunc
+
+	// processingDone is closed when the processing loop returns
+	processingDone chan struct{}
+
+	// cycleMu serializes the processing cycles, so that the cycles ran by ProcessCycle do not overlap the ones of the processing loop
+	cycleMu sync.Mutex
+
+	// lifecycleMu guards the scheduler start and shutdown
+	lifecycleMu sync.Mutex
+}
 
-// Init initis the scheduler library
-func Init(c Config) (err error) {
+// newScheduler creates a scheduler with the default values, that is not able to manage jobs yet
+func newScheduler() *Scheduler {
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+
+	return &Scheduler{
+		db:             &emptyDB{},
+		dbTimeout:      30 * time.Second,
+		logger:         &emptyLogger{},
+		jobDefinitions: make(map[string]*jobDefinition, 0),
+		location:       time.UTC,
+		clock:          systemClock{},
+		jobGracePeriod: 5 * time.Second,
+		leaseDuration:  5 * time.Minute,
+		maxOrphanings:  3,
+		workerID:       defaultWorkerID(),
+		workers:        make(chan struct{}, 1),
+		claimedJobs:    make(map[*Job]Job),
+		idle:           closedChannel(),
+		stopping:       make(chan struct{}),
+		jobsCtx:        jobsCtx,
+		cancelJobs:     cancelJobs,
+	}
+}
+
+// New creates a new scheduler instance given the configuration, and starts processing its jobs.
+func New(c Config) (s *Scheduler, err error) {
+	s = newScheduler()
+
+	err = s.init(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// init configures the scheduler and starts processing its jobs
+func (s *Scheduler) init(c Config) (err error) {
 	switch {
 	case c.DB != nil:
-		db = c.DB
+		s.db = c.DB
 
 	case c.MongoDB != nil && c.MongoDB.Conn != nil:
 		if c.MongoDB.DbName == "" {
@@ -31,21 +145,56 @@ func Init(c Config) (err error) {
 		if c.MongoDB.CollName == "" {
 			c.MongoDB.CollName = "scheduler-jobs"
 		}
-		db = newMongo(c.MongoDB.Conn, c.MongoDB.DbName, c.MongoDB.CollName)
+		if c.MongoDB.RunsCollName == "" {
+			c.MongoDB.RunsCollName = "scheduler-runs"
+		}
+		s.db = newMongo(c.MongoDB.Conn, c.MongoDB.DbName, c.MongoDB.CollName, c.MongoDB.RunsCollName)
+
+	case c.Postgres != nil && c.Postgres.Conn != nil:
+		if c.Postgres.TableName == "" {
+			c.Postgres.TableName = "scheduler_jobs"
+		}
+		if c.Postgres.RunsTableName == "" {
+			c.Postgres.RunsTableName = "scheduler_runs"
+		}
+		s.db, err = newPostgres(c.Postgres.Conn, c.Postgres.TableName, c.Postgres.RunsTableName)
+		if err != nil {
+			return
+		}
+
+	case c.Redis != nil && c.Redis.Client != nil:
+		if c.Redis.Prefix == "" {
+			c.Redis.Prefix = "{scheduler}"
+		}
+		s.db = newRedis(c.Redis.Client, c.Redis.Prefix)
+
+	case c.File != nil && c.File.Path != "":
+		s.db = NewFileJobDB(c.File.Path)
 
 	default:
 		err = errors.New("No job DB or mongo conection was provided")
 		return
 	}
 
-	err = db.InitJobDB()
+	if c.DBTimeout > 0 {
+		s.dbTimeout = c.DBTimeout
+	}
+
+	if c.Clock != nil {
+		s.clock = c.Clock
+	}
+
+	ctx, cancel := s.dbContext()
+	defer cancel()
+
+	err = s.db.InitJobDB(ctx)
 	if err != nil {
 		err = fmt.Errorf("Failed to init job db, %v", err)
 		return
 	}
 
 	if c.Logger != nil {
-		logger = c.Logger
+		s.logger = c.Logger
 	}
 
 	if c.ProcessingRate.Seconds() == float64(0) {
@@ -58,31 +207,62 @@ func Init(c Config) (err error) {
 			return fmt.Errorf("Failed to load the provided location '%s', %v", c.Location, err)
 		}
 
-		location = l
+		s.location = l
 	}
 
-	deleteOnCancel = c.DeleteOnCancel
-	deleteOnDone = c.DeleteOnDone
+	if c.Concurrency > 0 {
+		s.workers = make(chan struct{}, c.Concurrency)
+	}
+
+	s.defaultJobTimeout = c.JobTimeout
+
+	if c.RetryPolicy != nil {
+		s.retryPolicy = c.RetryPolicy
+	}
 
-	go processJobs(c.ProcessingRate)
+	if c.LeaseDuration > 0 {
+		s.leaseDuration = c.LeaseDuration
+	}
+
+	if c.MaxOrphanings != 0 {
+		s.maxOrphanings = c.MaxOrphanings
+	}
+
+	if c.WorkerID != "" {
+		s.workerID = c.WorkerID
+	}
+
+	s.runHistory = c.RunHistory
+	s.runRetention = c.RunRetention
+
+	s.deleteOnCancel = c.DeleteOnCancel
+	s.deleteOnDone = c.DeleteOnDone
+
+	s.startProcessing(c.ProcessingRate)
 	return
 }
 
 // Define inserts a new job definition
 // given the job name and the function to be called when that job is triggered.
 //
+// Optionally, definition options can be provided (Ex.: to limit how many jobs with this name can run at the same time).
+//
 // If the jobName was already previously defined, the previous function will be overridden
-func Define(jobName string, fn JobFunc) {
-	jobDefinitions[jobName] = &fn
+func (s *Scheduler) Define(jobName string, fn JobFunc, options ...DefinitionOptions) {
+	s.jobDefinitionsMu.Lock()
+	defer s.jobDefinitionsMu.Unlock()
+
+	s.jobDefinitions[jobName] = newJobDefinition(fn, options...)
 }
 
 // In creates a new definition of a SIMPLE and PENDING job to be run once in the provided duration.
 //
 // This function does not save the schedule in the database yet,
 // the function Do must be called subsequently so that the job can be defined and saved.
-func In(d time.Duration) *simpleScheduleDefinition {
+func (s *Scheduler) In(d time.Duration) *simpleScheduleDefinition {
 	return &simpleScheduleDefinition{
-		nextRunAt: now().Add(d),
+		scheduler: s,
+		nextRunAt: s.now().Add(d),
 	}
 }
 
@@ -92,9 +272,10 @@ func In(d time.Duration) *simpleScheduleDefinition {
 // the function Do must be called subsequently so that the job can be validated and saved.
 //
 // IMPORTANT: Please note that, for the library flow to function correctly,
-// the provided time value (t) should be in the same timezone as configured during the library instantiation.
-func On(t time.Time) *simpleScheduleDefinition {
+// the provided time value (t) should be in the same timezone as configured during the scheduler instantiation.
+func (s *Scheduler) On(t time.Time) *simpleScheduleDefinition {
 	return &simpleScheduleDefinition{
+		scheduler: s,
 		nextRunAt: t,
 	}
 }
@@ -103,20 +284,100 @@ func On(t time.Time) *simpleScheduleDefinition {
 //
 // The schedule string expects the following formats:
 //
-// - A time interval string (Ex.: "1 minute", "2 months", "6 years")
+// - A time interval string (Ex.: "1 minute", "2 months", "6 years"), months and years follow the calendar
+//
+// - A day of the month string, with an optional time (Ex.: "every month on the 1st at 09:00", "last day of month")
 //
 // - A time string in HH:MM format (Ex.: "11:27")
 //
 // - A weekday string (Ex.: "monday", "friday")
 //
 // - A weekday and time string (Ex.: "monday at 12:00", "friday at 15:08")
-func Every(schedule string) *recurrentScheduleDefinition {
+//
+// - A 5 field (minute, hour, day of month, month, day of week) or 6 field (with a leading second) cron expression,
+// supporting ranges, steps, lists, "L", "W" and "#" (Ex.: "*/15 * * * *", "0 30 9 * * MON-FRI", "0 0 L * *", "0 12 * * FRI#2")
+//
+// - A cron macro (Ex.: "@hourly", "@daily", "@weekly", "@monthly", "@yearly")
+//
+// The schedule string is validated when Do is called, and the next run dates are computed on the scheduler location.
+func (s *Scheduler) Every(schedule string) *recurrentScheduleDefinition {
 	return &recurrentScheduleDefinition{
-		schedule: schedule,
+		scheduler: s,
+		schedule:  schedule,
 	}
 }
 
 // List lists jobs on the database given the finder.
-func List(f Finder) ([]*Job, error) {
-	return db.List(f)
+//
+// If the finder has Fields, the jobs are listed with a projection, and are read-only.
+func (s *Scheduler) List(f Finder) (js []*Job, err error) {
+	err = f.checkFields()
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := s.dbContext()
+	defer cancel()
+
+	js, err = s.db.List(ctx, f)
+	if len(f.Fields) > 0 {
+		for i, j := range js {
+			js[i] = f.project(j)
+		}
+	}
+	s.attach(js)
+
+	return
+}
+
+// Count counts the jobs on the database given the finder.
+//
+// The finder sorting and pagination values are ignored.
+func (s *Scheduler) Count(f Finder) (int, error) {
+	ctx, cancel := s.dbContext()
+	defer cancel()
+
+	return s.db.Count(ctx, f)
+}
+
+// attach attaches the jobs to the scheduler, so that the job methods (Ex.: Done, Cancel) use the scheduler database
+func (s *Scheduler) attach(js []*Job) {
+	for _, j := range js {
+		j.scheduler = s
+	}
+}
+
+// dbContext returns the context that should be used on a call to the job database
+func (s *Scheduler) dbContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(withNow(context.Background(), s.now()), s.dbTimeout)
+}
+
+// createJob saves a new job on the job database,
+// and returns it attached to the scheduler, with the ID assigned by the database.
+//
+// If the job has a unique key, the conflict policy defines what happens when the key is already used by another job.
+func (s *Scheduler) createJob(j Job, cp ConflictPolicy) (*Job, error) {
+	if j.UniqueKey != "" {
+		return s.saveUniqueJob(j, cp)
+	}
+
+	ctx, cancel := s.dbContext()
+	defer cancel()
+
+	id, err := s.db.SaveJob(ctx, j)
+	if err != nil {
+		return nil, err
+	}
+
+	j.ID = id
+	j.scheduler = s
+	return &j, nil
+}
+
+// getJobDefinition returns the job definition given the job name, or nil if the job was not defined
+func (s *Scheduler) getJobDefinition(jobName string) *jobDefinition {
+	s.jobDefinitionsMu.RLock()
+	defer s.jobDefinitionsMu.RUnlock()
+
+	return s.jobDefinitions[jobName]
 }
@@ -0,0 +1,289 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("Should fail if no job DB or mongo connection was provided", func(t *testing.T) {
+		s, err := New(Config{})
+
+		assert.Nil(t, s)
+		assert.EqualError(t, err, "No job DB or mongo conection was provided")
+	})
+	t.Run("Should fail if the job DB fails to init", func(t *testing.T) {
+		dbMock := newDatabaseMock()
+		dbMock.SetMethodResponse("InitJobDB", errors.New("mock!!"))
+
+		s, err := New(Config{DB: dbMock})
+
+		assert.Nil(t, s)
+		assert.EqualError(t, err, "Failed to init job db, mock!!")
+	})
+	t.Run("Should fail if the location is invalid", func(t *testing.T) {
+		s, err := New(Config{DB: newDatabaseMock(), Location: "Nowhere/Invalid"})
+
+		assert.Nil(t, s)
+		assert.Contains(t, err.Error(), "Failed to load the provided location 'Nowhere/Invalid'")
+	})
+	t.Run("Should create a scheduler with the provided configuration", func(t *testing.T) {
+		dbMock := newDatabaseMock()
+
+		s, err := New(Config{
+			DB:             dbMock,
+			ProcessingRate: time.Hour,
+			Location:       "America/Sao_Paulo",
+			Concurrency:    4,
+			WorkerID:       "my-worker",
+			DeleteOnDone:   true,
+		})
+		assert.NoError(t, err)
+		defer s.Stop()
+
+		assert.True(t, dbMock.Method("InitJobDB").CalledOnce())
+		assert.Equal(t, "America/Sao_Paulo", s.location.String())
+		assert.Equal(t, 4, cap(s.workers))
+		assert.Equal(t, "my-worker", s.workerID)
+		assert.True(t, s.deleteOnDone)
+	})
+}
+
+func TestSchedulerIsolation(t *testing.T) {
+	t.Run("Should keep job definitions and databases separated between schedulers", func(t *testing.T) {
+		s1, dbMock1, _ := mockScheduler()
+		s2, dbMock2, _ := mockScheduler()
+
+		s1.Define("MYMOCKJOB!", func(ctx context.Context, j *Job) error { return nil })
+
+		_, err := s1.In(time.Hour).Do("MYMOCKJOB!")
+		assert.NoError(t, err)
+
+		_, err = s2.In(time.Hour).Do("MYMOCKJOB!")
+		assert.EqualError(t, err, "No job definition with the name MYMOCKJOB! was found")
+
+		assert.True(t, dbMock1.Method("SaveJob").CalledOnce())
+		assert.False(t, dbMock2.Method("SaveJob").Called())
+	})
+	t.Run("Should manage listed jobs on the scheduler that listed them", func(t *testing.T) {
+		s1, dbMock1, _ := mockScheduler()
+		_, dbMock2, _ := mockScheduler()
+
+		dbMock1.SetMethodResponse("List", []*Job{{Name: "MYMOCKJOB!"}}, nil)
+
+		js, err := s1.List(Finder{})
+		assert.NoError(t, err)
+
+		err = js[0].Cancel()
+		assert.NoError(t, err)
+
+		assert.True(t, dbMock1.Method("SaveJob").CalledOnce())
+		assert.False(t, dbMock2.Called())
+		assert.NoError(t, s1.Shutdown(context.Background()))
+	})
+}
+
+func TestDo(t *testing.T) {
+	t.Run("Should return the saved SIMPLE job with the ID assigned by the database", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+		s.Define("MYMOCKJOB!", func(ctx context.Context, j *Job) error { return nil })
+
+		dbMock.SetMethodResponse("SaveJob", "MYMOCKID", nil)
+
+		j, err := s.In(time.Hour).Do("MYMOCKJOB!", map[string]any{"key": "value"})
+		assert.NoError(t, err)
+		assert.Equal(t, "MYMOCKID", j.ID)
+		assert.Equal(t, "MYMOCKJOB!", j.Name)
+		assert.Equal(t, "value", j.Data["key"])
+		assert.True(t, j.IsPending())
+		assert.True(t, j.IsSimple())
+		assert.Equal(t, s, j.scheduler)
+	})
+	t.Run("Should return the saved RECURRENT job with the ID assigned by the database", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+		s.Define("MYMOCKJOB!", func(ctx context.Context, j *Job) error { return nil })
+
+		dbMock.SetMethodResponse("SaveJob", "MYMOCKID", nil)
+
+		j, err := s.Every("@daily").Do("MYMOCKJOB!")
+		assert.NoError(t, err)
+		assert.Equal(t, "MYMOCKID", j.ID)
+		assert.Equal(t, "@daily", j.ScheduleString)
+		assert.True(t, j.IsRecurrent())
+	})
+	t.Run("Should return an error and no job if the database fails to save the job", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+		s.Define("MYMOCKJOB!", func(ctx context.Context, j *Job) error { return nil })
+
+		dbMock.SetMethodResponse("SaveJob", "", errors.New("MOCK ERROR!"))
+
+		j, err := s.In(time.Hour).Do("MYMOCKJOB!")
+		assert.EqualError(t, err, "MOCK ERROR!")
+		assert.Nil(t, j)
+	})
+	t.Run("Should return an error and not save the job if the schedule string is invalid", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+		s.Define("MYMOCKJOB!", func(ctx context.Context, j *Job) error { return nil })
+
+		j, err := s.Every("5 bananas").Do("MYMOCKJOB!")
+		assert.Error(t, err)
+		assert.Nil(t, j)
+		assert.False(t, dbMock.Method("SaveJob").Called())
+	})
+	t.Run("When the job is unique", func(t *testing.T) {
+		t.Run("Should save the job with the unique key", func(t *testing.T) {
+			s, dbMock, _ := mockScheduler()
+			s.Define("MYMOCKJOB!", func(ctx context.Context, j *Job) error { return nil })
+
+			dbMock.SetMethodResponse("SaveUniqueJob", "MYMOCKID", true, nil)
+
+			j, err := s.Every("hour").Unique("sync-tenant-42").Do("MYMOCKJOB!")
+			assert.NoError(t, err)
+			assert.Equal(t, "MYMOCKID", j.ID)
+			assert.Equal(t, "sync-tenant-42", j.UniqueKey)
+			assert.False(t, dbMock.Method("SaveJob").Called())
+
+			call := dbMock.Method("SaveUniqueJob").GetCalls()[0]
+			assert.Equal(t, false, call.Args[1])
+		})
+		t.Run("Should derive the unique key from the job name and data if no key is given", func(t *testing.T) {
+			s, dbMock, _ := mockScheduler()
+			s.Define("MYMOCKJOB!", func(ctx context.Context, j *Job) error { return nil })
+
+			dbMock.SetMethodResponse("SaveUniqueJob", "MYMOCKID", true, nil)
+
+			j1, err := s.Every("hour").Unique("").Do("MYMOCKJOB!", map[string]any{"a": 1, "b": 2})
+			assert.NoError(t, err)
+
+			j2, err := s.Every("hour").Unique("").Do("MYMOCKJOB!", map[string]any{"b": 2, "a": 1})
+			assert.NoError(t, err)
+
+			j3, err := s.Every("hour").Unique("").Do("MYMOCKJOB!", map[string]any{"a": 2})
+			assert.NoError(t, err)
+
+			assert.Contains(t, j1.UniqueKey, "MYMOCKJOB!:")
+			assert.Equal(t, j1.UniqueKey, j2.UniqueKey)
+			assert.NotEqual(t, j1.UniqueKey, j3.UniqueKey)
+		})
+		t.Run("Should return the existing job if the key is already used and the policy is KEEP", func(t *testing.T) {
+			s, dbMock, _ := mockScheduler()
+			s.Define("MYMOCKJOB!", func(ctx context.Context, j *Job) error { return nil })
+
+			existing := &Job{ID: "MYEXISTINGID", UniqueKey: "sync-tenant-42", ScheduleString: "day"}
+			dbMock.SetMethodResponse("SaveUniqueJob", "MYEXISTINGID", false, nil)
+			dbMock.SetMethodResponse("GetJob", existing, nil)
+
+			j, err := s.Every("hour").Unique("sync-tenant-42").Do("MYMOCKJOB!")
+			assert.NoError(t, err)
+			assert.Equal(t, existing, j)
+			assert.True(t, dbMock.Method("GetJob").CalledWith("MYEXISTINGID"))
+		})
+		t.Run("Should replace the existing job if the policy is REPLACE", func(t *testing.T) {
+			s, dbMock, _ := mockScheduler()
+			s.Define("MYMOCKJOB!", func(ctx context.Context, j *Job) error { return nil })
+
+			dbMock.SetMethodResponse("SaveUniqueJob", "MYEXISTINGID", true, nil)
+
+			j, err := s.Every("hour").Unique("sync-tenant-42").OnConflict(REPLACE).Do("MYMOCKJOB!")
+			assert.NoError(t, err)
+			assert.Equal(t, "MYEXISTINGID", j.ID)
+			assert.Equal(t, "hour", j.ScheduleString)
+
+			call := dbMock.Method("SaveUniqueJob").GetCalls()[0]
+			assert.Equal(t, true, call.Args[1])
+		})
+		t.Run("Should return ErrDuplicateJob if the key is already used and the policy is REJECT", func(t *testing.T) {
+			s, dbMock, _ := mockScheduler()
+			s.Define("MYMOCKJOB!", func(ctx context.Context, j *Job) error { return nil })
+
+			dbMock.SetMethodResponse("SaveUniqueJob", "MYEXISTINGID", false, nil)
+
+			j, err := s.In(time.Hour).Unique("email-42").OnConflict(REJECT).Do("MYMOCKJOB!")
+			assert.ErrorIs(t, err, ErrDuplicateJob)
+			assert.Nil(t, j)
+			assert.False(t, dbMock.Method("GetJob").Called())
+		})
+	})
+}
+
+func TestList(t *testing.T) {
+	t.Run("Should list the jobs with every field if the finder has no fields", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		dbMock.SetMethodResponse("List", []*Job{{ID: "MYMOCKID", Name: "MYMOCKJOB!", Status: PENDING}}, nil)
+
+		js, err := s.List(Finder{})
+		assert.NoError(t, err)
+		assert.Equal(t, "MYMOCKJOB!", js[0].Name)
+		assert.Equal(t, PENDING, js[0].Status)
+		assert.False(t, js[0].partial)
+	})
+	t.Run("Should list the jobs filled only with their ID and the finder fields", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		nextRun := time.Now()
+		dbMock.SetMethodResponse("List", []*Job{{
+			ID:           "MYMOCKID",
+			Name:         "MYMOCKJOB!",
+			Status:       PENDING,
+			ScheduleType: RECURRENT,
+			NextRunAt:    nextRun,
+			Data:         map[string]any{"key": "value"},
+		}}, nil)
+
+		js, err := s.List(Finder{Fields: []string{"Name", "ScheduleType", "NextRunAt"}})
+		assert.NoError(t, err)
+		assert.Equal(t, "MYMOCKID", js[0].ID)
+		assert.Equal(t, "MYMOCKJOB!", js[0].Name)
+		assert.Equal(t, RECURRENT, js[0].ScheduleType)
+		assert.Equal(t, nextRun, js[0].NextRunAt)
+		assert.Empty(t, js[0].Status)
+		assert.Nil(t, js[0].Data)
+		assert.Equal(t, s, js[0].scheduler)
+	})
+	t.Run("Should not save back the jobs listed with a projection", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		dbMock.SetMethodResponse("List", []*Job{{ID: "MYMOCKID", Name: "MYMOCKJOB!", Status: PENDING}}, nil)
+
+		js, err := s.List(Finder{Fields: []string{"Name"}})
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, js[0].Done(), ErrPartialJob)
+		assert.ErrorIs(t, js[0].Fail(), ErrPartialJob)
+		assert.ErrorIs(t, js[0].Cancel(), ErrPartialJob)
+		assert.Empty(t, js[0].Status)
+		assert.False(t, dbMock.Method("SaveJob").Called())
+		assert.False(t, dbMock.Method("DeleteJob").Called())
+	})
+	t.Run("Should fail without listing if a finder field is not a job field", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		js, err := s.List(Finder{Fields: []string{"Name", "bananas"}})
+		assert.EqualError(t, err, "Failed to list jobs, 'bananas' is not a job field")
+		assert.Nil(t, js)
+		assert.False(t, dbMock.Method("List").Called())
+
+		_, err = s.List(Finder{Fields: []string{"scheduler"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestCount(t *testing.T) {
+	t.Run("Should count the jobs given the finder", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		dbMock.SetMethodResponse("Count", 42, nil)
+
+		f := Finder{Statuses: []ScheduleStatus{FAILED, TIMED_OUT}, ScheduleType: RECURRENT}
+		count, err := s.Count(f)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 42, count)
+		assert.True(t, dbMock.Method("Count").CalledWith(f))
+	})
+}
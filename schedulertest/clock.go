@@ -0,0 +1,129 @@
+package schedulertest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/delivery-much/go-scheduler"
+)
+
+// FakeClock represents a clock that only moves when it is told to, so that the schedules can be tested deterministically.
+//
+// Its tickers tick when the clock is moved past their next tick, and, as the tickers of the time package,
+// they drop the ticks that are not received in time.
+//
+// Ex.:
+//
+//	clock := schedulertest.NewFakeClock(time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC))
+//	s, _ := scheduler.New(scheduler.Config{DB: scheduler.NewMemoryJobDB(), Clock: clock})
+//
+//	s.Define("my-job", func(ctx context.Context, j *scheduler.Job) error { return nil })
+//	s.Every("monday at 12:00").Do("my-job")
+//	clock.Advance(3 * time.Hour)
+//	s.ProcessCycle(ctx)
+//	// "my-job" has ran once
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+// NewFakeClock creates a fake clock stopped at the given time
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current time of the clock
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// NewTicker returns a ticker that ticks every d duration of the clock.
+//
+// It panics if d is not positive, as time.NewTicker does.
+func (c *FakeClock) NewTicker(d time.Duration) scheduler.Ticker {
+	if d <= 0 {
+		panic("non-positive interval for FakeClock.NewTicker")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTicker{
+		clock:  c,
+		c:      make(chan time.Time, 1),
+		period: d,
+		next:   c.now.Add(d),
+	}
+	c.tickers = append(c.tickers, t)
+
+	return t
+}
+
+// Advance moves the clock forward by d, ticking the tickers that are due
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(c.now.Add(d))
+}
+
+// Set moves the clock to the given time, ticking the tickers that are due.
+//
+// The clock can also be moved backwards, in that case no ticker ticks.
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(t)
+}
+
+// set moves the clock, it should be called while holding the lock
+func (c *FakeClock) set(t time.Time) {
+	c.now = t
+
+	for _, ticker := range c.tickers {
+		if t.Before(ticker.next) {
+			continue
+		}
+
+		select {
+		case ticker.c <- t:
+		default:
+			// the previous tick was not received yet, so this one is dropped
+		}
+
+		// the next tick is the first one after now, the skipped ticks are not delivered
+		skipped := t.Sub(ticker.next) / ticker.period
+		ticker.next = ticker.next.Add((skipped + 1) * ticker.period)
+	}
+}
+
+// fakeTicker represents a ticker of the fake clock
+type fakeTicker struct {
+	clock  *FakeClock
+	c      chan time.Time
+	period time.Duration
+
+	// next its when the ticker ticks next
+	next time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	for i, ticker := range t.clock.tickers {
+		if ticker == t {
+			t.clock.tickers = append(t.clock.tickers[:i], t.clock.tickers[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package schedulertest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("Should only move when advanced or set", func(t *testing.T) {
+		clock := NewFakeClock(start)
+		assert.Equal(t, start, clock.Now())
+
+		clock.Advance(time.Hour)
+		assert.Equal(t, start.Add(time.Hour), clock.Now())
+
+		clock.Set(start)
+		assert.Equal(t, start, clock.Now())
+	})
+	t.Run("Should tick the ticker when the clock moves past its next tick", func(t *testing.T) {
+		clock := NewFakeClock(start)
+		ticker := clock.NewTicker(time.Minute)
+
+		clock.Advance(30 * time.Second)
+		assert.Empty(t, ticker.C())
+
+		clock.Advance(30 * time.Second)
+		assert.Equal(t, start.Add(time.Minute), <-ticker.C())
+	})
+	t.Run("Should drop the ticks that were not received, as the time package tickers", func(t *testing.T) {
+		clock := NewFakeClock(start)
+		ticker := clock.NewTicker(time.Minute)
+
+		clock.Advance(time.Minute)
+		clock.Advance(time.Minute)
+		assert.Equal(t, start.Add(time.Minute), <-ticker.C())
+		assert.Empty(t, ticker.C())
+
+		// the ticks skipped by a long advance are not delivered either
+		clock.Advance(10*time.Minute + 30*time.Second)
+		assert.Equal(t, start.Add(12*time.Minute+30*time.Second), <-ticker.C())
+		clock.Advance(30 * time.Second)
+		assert.Equal(t, start.Add(13*time.Minute), <-ticker.C())
+	})
+	t.Run("Should not tick the ticker after it is stopped", func(t *testing.T) {
+		clock := NewFakeClock(start)
+		ticker := clock.NewTicker(time.Minute)
+		ticker.Stop()
+
+		clock.Advance(time.Hour)
+		assert.Empty(t, ticker.C())
+	})
+	t.Run("Should panic if the ticker interval is not positive", func(t *testing.T) {
+		assert.Panics(t, func() { NewFakeClock(start).NewTicker(0) })
+	})
+}
@@ -0,0 +1,1071 @@
+// Package schedulertest provides helpers to test the applications and the integrations of the go-scheduler library.
+package schedulertest
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/delivery-much/go-scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+// DatabaseFactory creates a new and empty job database, that is used by a single test of the database suite.
+//
+// The factory should clean up the database when the test ends (See testing.T.Cleanup), if its needed.
+type DatabaseFactory func(t *testing.T) scheduler.JobDatabase
+
+// RunDatabaseSuite runs the conformance test suite of the JobDatabase interface against the databases created by the factory,
+// checking that they behave like the job databases shipped with the library.
+//
+// Every interface method is tested, including the edge cases relied on by the library
+// (Ex.: ListExpiredSchedules only listing the PENDING jobs that are past due, or SaveJob updating the job with the same ID).
+//
+// The time values are saved with millisecond precision, so databases with a lower precision will not pass the suite.
+//
+// Ex.:
+//
+//	func TestMyJobDB(t *testing.T) {
+//		schedulertest.RunDatabaseSuite(t, func(t *testing.T) scheduler.JobDatabase {
+//			return NewMyJobDB()
+//		})
+//	}
+func RunDatabaseSuite(t *testing.T, newDB DatabaseFactory) {
+	s := &databaseSuite{newDB}
+
+	t.Run("InitJobDB", s.testInitJobDB)
+	t.Run("SaveJob", s.testSaveJob)
+	t.Run("GetJob", s.testGetJob)
+	t.Run("ListExpiredSchedules", s.testListExpiredSchedules)
+	t.Run("ClaimJob", s.testClaimJob)
+	t.Run("RenewLease", s.testRenewLease)
+	t.Run("ListExpiredLeases", s.testListExpiredLeases)
+	t.Run("RecoverJob", s.testRecoverJob)
+	t.Run("ReleaseJob", s.testReleaseJob)
+	t.Run("FinishJob", s.testFinishJob)
+	t.Run("List", s.testList)
+	t.Run("Count", s.testCount)
+	t.Run("UpdateJob", s.testUpdateJob)
+	t.Run("SaveUniqueJob", s.testSaveUniqueJob)
+	t.Run("DeleteJob", s.testDeleteJob)
+	t.Run("CancelMany", s.testCancelMany)
+	t.Run("DeleteMany", s.testDeleteMany)
+	t.Run("RescheduleMany", s.testRescheduleMany)
+	t.Run("SaveRun and ListRuns", s.testRuns)
+	t.Run("DeleteRunsBefore", s.testDeleteRunsBefore)
+}
+
+type databaseSuite struct {
+	newDB DatabaseFactory
+}
+
+// init creates a new job database and starts it
+func (s *databaseSuite) init(t *testing.T) scheduler.JobDatabase {
+	db := s.newDB(t)
+
+	if !assert.NoError(t, db.InitJobDB(context.Background()), "Failed to init the job database") {
+		t.FailNow()
+	}
+
+	return db
+}
+
+// save saves the job as a new job, and returns its ID
+func save(t *testing.T, db scheduler.JobDatabase, j scheduler.Job) string {
+	id, err := db.SaveJob(context.Background(), j)
+	if !assert.NoError(t, err, "Failed to save the job") || !assert.NotEmpty(t, id, "The saved job has no ID") {
+		t.FailNow()
+	}
+
+	return id
+}
+
+// get gets the job with the given ID
+func get(t *testing.T, db scheduler.JobDatabase, id string) *scheduler.Job {
+	j, err := db.GetJob(context.Background(), id)
+	if !assert.NoError(t, err, "Failed to get the job %s", id) {
+		t.FailNow()
+	}
+
+	return j
+}
+
+// ids returns the IDs of the jobs
+func ids(js []*scheduler.Job) []string {
+	ids := []string{}
+	for _, j := range js {
+		ids = append(ids, j.ID)
+	}
+
+	return ids
+}
+
+// now returns the current time, with the precision that the job databases should keep
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+// assertSameTime asserts that the times represent the same instant, regardless of their locations
+func assertSameTime(t *testing.T, expected, actual time.Time, field string) {
+	assert.True(t, expected.Equal(actual), "%s: expected %v, got %v", field, expected, actual)
+}
+
+// assertSameTimePtr asserts that the times are both nil, or represent the same instant
+func assertSameTimePtr(t *testing.T, expected, actual *time.Time, field string) {
+	if expected == nil || actual == nil {
+		assert.Equal(t, expected == nil, actual == nil, "%s: expected %v, got %v", field, expected, actual)
+		return
+	}
+
+	assertSameTime(t, *expected, *actual, field)
+}
+
+func (s *databaseSuite) testInitJobDB(t *testing.T) {
+	t.Run("Should init the database more than once", func(t *testing.T) {
+		db := s.init(t)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: now()})
+
+		assert.NoError(t, db.InitJobDB(context.Background()))
+		assert.Equal(t, "MYMOCKJOB!", get(t, db, id).Name)
+	})
+}
+
+func (s *databaseSuite) testSaveJob(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should save every job field", func(t *testing.T) {
+		db := s.init(t)
+		n := now()
+		lease := n.Add(time.Minute)
+		limit := n.Add(24 * time.Hour)
+		j := scheduler.Job{
+			Name:              "MYMOCKJOB!",
+			Data:              map[string]any{"key": "value"},
+			ScheduleType:      scheduler.RECURRENT,
+			Status:            scheduler.RUNNING,
+			Owner:             "my-worker",
+			LeaseExpiresAt:    &lease,
+			OrphanCount:       1,
+			NextRunAt:         n,
+			LastRunAt:         &n,
+			ScheduleString:    "@daily",
+			ScheduleLimitDate: &limit,
+			Location:          "America/Sao_Paulo",
+			RetryPolicy:       &scheduler.RetryPolicy{MaxAttempts: 3, Backoff: scheduler.EXPONENTIAL, Delay: time.Minute},
+			Timeout:           time.Hour,
+			Attempts:          2,
+			LastError:         "MOCK ERROR!",
+			FailedAt:          &n,
+			FailureCount:      2,
+			UniqueKey:         "MYKEY",
+		}
+
+		saved := get(t, db, save(t, db, j))
+
+		assert.Equal(t, j.Name, saved.Name)
+		assert.Equal(t, j.Data, saved.Data)
+		assert.Equal(t, j.ScheduleType, saved.ScheduleType)
+		assert.Equal(t, j.Status, saved.Status)
+		assert.Equal(t, j.Owner, saved.Owner)
+		assertSameTimePtr(t, j.LeaseExpiresAt, saved.LeaseExpiresAt, "LeaseExpiresAt")
+		assert.Equal(t, j.OrphanCount, saved.OrphanCount)
+		assertSameTime(t, j.NextRunAt, saved.NextRunAt, "NextRunAt")
+		assertSameTimePtr(t, j.LastRunAt, saved.LastRunAt, "LastRunAt")
+		assert.Equal(t, j.ScheduleString, saved.ScheduleString)
+		assertSameTimePtr(t, j.ScheduleLimitDate, saved.ScheduleLimitDate, "ScheduleLimitDate")
+		assert.Equal(t, j.Location, saved.Location)
+		assert.Equal(t, j.RetryPolicy, saved.RetryPolicy)
+		assert.Equal(t, j.Timeout, saved.Timeout)
+		assert.Equal(t, j.Attempts, saved.Attempts)
+		assert.Equal(t, j.LastError, saved.LastError)
+		assertSameTimePtr(t, j.FailedAt, saved.FailedAt, "FailedAt")
+		assert.Equal(t, j.FailureCount, saved.FailureCount)
+		assert.Equal(t, j.UniqueKey, saved.UniqueKey)
+	})
+	t.Run("Should keep the empty values empty", func(t *testing.T) {
+		db := s.init(t)
+
+		saved := get(t, db, save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: now()}))
+
+		assert.Empty(t, saved.Data)
+		assert.Nil(t, saved.LeaseExpiresAt)
+		assert.Nil(t, saved.LastRunAt)
+		assert.Nil(t, saved.ScheduleLimitDate)
+		assert.Nil(t, saved.RetryPolicy)
+		assert.Nil(t, saved.FailedAt)
+		assert.Empty(t, saved.Owner)
+		assert.Empty(t, saved.UniqueKey)
+	})
+	t.Run("Should assign a different ID to each new job", func(t *testing.T) {
+		db := s.init(t)
+
+		firstID := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: now()})
+		secondID := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: now()})
+
+		assert.NotEqual(t, firstID, secondID)
+	})
+	t.Run("Should update the existing job when the job has an ID", func(t *testing.T) {
+		db := s.init(t)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: now()})
+
+		j := get(t, db, id)
+		j.Status = scheduler.DONE
+		j.Attempts = 3
+
+		savedID, err := db.SaveJob(ctx, *j)
+		assert.NoError(t, err)
+		assert.Equal(t, id, savedID)
+
+		count, _ := db.Count(ctx, scheduler.Finder{})
+		assert.Equal(t, 1, count)
+		saved := get(t, db, id)
+		assert.Equal(t, scheduler.DONE, saved.Status)
+		assert.Equal(t, 3, saved.Attempts)
+	})
+	t.Run("Should clear the fields that are emptied on the job", func(t *testing.T) {
+		db := s.init(t)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: now(), LastError: "my error"})
+
+		j := get(t, db, id)
+		j.LastError = ""
+
+		_, err := db.SaveJob(ctx, *j)
+		assert.NoError(t, err)
+		assert.Empty(t, get(t, db, id).LastError)
+	})
+	t.Run("Should update a RUNNING job", func(t *testing.T) {
+		db := s.init(t)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.RUNNING, NextRunAt: now()})
+
+		_, err := db.SaveJob(ctx, scheduler.Job{ID: id, Name: "MYMOCKJOB!", Status: scheduler.DONE, NextRunAt: now()})
+		assert.NoError(t, err)
+		assert.Equal(t, scheduler.DONE, get(t, db, id).Status)
+	})
+}
+
+func (s *databaseSuite) testGetJob(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should return ErrJobNotFound if no job has the ID", func(t *testing.T) {
+		db := s.init(t)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: now()})
+		assert.NoError(t, db.DeleteJob(ctx, scheduler.Job{ID: id}))
+
+		j, err := db.GetJob(ctx, id)
+		assert.ErrorIs(t, err, scheduler.ErrJobNotFound)
+		assert.Nil(t, j)
+	})
+	t.Run("Should return ErrJobNotFound if the ID is invalid", func(t *testing.T) {
+		_, err := s.init(t).GetJob(ctx, "not a valid ID")
+		assert.ErrorIs(t, err, scheduler.ErrJobNotFound)
+	})
+}
+
+func (s *databaseSuite) testListExpiredSchedules(t *testing.T) {
+	t.Run("Should only list the PENDING jobs that are past due", func(t *testing.T) {
+		db := s.init(t)
+		past, future := now().Add(-time.Minute), now().Add(time.Hour)
+
+		dueID := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: past})
+		save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: future})
+		for _, status := range []scheduler.ScheduleStatus{scheduler.RUNNING, scheduler.FAILED, scheduler.TIMED_OUT, scheduler.DONE, scheduler.CANCELED} {
+			save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: status, NextRunAt: past})
+		}
+
+		js, err := db.ListExpiredSchedules(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{dueID}, ids(js))
+	})
+	t.Run("Should list nothing if there are no jobs", func(t *testing.T) {
+		js, err := s.init(t).ListExpiredSchedules(context.Background())
+		assert.NoError(t, err)
+		assert.Empty(t, js)
+	})
+}
+
+func (s *databaseSuite) testClaimJob(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should set the PENDING job as RUNNING and owned by the claimer", func(t *testing.T) {
+		db := s.init(t)
+		nextRunAt := now()
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: nextRunAt, Data: map[string]any{"key": "value"}})
+		lease := now().Add(time.Minute)
+
+		claimed, err := db.ClaimJob(ctx, scheduler.Job{ID: id, Owner: "my-worker", NextRunAt: nextRunAt, LeaseExpiresAt: &lease})
+		assert.NoError(t, err)
+		assert.True(t, claimed)
+
+		j := get(t, db, id)
+		assert.Equal(t, scheduler.RUNNING, j.Status)
+		assert.Equal(t, "my-worker", j.Owner)
+		assertSameTimePtr(t, &lease, j.LeaseExpiresAt, "LeaseExpiresAt")
+		// the other fields are kept
+		assert.Equal(t, "MYMOCKJOB!", j.Name)
+		assert.Equal(t, "value", j.Data["key"])
+	})
+	t.Run("Should not claim a job that is not PENDING", func(t *testing.T) {
+		db := s.init(t)
+		lease := now().Add(time.Minute)
+
+		nextRunAt := now()
+
+		for _, status := range []scheduler.ScheduleStatus{scheduler.RUNNING, scheduler.FAILED, scheduler.DONE, scheduler.CANCELED} {
+			id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: status, Owner: "another-worker", NextRunAt: nextRunAt})
+
+			claimed, err := db.ClaimJob(ctx, scheduler.Job{ID: id, Owner: "my-worker", NextRunAt: nextRunAt, LeaseExpiresAt: &lease})
+			assert.NoError(t, err)
+			assert.False(t, claimed, "a %s job was claimed", status)
+			assert.Equal(t, status, get(t, db, id).Status)
+		}
+	})
+	t.Run("Should not claim a job that was ran and re-scheduled since it was listed", func(t *testing.T) {
+		db := s.init(t)
+		save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: now().Add(-time.Minute)})
+		lease := now().Add(time.Minute)
+
+		// two instances list the same due job
+		js, err := db.ListExpiredSchedules(ctx)
+		assert.NoError(t, err)
+		if !assert.Len(t, js, 1) {
+			return
+		}
+		listedByA, listedByB := *js[0], *js[0]
+
+		// the first instance claims it, runs it, and re-schedules it as PENDING for the next hour
+		listedByA.Owner, listedByA.LeaseExpiresAt = "worker-a", &lease
+		claimed, err := db.ClaimJob(ctx, listedByA)
+		assert.NoError(t, err)
+		assert.True(t, claimed)
+
+		listedByA.Status, listedByA.Owner, listedByA.LeaseExpiresAt = scheduler.PENDING, "", nil
+		listedByA.NextRunAt = now().Add(time.Hour)
+		_, err = db.SaveJob(ctx, listedByA)
+		assert.NoError(t, err)
+
+		// the second instance must not claim its stale copy
+		listedByB.Owner, listedByB.LeaseExpiresAt = "worker-b", &lease
+		claimed, err = db.ClaimJob(ctx, listedByB)
+		assert.NoError(t, err)
+		assert.False(t, claimed)
+
+		j := get(t, db, listedByA.ID)
+		assert.Equal(t, scheduler.PENDING, j.Status)
+		assert.Empty(t, j.Owner)
+	})
+	t.Run("Should let a single claimer claim the job at the same time", func(t *testing.T) {
+		db := s.init(t)
+		nextRunAt := now()
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: nextRunAt})
+		lease := now().Add(time.Minute)
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		claimers := []string{}
+		for _, owner := range []string{"worker-1", "worker-2", "worker-3", "worker-4", "worker-5"} {
+			wg.Add(1)
+			go func(owner string) {
+				defer wg.Done()
+
+				claimed, err := db.ClaimJob(ctx, scheduler.Job{ID: id, Owner: owner, NextRunAt: nextRunAt, LeaseExpiresAt: &lease})
+				assert.NoError(t, err)
+				if claimed {
+					mu.Lock()
+					claimers = append(claimers, owner)
+					mu.Unlock()
+				}
+			}(owner)
+		}
+		wg.Wait()
+
+		if assert.Len(t, claimers, 1) {
+			assert.Equal(t, claimers[0], get(t, db, id).Owner)
+		}
+	})
+}
+
+func (s *databaseSuite) testRenewLease(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should renew the lease of the job owner", func(t *testing.T) {
+		db := s.init(t)
+		lease, renewedLease := now().Add(time.Minute), now().Add(time.Hour)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.RUNNING, Owner: "my-worker", LeaseExpiresAt: &lease})
+
+		renewed, err := db.RenewLease(ctx, scheduler.Job{ID: id, Owner: "my-worker", LeaseExpiresAt: &renewedLease})
+		assert.NoError(t, err)
+		assert.True(t, renewed)
+		assertSameTimePtr(t, &renewedLease, get(t, db, id).LeaseExpiresAt, "LeaseExpiresAt")
+	})
+	t.Run("Should not renew the lease if the job is owned by another worker", func(t *testing.T) {
+		db := s.init(t)
+		lease, renewedLease := now().Add(time.Minute), now().Add(time.Hour)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.RUNNING, Owner: "another-worker", LeaseExpiresAt: &lease})
+
+		renewed, err := db.RenewLease(ctx, scheduler.Job{ID: id, Owner: "my-worker", LeaseExpiresAt: &renewedLease})
+		assert.NoError(t, err)
+		assert.False(t, renewed)
+		assertSameTimePtr(t, &lease, get(t, db, id).LeaseExpiresAt, "LeaseExpiresAt")
+	})
+	t.Run("Should not renew the lease if the job is not RUNNING", func(t *testing.T) {
+		db := s.init(t)
+		renewedLease := now().Add(time.Hour)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, Owner: "my-worker"})
+
+		renewed, err := db.RenewLease(ctx, scheduler.Job{ID: id, Owner: "my-worker", LeaseExpiresAt: &renewedLease})
+		assert.NoError(t, err)
+		assert.False(t, renewed)
+	})
+}
+
+func (s *databaseSuite) testListExpiredLeases(t *testing.T) {
+	t.Run("Should only list the RUNNING jobs which lease has expired", func(t *testing.T) {
+		db := s.init(t)
+		past, future := now().Add(-time.Minute), now().Add(time.Hour)
+
+		expiredID := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.RUNNING, Owner: "my-worker", LeaseExpiresAt: &past})
+		save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.RUNNING, Owner: "my-worker", LeaseExpiresAt: &future})
+		save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, LeaseExpiresAt: &past})
+		save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.DONE, LeaseExpiresAt: &past})
+
+		js, err := db.ListExpiredLeases(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{expiredID}, ids(js))
+	})
+}
+
+func (s *databaseSuite) testRecoverJob(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should save the job if its RUNNING and its lease has expired", func(t *testing.T) {
+		db := s.init(t)
+		past, next := now().Add(-time.Minute), now().Add(time.Hour)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.RUNNING, Owner: "my-worker", LeaseExpiresAt: &past})
+
+		recovered, err := db.RecoverJob(ctx, scheduler.Job{ID: id, Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: next, OrphanCount: 1})
+		assert.NoError(t, err)
+		assert.True(t, recovered)
+
+		j := get(t, db, id)
+		assert.Equal(t, scheduler.PENDING, j.Status)
+		assert.Equal(t, 1, j.OrphanCount)
+		assertSameTime(t, next, j.NextRunAt, "NextRunAt")
+
+		js, _ := db.ListExpiredLeases(ctx)
+		assert.Empty(t, js)
+	})
+	t.Run("Should not save the job if its lease has not expired", func(t *testing.T) {
+		db := s.init(t)
+		future := now().Add(time.Hour)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.RUNNING, Owner: "my-worker", LeaseExpiresAt: &future})
+
+		recovered, err := db.RecoverJob(ctx, scheduler.Job{ID: id, Name: "MYMOCKJOB!", Status: scheduler.PENDING})
+		assert.NoError(t, err)
+		assert.False(t, recovered)
+		assert.Equal(t, scheduler.RUNNING, get(t, db, id).Status)
+	})
+	t.Run("Should not save the job if its not RUNNING", func(t *testing.T) {
+		db := s.init(t)
+		past := now().Add(-time.Minute)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.DONE, LeaseExpiresAt: &past})
+
+		recovered, err := db.RecoverJob(ctx, scheduler.Job{ID: id, Name: "MYMOCKJOB!", Status: scheduler.PENDING})
+		assert.NoError(t, err)
+		assert.False(t, recovered)
+		assert.Equal(t, scheduler.DONE, get(t, db, id).Status)
+	})
+}
+
+func (s *databaseSuite) testReleaseJob(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should set the job back as PENDING if its RUNNING and owned by the releaser", func(t *testing.T) {
+		db := s.init(t)
+		lease, next := now().Add(time.Minute), now()
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.RUNNING, Owner: "my-worker", LeaseExpiresAt: &lease, NextRunAt: next, Data: map[string]any{"key": "value"}})
+
+		released, err := db.ReleaseJob(ctx, scheduler.Job{ID: id, Owner: "my-worker"})
+		assert.NoError(t, err)
+		assert.True(t, released)
+
+		j := get(t, db, id)
+		assert.Equal(t, scheduler.PENDING, j.Status)
+		assert.Empty(t, j.Owner)
+		assert.Nil(t, j.LeaseExpiresAt)
+		// the other fields are kept
+		assertSameTime(t, next, j.NextRunAt, "NextRunAt")
+		assert.Equal(t, "value", j.Data["key"])
+	})
+	t.Run("Should not release a job owned by another worker", func(t *testing.T) {
+		db := s.init(t)
+		lease := now().Add(time.Minute)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.RUNNING, Owner: "another-worker", LeaseExpiresAt: &lease})
+
+		released, err := db.ReleaseJob(ctx, scheduler.Job{ID: id, Owner: "my-worker"})
+		assert.NoError(t, err)
+		assert.False(t, released)
+
+		j := get(t, db, id)
+		assert.Equal(t, scheduler.RUNNING, j.Status)
+		assert.Equal(t, "another-worker", j.Owner)
+	})
+	t.Run("Should not release a job that is not RUNNING anymore", func(t *testing.T) {
+		db := s.init(t)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.DONE})
+
+		released, err := db.ReleaseJob(ctx, scheduler.Job{ID: id, Owner: "my-worker"})
+		assert.NoError(t, err)
+		assert.False(t, released)
+		assert.Equal(t, scheduler.DONE, get(t, db, id).Status)
+	})
+}
+
+func (s *databaseSuite) testFinishJob(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should save the job if its RUNNING and owned by the given owner", func(t *testing.T) {
+		db := s.init(t)
+		lease := now().Add(time.Minute)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.RUNNING, Owner: "my-worker", LeaseExpiresAt: &lease})
+
+		lastRun, next := now(), now().Add(time.Hour)
+		finished, err := db.FinishJob(ctx, scheduler.Job{ID: id, Name: "MYMOCKJOB!", Status: scheduler.PENDING, LastRunAt: &lastRun, NextRunAt: next, Data: map[string]any{"key": "value"}}, "my-worker")
+		assert.NoError(t, err)
+		assert.True(t, finished)
+
+		j := get(t, db, id)
+		assert.Equal(t, scheduler.PENDING, j.Status)
+		assert.Empty(t, j.Owner)
+		assert.Nil(t, j.LeaseExpiresAt)
+		assertSameTimePtr(t, &lastRun, j.LastRunAt, "LastRunAt")
+		assertSameTime(t, next, j.NextRunAt, "NextRunAt")
+		assert.Equal(t, "value", j.Data["key"])
+	})
+	t.Run("Should not save a job owned by another worker", func(t *testing.T) {
+		db := s.init(t)
+		lease := now().Add(time.Minute)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.RUNNING, Owner: "another-worker", LeaseExpiresAt: &lease})
+
+		finished, err := db.FinishJob(ctx, scheduler.Job{ID: id, Name: "MYMOCKJOB!", Status: scheduler.DONE}, "my-worker")
+		assert.NoError(t, err)
+		assert.False(t, finished)
+
+		j := get(t, db, id)
+		assert.Equal(t, scheduler.RUNNING, j.Status)
+		assert.Equal(t, "another-worker", j.Owner)
+	})
+	t.Run("Should not save a job that is not RUNNING anymore", func(t *testing.T) {
+		db := s.init(t)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, Owner: "my-worker"})
+
+		finished, err := db.FinishJob(ctx, scheduler.Job{ID: id, Name: "MYMOCKJOB!", Status: scheduler.DONE}, "my-worker")
+		assert.NoError(t, err)
+		assert.False(t, finished)
+		assert.Equal(t, scheduler.PENDING, get(t, db, id).Status)
+	})
+}
+
+// listFixture saves jobs with different values to be found,
+// and returns their IDs in the order they were saved, and the time their values are relative to
+func listFixture(t *testing.T, db scheduler.JobDatabase) ([]string, time.Time) {
+	n := now()
+	lastRun := n.Add(-time.Hour)
+
+	return []string{
+		save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, ScheduleType: scheduler.SIMPLE, NextRunAt: n.Add(2 * time.Hour), Data: map[string]any{"tenant": "a"}}),
+		save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.FAILED, ScheduleType: scheduler.RECURRENT, NextRunAt: n, LastRunAt: &lastRun, Data: map[string]any{"tenant": "b"}}),
+		save(t, db, scheduler.Job{Name: "ANOTHERJOB!", Status: scheduler.DONE, ScheduleType: scheduler.SIMPLE, NextRunAt: n.Add(time.Hour), LastRunAt: &n, Data: map[string]any{"tenant": "a"}}),
+		save(t, db, scheduler.Job{Name: "ANOTHERJOB!", Status: scheduler.PENDING, ScheduleType: scheduler.RECURRENT, NextRunAt: n.Add(-time.Hour)}),
+	}, n
+}
+
+func (s *databaseSuite) testList(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should list every job in the order they were saved if the finder is empty", func(t *testing.T) {
+		db := s.init(t)
+		saved, _ := listFixture(t, db)
+
+		js, err := db.List(ctx, scheduler.Finder{})
+		assert.NoError(t, err)
+		assert.Equal(t, saved, ids(js))
+	})
+	t.Run("Should filter the jobs by every finder value", func(t *testing.T) {
+		db := s.init(t)
+		saved, n := listFixture(t, db)
+
+		tests := []struct {
+			name     string
+			finder   scheduler.Finder
+			expected []string
+		}{
+			{"name", scheduler.Finder{Name: "ANOTHERJOB!"}, []string{saved[2], saved[3]}},
+			{"status", scheduler.Finder{Status: "PENDING"}, []string{saved[0], saved[3]}},
+			{"statuses", scheduler.Finder{Statuses: []scheduler.ScheduleStatus{scheduler.FAILED, scheduler.DONE}}, []string{saved[1], saved[2]}},
+			{"status and statuses", scheduler.Finder{Status: "DONE", Statuses: []scheduler.ScheduleStatus{scheduler.FAILED}}, []string{saved[1], saved[2]}},
+			{"schedule type", scheduler.Finder{ScheduleType: scheduler.RECURRENT}, []string{saved[1], saved[3]}},
+			{"data", scheduler.Finder{Data: map[string]any{"tenant": "a"}}, []string{saved[0], saved[2]}},
+			{"name and status", scheduler.Finder{Name: "MYMOCKJOB!", Status: "PENDING"}, []string{saved[0]}},
+			{"next run after, inclusive", scheduler.Finder{NextRunAfter: n.Add(time.Hour)}, []string{saved[0], saved[2]}},
+			{"next run before, exclusive", scheduler.Finder{NextRunBefore: n}, []string{saved[3]}},
+			{"last run after, ignoring the jobs that never ran", scheduler.Finder{LastRunAfter: n.Add(-2 * time.Hour)}, []string{saved[1], saved[2]}},
+			{"last run before", scheduler.Finder{LastRunBefore: n}, []string{saved[1]}},
+			{"no match", scheduler.Finder{Name: "MYMOCKJOB!", Status: "DONE"}, []string{}},
+		}
+
+		for _, test := range tests {
+			js, err := db.List(ctx, test.finder)
+			assert.NoError(t, err, test.name)
+			assert.Equal(t, test.expected, ids(js), test.name)
+		}
+	})
+	t.Run("Should sort the jobs by the finder sort field", func(t *testing.T) {
+		db := s.init(t)
+		saved, _ := listFixture(t, db)
+
+		tests := []struct {
+			name     string
+			finder   scheduler.Finder
+			expected []string
+		}{
+			{"next run at", scheduler.Finder{SortBy: scheduler.SORT_BY_NEXT_RUN_AT}, []string{saved[3], saved[1], saved[2], saved[0]}},
+			{"next run at, descending", scheduler.Finder{SortBy: scheduler.SORT_BY_NEXT_RUN_AT, SortDescending: true}, []string{saved[0], saved[2], saved[1], saved[3]}},
+			{"last run at, the jobs that never ran first", scheduler.Finder{SortBy: scheduler.SORT_BY_LAST_RUN_AT}, []string{saved[0], saved[3], saved[1], saved[2]}},
+			{"last run at, descending", scheduler.Finder{SortBy: scheduler.SORT_BY_LAST_RUN_AT, SortDescending: true}, []string{saved[2], saved[1], saved[3], saved[0]}},
+			{"name, then the save order", scheduler.Finder{SortBy: scheduler.SORT_BY_NAME}, []string{saved[2], saved[3], saved[0], saved[1]}},
+			{"status, then the save order", scheduler.Finder{SortBy: scheduler.SORT_BY_STATUS}, []string{saved[2], saved[1], saved[0], saved[3]}},
+		}
+
+		for _, test := range tests {
+			js, err := db.List(ctx, test.finder)
+			assert.NoError(t, err, test.name)
+			assert.Equal(t, test.expected, ids(js), test.name)
+		}
+	})
+	t.Run("Should paginate the jobs", func(t *testing.T) {
+		db := s.init(t)
+		saved, _ := listFixture(t, db)
+
+		js, err := db.List(ctx, scheduler.Finder{SortBy: scheduler.SORT_BY_NEXT_RUN_AT, Limit: 2, Offset: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{saved[1], saved[2]}, ids(js))
+
+		js, err = db.List(ctx, scheduler.Finder{Offset: 10})
+		assert.NoError(t, err)
+		assert.Empty(t, js)
+	})
+}
+
+func (s *databaseSuite) testCount(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should count the jobs found, ignoring the pagination", func(t *testing.T) {
+		db := s.init(t)
+		listFixture(t, db)
+
+		count, err := db.Count(ctx, scheduler.Finder{Name: "MYMOCKJOB!", Limit: 1, Offset: 1, SortBy: scheduler.SORT_BY_NAME})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		count, err = db.Count(ctx, scheduler.Finder{})
+		assert.NoError(t, err)
+		assert.Equal(t, 4, count)
+	})
+	t.Run("Should count zero jobs if there are no jobs", func(t *testing.T) {
+		count, err := s.init(t).Count(ctx, scheduler.Finder{})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+}
+
+func (s *databaseSuite) testUpdateJob(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should save the job if its not RUNNING and was not changed since it was read", func(t *testing.T) {
+		db := s.init(t)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: now()})
+
+		old := get(t, db, id)
+		j := *old
+		j.Status = scheduler.CANCELED
+		updated, err := db.UpdateJob(ctx, j, *old)
+		assert.NoError(t, err)
+		assert.True(t, updated)
+		assert.Equal(t, scheduler.CANCELED, get(t, db, id).Status)
+	})
+	t.Run("Should not save the job if its RUNNING", func(t *testing.T) {
+		db := s.init(t)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.RUNNING, Owner: "my-worker", NextRunAt: now()})
+
+		old := get(t, db, id)
+		updated, err := db.UpdateJob(ctx, scheduler.Job{ID: id, Name: "MYMOCKJOB!", Status: scheduler.CANCELED}, *old)
+		assert.NoError(t, err)
+		assert.False(t, updated)
+		assert.Equal(t, scheduler.RUNNING, get(t, db, id).Status)
+	})
+	t.Run("Should not save the job if its status changed since it was read", func(t *testing.T) {
+		db := s.init(t)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: now()})
+
+		old := get(t, db, id)
+		// the job ran in the meantime
+		done := *old
+		done.Status = scheduler.DONE
+		save(t, db, done)
+
+		j := *old
+		j.Data = map[string]any{"key": "value"}
+		updated, err := db.UpdateJob(ctx, j, *old)
+		assert.NoError(t, err)
+		assert.False(t, updated)
+
+		stored := get(t, db, id)
+		assert.Equal(t, scheduler.DONE, stored.Status)
+		assert.Empty(t, stored.Data)
+	})
+	t.Run("Should not save the job if its NextRunAt changed since it was read", func(t *testing.T) {
+		db := s.init(t)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: now()})
+
+		old := get(t, db, id)
+		// the job ran and was re-scheduled in the meantime
+		next, lastRun := now().Add(time.Hour), now()
+		rescheduled := *old
+		rescheduled.NextRunAt = next
+		rescheduled.LastRunAt = &lastRun
+		save(t, db, rescheduled)
+
+		j := *old
+		j.Data = map[string]any{"key": "value"}
+		updated, err := db.UpdateJob(ctx, j, *old)
+		assert.NoError(t, err)
+		assert.False(t, updated)
+
+		stored := get(t, db, id)
+		assertSameTime(t, next, stored.NextRunAt, "NextRunAt")
+		assertSameTimePtr(t, &lastRun, stored.LastRunAt, "LastRunAt")
+		assert.Empty(t, stored.Data)
+	})
+	t.Run("Should not save the job if it does not exist", func(t *testing.T) {
+		db := s.init(t)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: now()})
+		old := get(t, db, id)
+		assert.NoError(t, db.DeleteJob(ctx, scheduler.Job{ID: id}))
+
+		updated, err := db.UpdateJob(ctx, scheduler.Job{ID: id, Name: "MYMOCKJOB!", Status: scheduler.CANCELED}, *old)
+		assert.NoError(t, err)
+		assert.False(t, updated)
+
+		count, _ := db.Count(ctx, scheduler.Finder{})
+		assert.Equal(t, 0, count)
+	})
+}
+
+func (s *databaseSuite) testSaveUniqueJob(t *testing.T) {
+	ctx := context.Background()
+	newJob := func(name string) scheduler.Job {
+		return scheduler.Job{Name: name, Status: scheduler.PENDING, NextRunAt: now(), UniqueKey: "MYKEY"}
+	}
+
+	t.Run("Should insert the job if no job has the unique key", func(t *testing.T) {
+		db := s.init(t)
+
+		id, saved, err := db.SaveUniqueJob(ctx, newJob("MYMOCKJOB!"), false)
+		assert.NoError(t, err)
+		assert.True(t, saved)
+		assert.Equal(t, "MYKEY", get(t, db, id).UniqueKey)
+	})
+	t.Run("Should keep the existing job if not replacing", func(t *testing.T) {
+		db := s.init(t)
+		id, _, _ := db.SaveUniqueJob(ctx, newJob("MYMOCKJOB!"), false)
+
+		existingID, saved, err := db.SaveUniqueJob(ctx, newJob("ANOTHERJOB!"), false)
+		assert.NoError(t, err)
+		assert.False(t, saved)
+		assert.Equal(t, id, existingID)
+		assert.Equal(t, "MYMOCKJOB!", get(t, db, id).Name)
+	})
+	t.Run("Should replace the existing job keeping its ID", func(t *testing.T) {
+		db := s.init(t)
+		id, _, _ := db.SaveUniqueJob(ctx, newJob("MYMOCKJOB!"), false)
+
+		replacedID, saved, err := db.SaveUniqueJob(ctx, newJob("ANOTHERJOB!"), true)
+		assert.NoError(t, err)
+		assert.True(t, saved)
+		assert.Equal(t, id, replacedID)
+		assert.Equal(t, "ANOTHERJOB!", get(t, db, id).Name)
+
+		count, _ := db.Count(ctx, scheduler.Finder{})
+		assert.Equal(t, 1, count)
+	})
+	t.Run("Should not keep the fields of the existing job that the new job does not set", func(t *testing.T) {
+		db := s.init(t)
+		existing := newJob("MYMOCKJOB!")
+		existing.ScheduleString = "1 hour"
+		existing.LastError = "my error"
+		existing.Data = map[string]any{"key": "value"}
+		id, _, _ := db.SaveUniqueJob(ctx, existing, false)
+
+		_, _, err := db.SaveUniqueJob(ctx, newJob("MYMOCKJOB!"), true)
+		assert.NoError(t, err)
+
+		replaced := get(t, db, id)
+		assert.Empty(t, replaced.ScheduleString)
+		assert.Empty(t, replaced.LastError)
+		assert.Empty(t, replaced.Data)
+	})
+	t.Run("Should return ErrJobRunning when replacing a RUNNING job", func(t *testing.T) {
+		db := s.init(t)
+		id, _, _ := db.SaveUniqueJob(ctx, newJob("MYMOCKJOB!"), false)
+		db.ClaimJob(ctx, scheduler.Job{ID: id, Owner: "my-worker", NextRunAt: get(t, db, id).NextRunAt})
+
+		_, saved, err := db.SaveUniqueJob(ctx, newJob("ANOTHERJOB!"), true)
+		assert.ErrorIs(t, err, scheduler.ErrJobRunning)
+		assert.False(t, saved)
+		assert.Equal(t, "MYMOCKJOB!", get(t, db, id).Name)
+
+		// without replacing, the running job is just kept
+		existingID, saved, err := db.SaveUniqueJob(ctx, newJob("ANOTHERJOB!"), false)
+		assert.NoError(t, err)
+		assert.False(t, saved)
+		assert.Equal(t, id, existingID)
+	})
+	t.Run("Should insert a new job once the existing job is deleted", func(t *testing.T) {
+		db := s.init(t)
+		id, _, _ := db.SaveUniqueJob(ctx, newJob("MYMOCKJOB!"), false)
+		assert.NoError(t, db.DeleteJob(ctx, scheduler.Job{ID: id}))
+
+		newID, saved, err := db.SaveUniqueJob(ctx, newJob("MYMOCKJOB!"), false)
+		assert.NoError(t, err)
+		assert.True(t, saved)
+		assert.NotEqual(t, id, newID)
+	})
+	t.Run("Should insert a single job when saving the same unique key at the same time", func(t *testing.T) {
+		db := s.init(t)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _, err := db.SaveUniqueJob(ctx, newJob("MYMOCKJOB!"), false)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		count, _ := db.Count(ctx, scheduler.Finder{})
+		assert.Equal(t, 1, count)
+	})
+}
+
+func (s *databaseSuite) testDeleteJob(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should delete only the job", func(t *testing.T) {
+		db := s.init(t)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: now()})
+		otherID := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: now()})
+
+		assert.NoError(t, db.DeleteJob(ctx, scheduler.Job{ID: id}))
+
+		js, _ := db.List(ctx, scheduler.Finder{})
+		assert.Equal(t, []string{otherID}, ids(js))
+	})
+	t.Run("Should not fail if the job does not exist", func(t *testing.T) {
+		db := s.init(t)
+		id := save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: now()})
+		assert.NoError(t, db.DeleteJob(ctx, scheduler.Job{ID: id}))
+
+		assert.NoError(t, db.DeleteJob(ctx, scheduler.Job{ID: id}))
+	})
+}
+
+// bulkFixture saves a PENDING, a RUNNING and a CANCELED job with the same name, and a PENDING job with another name,
+// and returns their IDs in the order they were saved
+func bulkFixture(t *testing.T, db scheduler.JobDatabase, nextRunAt time.Time) []string {
+	return []string{
+		save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.PENDING, NextRunAt: nextRunAt}),
+		save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.RUNNING, Owner: "my-worker", NextRunAt: nextRunAt}),
+		save(t, db, scheduler.Job{Name: "MYMOCKJOB!", Status: scheduler.CANCELED, NextRunAt: nextRunAt}),
+		save(t, db, scheduler.Job{Name: "ANOTHERJOB!", Status: scheduler.PENDING, NextRunAt: nextRunAt}),
+	}
+}
+
+func (s *databaseSuite) testCancelMany(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should cancel the jobs found, skipping the RUNNING and the already CANCELED ones", func(t *testing.T) {
+		db := s.init(t)
+		saved := bulkFixture(t, db, now())
+
+		canceled, err := db.CancelMany(ctx, scheduler.Finder{Name: "MYMOCKJOB!"})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, canceled)
+
+		assert.Equal(t, scheduler.CANCELED, get(t, db, saved[0]).Status)
+		assert.Equal(t, scheduler.RUNNING, get(t, db, saved[1]).Status)
+		assert.Equal(t, scheduler.PENDING, get(t, db, saved[3]).Status)
+	})
+	t.Run("Should ignore the pagination", func(t *testing.T) {
+		db := s.init(t)
+		bulkFixture(t, db, now())
+
+		canceled, err := db.CancelMany(ctx, scheduler.Finder{Status: "PENDING", Limit: 1, Offset: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, canceled)
+	})
+}
+
+func (s *databaseSuite) testDeleteMany(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should delete the jobs found, skipping the RUNNING ones", func(t *testing.T) {
+		db := s.init(t)
+		saved := bulkFixture(t, db, now())
+
+		deleted, err := db.DeleteMany(ctx, scheduler.Finder{Name: "MYMOCKJOB!", Limit: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, deleted)
+
+		js, _ := db.List(ctx, scheduler.Finder{})
+		assert.Equal(t, []string{saved[1], saved[3]}, ids(js))
+	})
+}
+
+func (s *databaseSuite) testRescheduleMany(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should add the delta to the next run of the jobs found, skipping the RUNNING ones", func(t *testing.T) {
+		db := s.init(t)
+		n := now()
+		saved := bulkFixture(t, db, n)
+
+		rescheduled, err := db.RescheduleMany(ctx, scheduler.Finder{Name: "MYMOCKJOB!", Limit: 1}, time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, rescheduled)
+
+		assertSameTime(t, n.Add(time.Hour), get(t, db, saved[0]).NextRunAt, "NextRunAt")
+		assertSameTime(t, n, get(t, db, saved[1]).NextRunAt, "NextRunAt")
+		assertSameTime(t, n.Add(time.Hour), get(t, db, saved[2]).NextRunAt, "NextRunAt")
+		assertSameTime(t, n, get(t, db, saved[3]).NextRunAt, "NextRunAt")
+
+		rescheduled, err = db.RescheduleMany(ctx, scheduler.Finder{Name: "MYMOCKJOB!"}, -30*time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, rescheduled)
+		assertSameTime(t, n.Add(30*time.Minute), get(t, db, saved[0]).NextRunAt, "NextRunAt")
+	})
+	t.Run("Should re-schedule nothing if the delta is zero", func(t *testing.T) {
+		db := s.init(t)
+		bulkFixture(t, db, now())
+
+		rescheduled, err := db.RescheduleMany(ctx, scheduler.Finder{}, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, rescheduled)
+	})
+}
+
+// runsFixture saves job runs that started at different times, and returns when the most recent one started
+func runsFixture(t *testing.T, db scheduler.JobDatabase) time.Time {
+	n := now()
+	runs := []scheduler.JobRun{
+		{JobID: "1", JobName: "MYMOCKJOB!", Attempt: 1, WorkerID: "my-worker", StartedAt: n.Add(-2 * time.Hour), FinishedAt: n.Add(-2 * time.Hour).Add(time.Second), Outcome: scheduler.RUN_FAILED, Error: "MOCK ERROR!"},
+		{JobID: "1", JobName: "MYMOCKJOB!", Attempt: 2, WorkerID: "my-worker", StartedAt: n.Add(-time.Hour), FinishedAt: n.Add(-time.Hour).Add(time.Second), Outcome: scheduler.RUN_SUCCEEDED},
+		{JobID: "2", JobName: "ANOTHERJOB!", Attempt: 1, WorkerID: "another-worker", StartedAt: n, FinishedAt: n.Add(time.Second), Outcome: scheduler.RUN_SUCCEEDED},
+	}
+
+	for _, r := range runs {
+		if !assert.NoError(t, db.SaveRun(context.Background(), r), "Failed to save the job run") {
+			t.FailNow()
+		}
+	}
+
+	return n
+}
+
+// attempts returns the job and the attempt of each job run, to identify them
+func attempts(rs []*scheduler.JobRun) []string {
+	attempts := []string{}
+	for _, r := range rs {
+		attempts = append(attempts, r.JobID+"#"+strconv.Itoa(r.Attempt))
+	}
+
+	return attempts
+}
+
+func (s *databaseSuite) testRuns(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should save every job run field", func(t *testing.T) {
+		db := s.init(t)
+		n := runsFixture(t, db)
+
+		rs, err := db.ListRuns(ctx, scheduler.RunFinder{JobID: "1", Outcome: scheduler.RUN_FAILED})
+		assert.NoError(t, err)
+		if !assert.Len(t, rs, 1) {
+			return
+		}
+
+		r := rs[0]
+		assert.NotEmpty(t, r.ID)
+		assert.Equal(t, "1", r.JobID)
+		assert.Equal(t, "MYMOCKJOB!", r.JobName)
+		assert.Equal(t, 1, r.Attempt)
+		assert.Equal(t, "my-worker", r.WorkerID)
+		assertSameTime(t, n.Add(-2*time.Hour), r.StartedAt, "StartedAt")
+		assertSameTime(t, n.Add(-2*time.Hour).Add(time.Second), r.FinishedAt, "FinishedAt")
+		assert.Equal(t, scheduler.RUN_FAILED, r.Outcome)
+		assert.Equal(t, "MOCK ERROR!", r.Error)
+	})
+	t.Run("Should list the most recent job runs first, given the run finder", func(t *testing.T) {
+		db := s.init(t)
+		n := runsFixture(t, db)
+
+		tests := []struct {
+			name     string
+			finder   scheduler.RunFinder
+			expected []string
+		}{
+			{"empty finder", scheduler.RunFinder{}, []string{"2#1", "1#2", "1#1"}},
+			{"job ID", scheduler.RunFinder{JobID: "1"}, []string{"1#2", "1#1"}},
+			{"job name", scheduler.RunFinder{JobName: "ANOTHERJOB!"}, []string{"2#1"}},
+			{"outcome", scheduler.RunFinder{Outcome: scheduler.RUN_SUCCEEDED}, []string{"2#1", "1#2"}},
+			{"started after, inclusive", scheduler.RunFinder{StartedAfter: n.Add(-time.Hour)}, []string{"2#1", "1#2"}},
+			{"started before, exclusive", scheduler.RunFinder{StartedBefore: n.Add(-time.Hour)}, []string{"1#1"}},
+			{"limit", scheduler.RunFinder{Limit: 2}, []string{"2#1", "1#2"}},
+			{"no match", scheduler.RunFinder{JobID: "2", Outcome: scheduler.RUN_FAILED}, []string{}},
+		}
+
+		for _, test := range tests {
+			rs, err := db.ListRuns(ctx, test.finder)
+			assert.NoError(t, err, test.name)
+			assert.Equal(t, test.expected, attempts(rs), test.name)
+		}
+	})
+}
+
+func (s *databaseSuite) testDeleteRunsBefore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Should delete only the job runs that started before the time", func(t *testing.T) {
+		db := s.init(t)
+		n := runsFixture(t, db)
+
+		deleted, err := db.DeleteRunsBefore(ctx, n.Add(-time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+
+		rs, _ := db.ListRuns(ctx, scheduler.RunFinder{})
+		assert.Equal(t, []string{"2#1", "1#2"}, attempts(rs))
+	})
+	t.Run("Should delete nothing if no job run started before the time", func(t *testing.T) {
+		db := s.init(t)
+		n := runsFixture(t, db)
+
+		deleted, err := db.DeleteRunsBefore(ctx, n.Add(-3*time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, 0, deleted)
+	})
+}
@@ -0,0 +1,107 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// startProcessing starts processing jobs on the provided rate, on the background
+func (s *Scheduler) startProcessing(rate time.Duration) {
+	s.lifecycleMu.Lock()
+	defer s.lifecycleMu.Unlock()
+
+	s.stopping = make(chan struct{})
+	s.processingDone = make(chan struct{})
+	s.jobsCtx, s.cancelJobs = context.WithCancel(context.Background())
+
+	go s.processJobs(rate, s.stopping, s.processingDone)
+}
+
+// Shutdown gracefully shuts down the scheduler.
+//
+// It stops picking new jobs and waits for the running jobs to finish, until the context is done.
+// If the context is done before the running jobs finish, the running jobs contexts are cancelled,
+// the jobs are released back as PENDING so that they can be picked by another instance, and an error is returned.
+func (s *Scheduler) Shutdown(ctx context.Context) (err error) {
+	s.lifecycleMu.Lock()
+	select {
+	case <-s.stopping:
+	default:
+		close(s.stopping)
+	}
+	done := s.processingDone
+	s.lifecycleMu.Unlock()
+
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			s.cancelRunningJobs()
+			return fmt.Errorf("Failed to stop processing jobs before shutting down, %v", ctx.Err())
+		}
+	}
+
+	select {
+	case <-s.runningJobsDone():
+		return
+	case <-ctx.Done():
+		s.cancelRunningJobs()
+		return fmt.Errorf("Failed to drain the running jobs before shutting down, %v", ctx.Err())
+	}
+}
+
+// Stop stops the scheduler, waiting for the running jobs to finish with no time limit.
+func (s *Scheduler) Stop() {
+	_ = s.Shutdown(context.Background())
+}
+
+// jobsContext returns the context from which the running jobs contexts derive
+func (s *Scheduler) jobsContext() context.Context {
+	s.lifecycleMu.Lock()
+	defer s.lifecycleMu.Unlock()
+
+	return s.jobsCtx
+}
+
+// cancelRunningJobs cancels the running jobs contexts, and releases them back as PENDING
+func (s *Scheduler) cancelRunningJobs() {
+	s.lifecycleMu.Lock()
+	s.cancelJobs()
+	s.lifecycleMu.Unlock()
+
+	s.releaseClaimedJobs()
+}
+
+// isStopping returns true if the scheduler is shutting down, or was already stopped
+func (s *Scheduler) isStopping() bool {
+	s.lifecycleMu.Lock()
+	defer s.lifecycleMu.Unlock()
+
+	select {
+	case <-s.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// releaseClaimedJobs sets the jobs that are still running back as PENDING on the database.
+//
+// A job is only released while it is still RUNNING and owned by this scheduler instance,
+// so that a job that just finished, or that was recovered and claimed by another instance, is not overwritten.
+func (s *Scheduler) releaseClaimedJobs() {
+	s.claimedJobsMu.Lock()
+	defer s.claimedJobsMu.Unlock()
+
+	for _, j := range s.claimedJobs {
+		j.Owner = s.workerID
+
+		ctx, cancel := s.dbContext()
+		_, err := s.db.ReleaseJob(ctx, j)
+		cancel()
+		if err != nil {
+			s.logger.Errorf("Failed to release job %s while shutting down, %v", j.ID, err)
+		}
+	}
+}
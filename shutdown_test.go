@@ -0,0 +1,153 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdown(t *testing.T) {
+	t.Run("Should wait for the running jobs to finish", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		mockJobName := "MYMOCKJOB!"
+		mockJobFunc := func(ctx context.Context, j *Job) error {
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		}
+
+		mockJob := Job{
+			Name:         mockJobName,
+			ScheduleType: SIMPLE,
+		}
+
+		s.Define(mockJobName, mockJobFunc)
+
+		dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+		dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+		s.startProcessing(time.Hour)
+		s.process()
+
+		err := s.Shutdown(context.Background())
+
+		assert.NoError(t, err)
+		assert.True(t, mockJob.IsDone())
+	})
+	t.Run("Should release the running jobs and return an error if the context is done before they finish", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		mockJobName := "MYMOCKJOB!"
+		proceed := make(chan struct{})
+		mockJobFunc := func(ctx context.Context, j *Job) error {
+			<-proceed
+			return nil
+		}
+
+		mockJob := Job{
+			ID:           "mock id",
+			Name:         mockJobName,
+			ScheduleType: SIMPLE,
+			Status:       PENDING,
+		}
+
+		s.Define(mockJobName, mockJobFunc)
+
+		dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+		dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+		s.startProcessing(time.Hour)
+		s.process()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		err := s.Shutdown(ctx)
+
+		assert.Error(t, err)
+		assert.False(t, dbMock.Method("FinishJob").Called())
+		if assert.True(t, dbMock.Method("ReleaseJob").CalledOnce()) {
+			// the job is only released if it is still owned by this scheduler instance
+			released := dbMock.Method("ReleaseJob").GetCalls()[0].Args[0].(Job)
+			assert.Equal(t, "mock id", released.ID)
+			assert.Equal(t, s.workerID, released.Owner)
+		}
+
+		close(proceed)
+		<-s.runningJobsDone()
+	})
+	t.Run("Should cancel the running jobs contexts if the context is done before they finish", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		mockJobName := "MYMOCKJOB!"
+		jobErr := make(chan error, 1)
+		mockJobFunc := func(ctx context.Context, j *Job) error {
+			<-ctx.Done()
+			jobErr <- ctx.Err()
+			return ctx.Err()
+		}
+
+		mockJob := Job{
+			Name:         mockJobName,
+			ScheduleType: SIMPLE,
+		}
+
+		s.Define(mockJobName, mockJobFunc)
+
+		dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+		dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+		s.startProcessing(time.Hour)
+		s.process()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		err := s.Shutdown(ctx)
+
+		assert.Error(t, err)
+		assert.ErrorIs(t, <-jobErr, context.Canceled)
+	})
+	t.Run("Should not pick new jobs after shutting down", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		s.workers = make(chan struct{})
+
+		mockJob := Job{
+			Name: "MYMOCKJOB!",
+		}
+
+		dbMock.SetMethodResponse("ListExpiredSchedules", []*Job{&mockJob}, nil)
+
+		s.startProcessing(time.Hour)
+		err := s.Shutdown(context.Background())
+		s.process()
+
+		assert.NoError(t, err)
+		assert.False(t, dbMock.Method("ClaimJob").Called())
+	})
+	t.Run("Should not pick new jobs after shutting down, even with free workers", func(t *testing.T) {
+		s, dbMock, _ := mockScheduler()
+
+		s.workers = make(chan struct{}, 10)
+		s.Define("MYMOCKJOB!", func(ctx context.Context, j *Job) error { return nil })
+
+		mockJobs := []*Job{}
+		for i := 0; i < 10; i++ {
+			mockJobs = append(mockJobs, &Job{Name: "MYMOCKJOB!", ScheduleType: SIMPLE})
+		}
+
+		dbMock.SetMethodResponse("ListExpiredSchedules", mockJobs, nil)
+		dbMock.SetMethodResponse("ClaimJob", true, nil)
+
+		s.startProcessing(time.Hour)
+		s.Stop()
+
+		err := s.ProcessCycle(context.Background())
+
+		assert.Error(t, err)
+		assert.False(t, dbMock.Method("ClaimJob").Called())
+	})
+}
@@ -6,15 +6,23 @@ import (
 )
 
 type simpleScheduleDefinition struct {
-	nextRunAt time.Time
+	scheduler   *Scheduler
+	nextRunAt   time.Time
+	retryPolicy *RetryPolicy
+	timeout     time.Duration
+
+	unique         bool
+	uniqueKey      string
+	conflictPolicy ConflictPolicy
 }
 
 // Do effectivelly schedules the job on the database to run in the configured time, given the job name.
 //
 // You can also provide extra data that will be saved with the job.
-func (ssd *simpleScheduleDefinition) Do(jobName string, data ...map[string]any) (err error) {
-	jobFunc := jobDefinitions[jobName]
-	if jobFunc == nil {
+//
+// Returns the saved job, with the ID assigned by the database.
+func (ssd *simpleScheduleDefinition) Do(jobName string, data ...map[string]any) (j *Job, err error) {
+	if ssd.scheduler.getJobDefinition(jobName) == nil {
 		err = fmt.Errorf("No job definition with the name %s was found", jobName)
 		return
 	}
@@ -28,9 +36,58 @@ func (ssd *simpleScheduleDefinition) Do(jobName string, data ...map[string]any)
 		Status:       PENDING,
 		ScheduleType: SIMPLE,
 		NextRunAt:    ssd.nextRunAt,
+		RetryPolicy:  ssd.retryPolicy,
+		Timeout:      ssd.timeout,
 		Name:         jobName,
 		Data:         d,
 	}
 
-	return db.SaveJob(job)
+	if ssd.unique {
+		job.UniqueKey, err = jobUniqueKey(ssd.uniqueKey, jobName, d)
+		if err != nil {
+			return
+		}
+	}
+
+	return ssd.scheduler.createJob(job, ssd.conflictPolicy)
+}
+
+// Retry sets the retry policy that should be used when the job function fails,
+// overriding the retry policy configured in the library instantiation.
+func (ssd *simpleScheduleDefinition) Retry(rp RetryPolicy) *simpleScheduleDefinition {
+	ssd.retryPolicy = &rp
+	return ssd
+}
+
+// Timeout sets how long the job function can run before its context is cancelled and the run is considered TIMED_OUT,
+// overriding the timeout of the job definition and the one configured in the library instantiation.
+//
+// A job function that ignores its cancelled context is abandoned after a grace period (See Config.JobTimeout).
+func (ssd *simpleScheduleDefinition) Timeout(d time.Duration) *simpleScheduleDefinition {
+	ssd.timeout = d
+	return ssd
+}
+
+// Unique makes the job unique given the key, so that scheduling it again does not create a duplicate job
+// (Ex.: when the job is scheduled on every application start).
+//
+// If the key is empty, the key is derived from the job name and data.
+//
+// When another job already holds the key, the existing job is kept by default, see OnConflict to change that.
+func (ssd *simpleScheduleDefinition) Unique(key string) *simpleScheduleDefinition {
+	ssd.unique = true
+	ssd.uniqueKey = key
+	return ssd
+}
+
+// OnConflict sets what happens when the Unique job key is already used by another job:
+//
+// - KEEP: the existing job is kept and returned by Do (default), even if it is already DONE or CANCELED
+//
+// - REPLACE: the existing job is replaced by the new job, keeping its ID (a RUNNING job can't be replaced)
+//
+// - REJECT: the existing job is kept, and Do returns ErrDuplicateJob
+func (ssd *simpleScheduleDefinition) OnConflict(cp ConflictPolicy) *simpleScheduleDefinition {
+	ssd.conflictPolicy = cp
+	return ssd
 }
@@ -1,12 +1,60 @@
 package scheduler
 
-import "time"
-
-var (
-	location = time.UTC
+import (
+	"fmt"
+	"time"
 )
 
-// now returns the current time in the given location that is configured in the library
-func now() time.Time {
-	return time.Now().In(location)
+// now returns the current time in the location that is configured in the scheduler
+func (s *Scheduler) now() time.Time {
+	return s.clock.Now().In(s.location)
+}
+
+// nextScheduleDate returns the date of the next execution of the schedule string after now,
+// evaluated on the given location, or on the location configured in the scheduler if no location is given.
+//
+// The time intervals of the schedule string (Ex.: "1 month") are counted from the anchor.
+func (s *Scheduler) nextScheduleDate(schedule, location string, anchor time.Time) (time.Time, error) {
+	now := s.now()
+	if location != "" {
+		l, err := time.LoadLocation(location)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("Failed to load the job location '%s', %v", location, err)
+		}
+
+		now = now.In(l)
+	}
+
+	return getNextAnchoredScheduleDate(schedule, anchor.In(now.Location()), now)
+}
+
+// dateIn returns the time of the wall clock on the location, as time.Date does,
+// but the wall clocks skipped by a DST transition are shifted forward by the length of the transition
+// (Ex.: 02:30 becomes 03:30), instead of resolving to a time before the transition.
+//
+// The wall clocks repeated by a DST transition resolve to their first occurrence.
+func dateIn(year int, month time.Month, day, hour, min, sec int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, min, sec, 0, loc)
+
+	wanted := time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+	got := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	if gap := wanted.Sub(got); gap > 0 {
+		return t.Add(gap)
+	}
+
+	return t
+}
+
+// laterThan returns t, moved to the second occurrence of its wall clock if t is not after prev.
+//
+// It guarantees that a wall clock repeated by a DST transition is not resolved
+// to its first occurrence when prev is already on the second one.
+func laterThan(prev, t time.Time) time.Time {
+	if t.After(prev) {
+		return t
+	}
+
+	_, prevOffset := prev.Zone()
+	_, offset := t.Zone()
+	return t.Add(time.Duration(offset-prevOffset) * time.Second)
 }
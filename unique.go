@@ -0,0 +1,56 @@
+package scheduler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrDuplicateJob is returned when a job is scheduled with the REJECT conflict policy,
+// and its unique key is already used by another job
+var ErrDuplicateJob = errors.New("Duplicate job")
+
+// jobUniqueKey returns the given unique key,
+// or a key derived from the job name and data if no key is given
+func jobUniqueKey(key, jobName string, data map[string]any) (string, error) {
+	if key != "" {
+		return key, nil
+	}
+
+	// the map keys are sorted when marshalled, so the same data always generates the same key
+	b, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("Failed to generate the job unique key, %v", err)
+	}
+
+	sum := sha256.Sum256(b)
+	return jobName + ":" + hex.EncodeToString(sum[:]), nil
+}
+
+// saveUniqueJob saves a new job that has a unique key on the job database,
+// resolving the conflict with an existing job with the same unique key according to the conflict policy.
+//
+// Returns the saved job, or the existing job when it is kept.
+func (s *Scheduler) saveUniqueJob(j Job, cp ConflictPolicy) (*Job, error) {
+	ctx, cancel := s.dbContext()
+	defer cancel()
+
+	id, saved, err := s.db.SaveUniqueJob(ctx, j, cp == REPLACE)
+	if err != nil {
+		return nil, err
+	}
+
+	if saved {
+		j.ID = id
+		j.scheduler = s
+		return &j, nil
+	}
+
+	if cp == REJECT {
+		return nil, ErrDuplicateJob
+	}
+
+	return s.Get(id)
+}
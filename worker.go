@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"fmt"
+	"os"
+)
+
+// defaultWorkerID returns a worker ID composed by the machine hostname and the process ID
+func defaultWorkerID() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "go-scheduler"
+	}
+
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// trackRunningJob keeps track of a job that was claimed and is about to run
+func (s *Scheduler) trackRunningJob(j *Job) {
+	s.claimedJobsMu.Lock()
+	defer s.claimedJobsMu.Unlock()
+
+	if len(s.claimedJobs) == 0 {
+		s.idle = make(chan struct{})
+	}
+	s.claimedJobs[j] = *j
+}
+
+// untrackRunningJob stops keeping track of a job that finished running
+func (s *Scheduler) untrackRunningJob(j *Job) {
+	s.claimedJobsMu.Lock()
+	defer s.claimedJobsMu.Unlock()
+
+	delete(s.claimedJobs, j)
+	if len(s.claimedJobs) == 0 {
+		close(s.idle)
+	}
+}
+
+// abandonJobFunc counts the job function that did not return after its context was done as abandoned, until it returns.
+//
+// Returns how many abandoned job functions are still running.
+func (s *Scheduler) abandonJobFunc(returned <-chan struct{}) int {
+	s.claimedJobsMu.Lock()
+	defer s.claimedJobsMu.Unlock()
+
+	s.abandonedJobFuncs++
+	go func() {
+		<-returned
+
+		s.claimedJobsMu.Lock()
+		defer s.claimedJobsMu.Unlock()
+
+		s.abandonedJobFuncs--
+	}()
+
+	return s.abandonedJobFuncs
+}
+
+// runningJobsDone returns a channel that is closed when there are no jobs running on the scheduler
+func (s *Scheduler) runningJobsDone() <-chan struct{} {
+	s.claimedJobsMu.Lock()
+	defer s.claimedJobsMu.Unlock()
+
+	return s.idle
+}
+
+// closedChannel returns an already closed channel
+func closedChannel() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}